@@ -13,7 +13,8 @@ import (
 )
 
 type AppEventsRepository interface {
-	ListEvents(appGuid string, cb func(models.EventFields) bool) net.ApiResponse
+	ListEvents(appGuid string, query models.EventQuery, cb func(models.EventFields) bool) net.ApiResponse
+	ListSpaceEvents(spaceGuid string, query models.EventQuery, cb func(appName string, event models.EventFields) bool) net.ApiResponse
 }
 
 type CloudControllerAppEventsRepository struct {
@@ -27,11 +28,11 @@ func NewCloudControllerAppEventsRepository(config configuration.Reader, gateway
 	return
 }
 
-func (repo CloudControllerAppEventsRepository) ListEvents(appGuid string, cb func(models.EventFields) bool) net.ApiResponse {
+func (repo CloudControllerAppEventsRepository) ListEvents(appGuid string, query models.EventQuery, cb func(models.EventFields) bool) net.ApiResponse {
 	apiResponse := repo.gateway.ListPaginatedResources(
 		repo.config.ApiEndpoint(),
 		repo.config.AccessToken(),
-		fmt.Sprintf("/v2/events?q=%s", url.QueryEscape(fmt.Sprintf("actee:%s", appGuid))),
+		"/v2/events?"+eventsQueryString("actee:"+appGuid, query),
 		EventResourceNewV2{},
 		func(resource interface{}) bool {
 			return cb(resource.(EventResourceNewV2).ToFields())
@@ -39,20 +40,69 @@ func (repo CloudControllerAppEventsRepository) ListEvents(appGuid string, cb fun
 
 	// FIXME: needs semantic versioning
 	if apiResponse.IsNotFound() {
+		// the old endpoint only knows about crashes and cannot filter them
 		apiResponse = repo.gateway.ListPaginatedResources(
 			repo.config.ApiEndpoint(),
 			repo.config.AccessToken(),
 			fmt.Sprintf("/v2/apps/%s/events", appGuid),
 			EventResourceOldV2{},
 			func(resource interface{}) bool {
-				return cb(resource.(EventResourceOldV2).ToFields())
+				event := resource.(EventResourceOldV2).ToFields()
+				if !query.Includes(event, APP_CRASH_EVENT_TYPE) {
+					return true
+				}
+				return cb(event)
 			})
 	}
 
 	return apiResponse
 }
 
-const APP_EVENT_TIMESTAMP_FORMAT = "2006-01-02T15:04:05-07:00"
+// ListSpaceEvents lists the app events of a space in one query, so that it also finds those of
+// deleted apps. Old Cloud Controllers only list events per app, so the response is not found
+// there and callers fall back to ListEvents.
+func (repo CloudControllerAppEventsRepository) ListSpaceEvents(spaceGuid string, query models.EventQuery, cb func(appName string, event models.EventFields) bool) net.ApiResponse {
+	return repo.gateway.ListPaginatedResources(
+		repo.config.ApiEndpoint(),
+		repo.config.AccessToken(),
+		"/v2/events?"+eventsQueryString("space_guid:"+spaceGuid, query),
+		EventResourceNewV2{},
+		func(resource interface{}) bool {
+			event := resource.(EventResourceNewV2)
+			if event.Entity.ActeeType != APP_ACTEE_TYPE {
+				return true
+			}
+			return cb(event.Entity.ActeeName, event.ToFields())
+		})
+}
+
+// eventsQueryString has a q parameter for the given filter and each filter of the query, which
+// the Cloud Controller combines with and.
+func eventsQueryString(filter string, query models.EventQuery) string {
+	filters := []string{filter}
+	if !query.Since.IsZero() {
+		filters = append(filters, fmt.Sprintf("timestamp>=%s", query.Since.UTC().Format(EVENT_QUERY_TIMESTAMP_FORMAT)))
+	}
+	if !query.Until.IsZero() {
+		filters = append(filters, fmt.Sprintf("timestamp<=%s", query.Until.UTC().Format(EVENT_QUERY_TIMESTAMP_FORMAT)))
+	}
+	if len(query.Types) > 0 {
+		filters = append(filters, fmt.Sprintf("type IN %s", strings.Join(query.Types, ",")))
+	}
+
+	params := []string{}
+	for _, filter := range filters {
+		params = append(params, "q="+url.QueryEscape(filter))
+	}
+	return strings.Join(params, "&")
+}
+
+const (
+	APP_EVENT_TIMESTAMP_FORMAT   = "2006-01-02T15:04:05-07:00"
+	EVENT_QUERY_TIMESTAMP_FORMAT = "2006-01-02T15:04:05Z"
+	APP_CRASH_EVENT_TYPE         = "app.crash"
+	APP_ACTEE_TYPE               = "app"
+)
 
 // FIXME: needs semantic versioning
 type EventResourceOldV2 struct {
@@ -79,6 +129,8 @@ type EventResourceNewV2 struct {
 	Entity struct {
 		Timestamp time.Time
 		Type      string
+		ActeeType string `json:"actee_type"`
+		ActeeName string `json:"actee_name"`
 		Metadata  map[string]interface{}
 	}
 }
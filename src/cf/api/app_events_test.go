@@ -131,7 +131,7 @@ var _ = Describe("App Events Repo", func() {
 		}
 
 		list := []models.EventFields{}
-		apiResponse := repo.ListEvents("my-app-guid", func(event models.EventFields) bool {
+		apiResponse := repo.ListEvents("my-app-guid", models.EventQuery{}, func(event models.EventFields) bool {
 			list = append(list, event)
 			return true
 		})
@@ -207,7 +207,7 @@ var _ = Describe("App Events Repo", func() {
 		repo := NewCloudControllerAppEventsRepository(deps.config, deps.gateway)
 
 		events := []models.EventFields{}
-		apiResponse := repo.ListEvents("my-app-guid", func(e models.EventFields) bool {
+		apiResponse := repo.ListEvents("my-app-guid", models.EventQuery{}, func(e models.EventFields) bool {
 			events = append(events, e)
 			return true
 		})
@@ -233,7 +233,7 @@ var _ = Describe("App Events Repo", func() {
 		repo := NewCloudControllerAppEventsRepository(deps.config, deps.gateway)
 
 		list := []models.EventFields{}
-		apiResponse := repo.ListEvents("my-app-guid", func(e models.EventFields) bool {
+		apiResponse := repo.ListEvents("my-app-guid", models.EventQuery{}, func(e models.EventFields) bool {
 			list = append(list, e)
 			return true
 		})
@@ -254,6 +254,143 @@ var _ = Describe("App Events Repo", func() {
 		Expect(deps.handler.AllRequestsCalled()).To(BeTrue())
 	})
 
+	It("turns the time range and types of the query into filters", func() {
+		deps := setupEventTest([]testnet.TestRequest{
+			testnet.TestRequest{
+				Method: "GET",
+				Path:   "/v2/events?q=actee%3Amy-app-guid&q=timestamp%3E%3D2014-01-21T00%3A00%3A00Z&q=timestamp%3C%3D2014-01-22T12%3A30%3A00Z&q=type+IN+app.crash%2Caudit.app.update",
+				Response: testnet.TestResponse{
+					Status: http.StatusOK,
+					Body:   `{"resources": [{"metadata": {"guid": "event-1-guid"}, "entity": {"type": "app.crash", "metadata": {}}}]}`,
+				},
+			},
+		})
+		defer teardownEventTest(deps)
+
+		repo := NewCloudControllerAppEventsRepository(deps.config, deps.gateway)
+
+		query := models.EventQuery{
+			Since: testtime.MustParse(APP_EVENT_TIMESTAMP_FORMAT, "2014-01-21T01:00:00+01:00"),
+			Until: testtime.MustParse(APP_EVENT_TIMESTAMP_FORMAT, "2014-01-22T12:30:00+00:00"),
+			Types: []string{"app.crash", "audit.app.update"},
+		}
+		events := []models.EventFields{}
+		apiResponse := repo.ListEvents("my-app-guid", query, func(e models.EventFields) bool {
+			events = append(events, e)
+			return true
+		})
+
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(deps.handler.AllRequestsCalled()).To(BeTrue())
+		Expect(len(events)).To(Equal(1))
+		Expect(events[0].Guid).To(Equal("event-1-guid"))
+	})
+
+	It("lists the app events of a space with one query", func() {
+		deps := setupEventTest([]testnet.TestRequest{
+			testnet.TestRequest{
+				Method: "GET",
+				Path:   "/v2/events?q=space_guid%3Amy-space-guid&q=type+IN+app.crash%2Caudit.app.delete-request",
+				Response: testnet.TestResponse{
+					Status: http.StatusOK,
+					Body: `{"resources": [
+  {"metadata": {"guid": "event-1-guid"}, "entity": {"type": "app.crash", "actee_type": "app", "actee_name": "my-app", "metadata": {}}},
+  {"metadata": {"guid": "event-2-guid"}, "entity": {"type": "audit.space.update", "actee_type": "space", "actee_name": "my-space", "metadata": {}}},
+  {"metadata": {"guid": "event-3-guid"}, "entity": {"type": "audit.app.delete-request", "actee_type": "app", "actee_name": "deleted-app", "metadata": {}}}
+]}`,
+				},
+			},
+		})
+		defer teardownEventTest(deps)
+
+		repo := NewCloudControllerAppEventsRepository(deps.config, deps.gateway)
+
+		query := models.EventQuery{Types: []string{"app.crash", "audit.app.delete-request"}}
+		appNames := []string{}
+		events := []models.EventFields{}
+		apiResponse := repo.ListSpaceEvents("my-space-guid", query, func(appName string, e models.EventFields) bool {
+			appNames = append(appNames, appName)
+			events = append(events, e)
+			return true
+		})
+
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(deps.handler.AllRequestsCalled()).To(BeTrue())
+		Expect(appNames).To(Equal([]string{"my-app", "deleted-app"}))
+		Expect(events[0].Guid).To(Equal("event-1-guid"))
+		Expect(events[1].Name).To(Equal("audit.app.delete-request"))
+	})
+
+	It("returns not found when the space events cannot be listed", func() {
+		deps := setupEventTest([]testnet.TestRequest{
+			testnet.TestRequest{
+				Method:   "GET",
+				Path:     "/v2/events?q=space_guid%3Amy-space-guid",
+				Response: testnet.TestResponse{Status: http.StatusNotFound},
+			},
+		})
+		defer teardownEventTest(deps)
+
+		repo := NewCloudControllerAppEventsRepository(deps.config, deps.gateway)
+
+		apiResponse := repo.ListSpaceEvents("my-space-guid", models.EventQuery{}, func(appName string, e models.EventFields) bool {
+			return true
+		})
+
+		Expect(apiResponse.IsNotFound()).To(BeTrue())
+		Expect(deps.handler.AllRequestsCalled()).To(BeTrue())
+	})
+
+	It("filters the events of the old endpoint by the query", func() {
+		deps := setupEventTest([]testnet.TestRequest{
+			testnet.TestRequest{
+				Method:   "GET",
+				Path:     "/v2/events",
+				Response: testnet.TestResponse{Status: http.StatusNotFound},
+			},
+			firstPageOldV2EventsRequest,
+			secondPageOldV2EventsRequest,
+		})
+		defer teardownEventTest(deps)
+
+		repo := NewCloudControllerAppEventsRepository(deps.config, deps.gateway)
+
+		query := models.EventQuery{Since: testtime.MustParse(APP_EVENT_TIMESTAMP_FORMAT, "2013-10-07T17:00:00+00:00")}
+		events := []models.EventFields{}
+		apiResponse := repo.ListEvents("my-app-guid", query, func(e models.EventFields) bool {
+			events = append(events, e)
+			return true
+		})
+
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(len(events)).To(Equal(1))
+		Expect(events[0].Description).To(ContainSubstring("instance: 2"))
+	})
+
+	It("lists no events of the old endpoint when only other types than crashes are asked for", func() {
+		deps := setupEventTest([]testnet.TestRequest{
+			testnet.TestRequest{
+				Method:   "GET",
+				Path:     "/v2/events",
+				Response: testnet.TestResponse{Status: http.StatusNotFound},
+			},
+			firstPageOldV2EventsRequest,
+			secondPageOldV2EventsRequest,
+		})
+		defer teardownEventTest(deps)
+
+		repo := NewCloudControllerAppEventsRepository(deps.config, deps.gateway)
+
+		events := []models.EventFields{}
+		apiResponse := repo.ListEvents("my-app-guid", models.EventQuery{Types: []string{"audit.app.update"}}, func(e models.EventFields) bool {
+			events = append(events, e)
+			return true
+		})
+
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(events).To(BeEmpty())
+	})
+
 	It("TestUnmarshalNewCrashEvent", func() {
 		resource := new(EventResourceNewV2)
 		err := json.Unmarshal([]byte(`
@@ -4,10 +4,27 @@ import (
 	"cf/configuration"
 	"cf/net"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
 )
 
 type AppFilesRepository interface {
-	ListFiles(appGuid, path string) (files string, apiResponse net.ApiResponse)
+	ListFiles(appGuid string, instance int, path string) (files string, apiResponse net.ApiResponse)
+	DownloadFile(appGuid string, instance int, path string, destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse)
+	ReadFileRange(appGuid string, instance int, path string, offset int64) (fileRange FileRange, apiResponse net.ApiResponse)
+}
+
+// FileRange is a part of a file, from Offset to the end of the file. Size is the size of the whole
+// file, or -1 when the server did not say. PastEnd is set when the offset asked for was at or past
+// the end of the file, so that there were no contents to send.
+type FileRange struct {
+	Contents []byte
+	Offset   int64
+	Size     int64
+	PastEnd  bool
 }
 
 type CloudControllerAppFilesRepository struct {
@@ -21,9 +38,8 @@ func NewCloudControllerAppFilesRepository(config configuration.Reader, gateway n
 	return
 }
 
-func (repo CloudControllerAppFilesRepository) ListFiles(appGuid, path string) (files string, apiResponse net.ApiResponse) {
-	url := fmt.Sprintf("%s/v2/apps/%s/instances/0/files/%s", repo.config.ApiEndpoint(), appGuid, path)
-	request, apiResponse := repo.gateway.NewRequest("GET", url, repo.config.AccessToken(), nil)
+func (repo CloudControllerAppFilesRepository) ListFiles(appGuid string, instance int, path string) (files string, apiResponse net.ApiResponse) {
+	request, apiResponse := repo.gateway.NewRequest("GET", repo.filesUrl(appGuid, instance, path), repo.config.AccessToken(), nil)
 	if apiResponse.IsNotSuccessful() {
 		return
 	}
@@ -31,3 +47,93 @@ func (repo CloudControllerAppFilesRepository) ListFiles(appGuid, path string) (f
 	files, _, apiResponse = repo.gateway.PerformRequestForTextResponse(request)
 	return
 }
+
+// DownloadFile writes the contents of the file at path in the given instance to destination byte
+// for byte, so that binary files such as core dumps arrive intact.
+func (repo CloudControllerAppFilesRepository) DownloadFile(appGuid string, instance int, path string, destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	return downloadResource(repo.gateway, repo.filesUrl(appGuid, instance, path), repo.config.AccessToken(), destination, progressCb)
+}
+
+// ReadFileRange reads the file at path from offset to its end with a Range request. A negative
+// offset reads that many bytes from the end of the file.
+func (repo CloudControllerAppFilesRepository) ReadFileRange(appGuid string, instance int, path string, offset int64) (fileRange FileRange, apiResponse net.ApiResponse) {
+	request, apiResponse := repo.gateway.NewRequest("GET", repo.filesUrl(appGuid, instance, path), repo.config.AccessToken(), nil)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+
+	if offset < 0 {
+		request.HttpReq.Header.Set("Range", fmt.Sprintf("bytes=%d", offset))
+	} else {
+		request.HttpReq.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	response, apiResponse := repo.gateway.PerformRequestForResponse(request)
+	if response != nil && response.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		response.Body.Close()
+		_, _, fileRange.Size = parseContentRange(response.Header.Get("Content-Range"))
+		fileRange.Offset = offset
+		fileRange.PastEnd = true
+		apiResponse = net.NewSuccessfulApiResponse()
+		return
+	}
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+	defer response.Body.Close()
+
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		apiResponse = net.NewApiResponseWithError("Error reading file", err)
+		return
+	}
+
+	if response.StatusCode == http.StatusPartialContent {
+		start, found, size := parseContentRange(response.Header.Get("Content-Range"))
+		if found {
+			fileRange = FileRange{Contents: contents, Offset: start, Size: size}
+			return
+		}
+	}
+
+	// the server sent the whole file, the part asked for is cut out of it here
+	fileRange = FileRange{Offset: offset, Size: int64(len(contents))}
+	if offset < 0 {
+		fileRange.Offset = fileRange.Size + offset
+		if fileRange.Offset < 0 {
+			fileRange.Offset = 0
+		}
+	} else if offset >= fileRange.Size {
+		fileRange.PastEnd = true
+		return
+	}
+	fileRange.Contents = contents[fileRange.Offset:]
+	return
+}
+
+// parseContentRange reads a Content-Range header such as "bytes 100-199/1000" or "bytes */1000".
+func parseContentRange(header string) (start int64, found bool, size int64) {
+	size = -1
+
+	rangeAndSize := strings.SplitN(strings.TrimPrefix(header, "bytes "), "/", 2)
+	if len(rangeAndSize) != 2 {
+		return
+	}
+
+	if parsedSize, err := strconv.ParseInt(rangeAndSize[1], 10, 64); err == nil {
+		size = parsedSize
+	}
+
+	startAndEnd := strings.SplitN(rangeAndSize[0], "-", 2)
+	if len(startAndEnd) != 2 {
+		return
+	}
+
+	start, err := strconv.ParseInt(startAndEnd[0], 10, 64)
+	found = err == nil
+	return
+}
+
+func (repo CloudControllerAppFilesRepository) filesUrl(appGuid string, instance int, path string) string {
+	return fmt.Sprintf("%s/v2/apps/%s/instances/%d/files/%s", repo.config.ApiEndpoint(), appGuid, instance, strings.TrimPrefix(path, "/"))
+}
@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"bytes"
 	. "cf/api"
 	"cf/net"
 	"fmt"
@@ -8,9 +9,11 @@ import (
 	. "github.com/onsi/gomega"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	testapi "testhelpers/api"
 	testconfig "testhelpers/configuration"
 	testnet "testhelpers/net"
+	"time"
 )
 
 var _ = Describe("AppFilesRepository", func() {
@@ -56,10 +59,160 @@ var _ = Describe("AppFilesRepository", func() {
 
 		gateway := net.NewCloudControllerGateway()
 		repo := NewCloudControllerAppFilesRepository(configRepo, gateway)
-		list, err := repo.ListFiles("my-app-guid", "some/path")
+		list, err := repo.ListFiles("my-app-guid", 0, "some/path")
 
 		Expect(handler.AllRequestsCalled()).To(BeTrue())
 		Expect(err.IsNotSuccessful()).To(BeFalse())
 		Expect(list).To(Equal(expectedResponse))
 	})
+
+	It("lists the files of the instance with the given index", func() {
+		ts, handler, repo := createAppFilesRepo([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "GET",
+				Path:     "/v2/apps/my-app-guid/instances/2/files/app/logs",
+				Response: testnet.TestResponse{Status: http.StatusOK, Body: "stdout.log     1.2K"},
+			}),
+		})
+		defer ts.Close()
+
+		list, apiResponse := repo.ListFiles("my-app-guid", 2, "/app/logs")
+
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(list).To(Equal("stdout.log     1.2K\n"))
+	})
+
+	It("downloads files byte for byte", func() {
+		contents := "\x00\xff\r\n\x1f\x8bbinary"
+		fileServer := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Write([]byte(contents))
+		}))
+		defer fileServer.Close()
+
+		ts, handler, repo := createAppFilesRepo([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method: "GET",
+				Path:   "/v2/apps/my-app-guid/instances/1/files/app/core",
+				Response: testnet.TestResponse{
+					Status: http.StatusTemporaryRedirect,
+					Header: http.Header{"Location": {fileServer.URL + "/app/core"}},
+				},
+			}),
+		})
+		defer ts.Close()
+
+		destination := &bytes.Buffer{}
+		apiResponse := repo.DownloadFile("my-app-guid", 1, "/app/core", destination, nil)
+
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(destination.Bytes()).To(Equal([]byte(contents)))
+	})
+
+	It("fails to download files that are not found", func() {
+		ts, handler, repo := createAppFilesRepo([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "GET",
+				Path:     "/v2/apps/my-app-guid/instances/0/files/app/missing",
+				Response: testnet.TestResponse{Status: http.StatusNotFound},
+			}),
+		})
+		defer ts.Close()
+
+		apiResponse := repo.DownloadFile("my-app-guid", 0, "app/missing", &bytes.Buffer{}, nil)
+
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsNotSuccessful()).To(BeTrue())
+	})
+
+	Describe("reading ranges of files", func() {
+		var (
+			rangeHeaders []string
+			ignoreRanges bool
+			ts           *httptest.Server
+			repo         AppFilesRepository
+		)
+
+		BeforeEach(func() {
+			rangeHeaders = []string{}
+			ignoreRanges = false
+
+			ts = httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				if request.URL.Path != "/v2/apps/my-app-guid/instances/1/files/app/log.txt" {
+					writer.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				rangeHeaders = append(rangeHeaders, request.Header.Get("Range"))
+				if ignoreRanges {
+					writer.Write([]byte("0123456789"))
+					return
+				}
+				http.ServeContent(writer, request, "log.txt", time.Now(), strings.NewReader("0123456789"))
+			}))
+
+			configRepo := testconfig.NewRepositoryWithDefaults()
+			configRepo.SetApiEndpoint(ts.URL)
+			repo = NewCloudControllerAppFilesRepository(configRepo, net.NewCloudControllerGateway())
+		})
+
+		AfterEach(func() {
+			ts.Close()
+		})
+
+		It("reads from an offset to the end of the file", func() {
+			fileRange, apiResponse := repo.ReadFileRange("my-app-guid", 1, "/app/log.txt", 3)
+
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(rangeHeaders).To(Equal([]string{"bytes=3-"}))
+			Expect(fileRange).To(Equal(FileRange{Contents: []byte("3456789"), Offset: 3, Size: 10}))
+		})
+
+		It("reads the end of the file with a negative offset", func() {
+			fileRange, apiResponse := repo.ReadFileRange("my-app-guid", 1, "/app/log.txt", -4)
+
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(rangeHeaders).To(Equal([]string{"bytes=-4"}))
+			Expect(fileRange).To(Equal(FileRange{Contents: []byte("6789"), Offset: 6, Size: 10}))
+		})
+
+		It("says when the offset is past the end of the file", func() {
+			fileRange, apiResponse := repo.ReadFileRange("my-app-guid", 1, "/app/log.txt", 12)
+
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(fileRange.PastEnd).To(BeTrue())
+			Expect(fileRange.Contents).To(BeEmpty())
+			Expect(fileRange.Size).To(Equal(int64(10)))
+		})
+
+		It("cuts the range out of the file when the server sends all of it", func() {
+			ignoreRanges = true
+
+			fileRange, apiResponse := repo.ReadFileRange("my-app-guid", 1, "/app/log.txt", 3)
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(fileRange).To(Equal(FileRange{Contents: []byte("3456789"), Offset: 3, Size: 10}))
+
+			fileRange, _ = repo.ReadFileRange("my-app-guid", 1, "/app/log.txt", -4)
+			Expect(fileRange).To(Equal(FileRange{Contents: []byte("6789"), Offset: 6, Size: 10}))
+
+			fileRange, _ = repo.ReadFileRange("my-app-guid", 1, "/app/log.txt", 10)
+			Expect(fileRange.PastEnd).To(BeTrue())
+		})
+
+		It("fails when the file is not found", func() {
+			_, apiResponse := repo.ReadFileRange("my-app-guid", 1, "/app/missing.txt", 0)
+
+			Expect(apiResponse.IsNotFound()).To(BeTrue())
+		})
+	})
 })
+
+func createAppFilesRepo(requests []testnet.TestRequest) (ts *httptest.Server, handler *testnet.TestHandler, repo AppFilesRepository) {
+	ts, handler = testnet.NewTLSServer(requests)
+	configRepo := testconfig.NewRepositoryWithDefaults()
+	configRepo.SetApiEndpoint(ts.URL)
+	gateway := net.NewCloudControllerGateway()
+	repo = NewCloudControllerAppFilesRepository(configRepo, gateway)
+	return
+}
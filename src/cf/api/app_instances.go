@@ -33,6 +33,7 @@ type InstanceStatsApiResponse struct {
 
 type AppInstancesRepository interface {
 	GetInstances(appGuid string) (instances []models.AppInstanceFields, apiResponse net.ApiResponse)
+	DeleteInstance(appGuid string, index int) (apiResponse net.ApiResponse)
 }
 
 type CloudControllerAppInstancesRepository struct {
@@ -63,7 +64,7 @@ func (repo CloudControllerAppInstancesRepository) GetInstances(appGuid string) (
 	instances = make([]models.AppInstanceFields, len(instancesResponse), len(instancesResponse))
 	for k, v := range instancesResponse {
 		index, err := strconv.Atoi(k)
-		if err != nil {
+		if err != nil || index < 0 || index >= len(instances) {
 			continue
 		}
 
@@ -76,6 +77,13 @@ func (repo CloudControllerAppInstancesRepository) GetInstances(appGuid string) (
 	return repo.updateInstancesWithStats(appGuid, instances)
 }
 
+// DeleteInstance stops the instance at index, after which the app is brought back to its number
+// of instances by starting a new one in its place.
+func (repo CloudControllerAppInstancesRepository) DeleteInstance(appGuid string, index int) (apiResponse net.ApiResponse) {
+	path := fmt.Sprintf("%s/v2/apps/%s/instances/%d", repo.config.ApiEndpoint(), appGuid, index)
+	return repo.gateway.DeleteResource(path, repo.config.AccessToken())
+}
+
 func (repo CloudControllerAppInstancesRepository) updateInstancesWithStats(guid string, instances []models.AppInstanceFields) (updatedInst []models.AppInstanceFields, apiResponse net.ApiResponse) {
 	path := fmt.Sprintf("%s/v2/apps/%s/stats", repo.config.ApiEndpoint(), guid)
 	statsResponse := StatsApiResponse{}
@@ -84,21 +92,22 @@ func (repo CloudControllerAppInstancesRepository) updateInstancesWithStats(guid
 		return
 	}
 
-	updatedInst = make([]models.AppInstanceFields, len(statsResponse), len(statsResponse))
+	// the instances and their stats are read one after the other, so they can disagree on which
+	// instances there are while the app is being scaled
+	updatedInst = make([]models.AppInstanceFields, len(instances), len(instances))
+	copy(updatedInst, instances)
 	for k, v := range statsResponse {
 		index, err := strconv.Atoi(k)
-		if err != nil {
+		if err != nil || index < 0 || index >= len(updatedInst) {
 			continue
 		}
 
-		instance := instances[index]
+		instance := &updatedInst[index]
 		instance.CpuUsage = v.Stats.Usage.Cpu
 		instance.DiskQuota = v.Stats.DiskQuota
 		instance.DiskUsage = v.Stats.Usage.Disk
 		instance.MemQuota = v.Stats.MemQuota
 		instance.MemUsage = v.Stats.Usage.Mem
-
-		updatedInst[index] = instance
 	}
 	return
 }
@@ -40,6 +40,47 @@ var _ = Describe("AppInstancesRepo", func() {
 		Expect(instance0.MemUsage).To(Equal(uint64(19218432)))
 		Expect(instance0.CpuUsage).To(Equal(3.659571249238058e-05))
 	})
+
+	It("skips instances and stats whose indexes are out of range while the app is scaled", func() {
+		instancesRequest := appInstancesRequest
+		instancesRequest.Response = testnet.TestResponse{Status: http.StatusOK, Body: `{
+			"0": {"state": "RUNNING", "since": 1379522342},
+			"4": {"state": "STARTING", "since": 1379522342}
+		}`}
+		statsRequest := appStatsRequest
+		statsRequest.Response = testnet.TestResponse{Status: http.StatusOK, Body: `{
+			"0": {"stats": {"usage": {"cpu": 0.5}}},
+			"1": {"stats": {"usage": {"cpu": 0.6}}},
+			"2": {"stats": {"usage": {"cpu": 0.7}}}
+		}`}
+
+		ts, handler, repo := createAppInstancesRepo([]testnet.TestRequest{instancesRequest, statsRequest})
+		defer ts.Close()
+
+		instances, apiResponse := repo.GetInstances("my-cool-app-guid")
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+
+		Expect(len(instances)).To(Equal(2))
+		Expect(instances[0].State).To(Equal(models.InstanceRunning))
+		Expect(instances[0].CpuUsage).To(Equal(0.5))
+		Expect(instances[1].CpuUsage).To(Equal(0.6))
+	})
+
+	It("deletes an instance by its index", func() {
+		ts, handler, repo := createAppInstancesRepo([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "DELETE",
+				Path:     "/v2/apps/my-cool-app-guid/instances/1",
+				Response: testnet.TestResponse{Status: http.StatusNoContent},
+			}),
+		})
+		defer ts.Close()
+
+		apiResponse := repo.DeleteInstance("my-cool-app-guid", 1)
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+	})
 })
 
 var appStatsRequest = testapi.NewCloudControllerTestRequest(testnet.TestRequest{
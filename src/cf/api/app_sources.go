@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"cf"
+	"errors"
+	"fileutils"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// fetchAppSource makes a remote app source available on disk for the time cb runs. Git sources
+// are cloned with the local git binary, archives are downloaded and unzipped.
+func fetchAppSource(source string, zipper cf.Zipper, cb func(sourceDir string, err error)) {
+	fileutils.TempDir("remote-app", func(tmpDir string, err error) {
+		if err != nil {
+			cb("", err)
+			return
+		}
+
+		if cf.IsGitAppSource(source) {
+			err = cloneGitAppSource(source, tmpDir)
+			cb(tmpDir, err)
+			return
+		}
+
+		downloadAppArchive(source, func(archive *os.File, err error) {
+			if err != nil {
+				cb("", err)
+				return
+			}
+
+			err = zipper.Unzip(archive.Name(), tmpDir)
+			if err != nil {
+				cb("", errors.New(fmt.Sprintf("Error unzipping %s: %s", source, err)))
+				return
+			}
+
+			cb(archiveRootDir(tmpDir), nil)
+		})
+	})
+}
+
+func cloneGitAppSource(source string, dir string) (err error) {
+	repoUrl, ref := cf.ParseGitAppSource(source)
+	if strings.HasPrefix(repoUrl, "-") || strings.HasPrefix(ref, "-") {
+		err = errors.New(fmt.Sprintf("Invalid git app source %s", source))
+		return
+	}
+
+	err = runGit(dir, "clone", "--quiet", "--", repoUrl, ".")
+	if err != nil {
+		return
+	}
+
+	if ref != "" {
+		err = runGit(dir, "checkout", "--quiet", ref, "--")
+	}
+	return
+}
+
+func runGit(dir string, args ...string) (err error) {
+	output := &bytes.Buffer{}
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	command.Stdout = output
+	command.Stderr = output
+
+	err = command.Run()
+	if err != nil {
+		err = errors.New(fmt.Sprintf("Error running git %s: %s\n%s", args[0], err, strings.TrimSpace(output.String())))
+	}
+	return
+}
+
+func downloadAppArchive(url string, cb func(*os.File, error)) {
+	fileutils.TempFile("app-download", func(tempfile *os.File, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		response, err := newDownloadClient().Get(url)
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			cb(nil, errors.New(fmt.Sprintf("Error downloading %s: %s", url, response.Status)))
+			return
+		}
+
+		_, err = io.Copy(tempfile, response.Body)
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		tempfile.Seek(0, 0)
+		cb(tempfile, nil)
+	})
+}
+
+// archiveRootDir skips the single top level directory that archives of source repositories
+// usually put everything in.
+func archiveRootDir(dir string) string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
@@ -30,6 +30,7 @@ type ApplicationFromSummary struct {
 	Urls             []string
 	State            string
 	SpaceGuid        string `json:"space_guid"`
+	Services         []AppServiceSummary
 }
 
 func (resource ApplicationFromSummary) ToFields() (app models.ApplicationFields) {
@@ -54,6 +55,12 @@ func (resource ApplicationFromSummary) ToModel() (app models.AppSummary) {
 	}
 	app.RouteSummaries = routes
 
+	services := []models.ServiceInstanceFields{}
+	for _, service := range resource.Services {
+		services = append(services, service.ToFields())
+	}
+	app.Services = services
+
 	return
 }
 
@@ -75,6 +82,17 @@ func (resource RouteSummary) ToModel() (route models.RouteSummary) {
 	return
 }
 
+type AppServiceSummary struct {
+	Guid string
+	Name string
+}
+
+func (resource AppServiceSummary) ToFields() (instance models.ServiceInstanceFields) {
+	instance.Guid = resource.Guid
+	instance.Name = resource.Name
+	return
+}
+
 type DomainSummary struct {
 	Guid                   string
 	Name                   string
@@ -52,8 +52,63 @@ var _ = Describe("AppSummaryRepository", func() {
 		Expect(app2.RunningInstances).To(Equal(1))
 		Expect(app2.Memory).To(Equal(uint64(512)))
 	})
+
+	It("TestGetAppSummary", func() {
+		getAppSummaryRequest := testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+			Method:   "GET",
+			Path:     "/v2/apps/app-1-guid/summary",
+			Response: testnet.TestResponse{Status: http.StatusOK, Body: getAppSummaryResponseBody},
+		})
+
+		ts, handler, repo := createAppSummaryRepo([]testnet.TestRequest{getAppSummaryRequest})
+		defer ts.Close()
+
+		app, apiResponse := repo.GetSummary("app-1-guid")
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+
+		Expect(app.Name).To(Equal("app1"))
+		Expect(app.DiskQuota).To(Equal(uint64(1024)))
+		Expect(len(app.RouteSummaries)).To(Equal(1))
+		Expect(app.RouteSummaries[0].URL()).To(Equal("app1.cfapps.io"))
+		Expect(len(app.Services)).To(Equal(2))
+		Expect(app.Services[0].Guid).To(Equal("service-1-guid"))
+		Expect(app.Services[0].Name).To(Equal("my-db"))
+		Expect(app.Services[1].Name).To(Equal("my-cache"))
+	})
 })
 
+var getAppSummaryResponseBody = `
+{
+  "guid":"app-1-guid",
+  "name":"app1",
+  "routes":[
+    {
+      "guid":"route-1-guid",
+      "host":"app1",
+      "domain":{
+        "guid":"domain-1-guid",
+        "name":"cfapps.io"
+      }
+    }
+  ],
+  "running_instances":1,
+  "memory":128,
+  "disk_quota":1024,
+  "instances":1,
+  "state":"STARTED",
+  "services":[
+    {
+      "guid":"service-1-guid",
+      "name":"my-db"
+    },
+    {
+      "guid":"service-2-guid",
+      "name":"my-cache"
+    }
+  ]
+}`
+
 var getAppSummariesResponseBody = `
 {
   "apps":[
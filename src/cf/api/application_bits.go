@@ -12,10 +12,10 @@ import (
 	"fileutils"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/textproto"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -26,111 +26,211 @@ type AppFileResource struct {
 }
 
 type ApplicationBitsRepository interface {
-	UploadApp(appGuid, dir string, cb func(path string, zipSize, fileCount uint64)) (apiResponse net.ApiResponse)
+	UploadApp(appGuid, dir string, ignoreFiles []string, cb func(path string, zipSize, fileCount uint64), progressCb net.ProgressCallback) (apiResponse net.ApiResponse)
+	AppFiles(dir string, ignoreFiles []string) (files []models.AppFileFields, apiResponse net.ApiResponse)
+	FilesToUpload(dir string, ignoreFiles []string) (files []models.AppFileFields, apiResponse net.ApiResponse)
+	DownloadApp(appGuid string, destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse)
+	DownloadDroplet(appGuid string, destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse)
+	UploadDroplet(appGuid string, droplet *os.File, progressCb net.ProgressCallback) (apiResponse net.ApiResponse)
+	CopyBits(sourceAppGuid, targetAppGuid string, statusCb func(status string)) (apiResponse net.ApiResponse)
+}
+
+type CopyBitsJobResource struct {
+	Metadata net.AsyncMetadata
+	Entity   net.JobEntity
 }
 
 type CloudControllerApplicationBitsRepository struct {
-	config  configuration.Reader
-	gateway net.Gateway
-	zipper  cf.Zipper
+	config    configuration.Reader
+	gateway   net.Gateway
+	zipper    cf.Zipper
+	hashCache *cf.FileHashCache
 }
 
-func NewCloudControllerApplicationBitsRepository(config configuration.Reader, gateway net.Gateway, zipper cf.Zipper) (repo CloudControllerApplicationBitsRepository) {
+func NewCloudControllerApplicationBitsRepository(config configuration.Reader, gateway net.Gateway, zipper cf.Zipper, hashCache *cf.FileHashCache) (repo CloudControllerApplicationBitsRepository) {
 	repo.config = config
 	repo.gateway = gateway
 	repo.zipper = zipper
+	repo.hashCache = hashCache
 	return
 }
 
-func (repo CloudControllerApplicationBitsRepository) UploadApp(appGuid string, appDir string, cb func(path string, zipSize, fileCount uint64)) (apiResponse net.ApiResponse) {
-	fileutils.TempDir("apps", func(uploadDir string, err error) {
+// DefaultFileHashCachePath is the file next to the config file that the hashes of app files are
+// kept in between pushes.
+func DefaultFileHashCachePath() string {
+	return filepath.Join(filepath.Dir(configuration.DefaultFilePath()), "file_hashes.json")
+}
+
+func (repo CloudControllerApplicationBitsRepository) UploadApp(appGuid string, appDir string, ignoreFiles []string, cb func(path string, zipSize, fileCount uint64), progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	repo.sourceDir(appDir, func(sourceDir string, err error) {
 		if err != nil {
-			apiResponse = net.NewApiResponseWithMessage(err.Error())
+			apiResponse = net.NewApiResponseWithMessage("%s", err)
 			return
 		}
 
-		var presentResourcesJson []byte
-		repo.sourceDir(appDir, func(sourceDir string, sourceErr error) {
-			if sourceErr != nil {
-				err = sourceErr
-				return
-			}
-			presentResourcesJson, err = repo.copyUploadableFiles(sourceDir, uploadDir)
-		})
-
+		appFilesToUpload, presentResourcesJson, err := repo.uploadableFiles(appDir, sourceDir, ignoreFiles)
 		if err != nil {
 			apiResponse = net.NewApiResponseWithMessage("%s", err)
 			return
 		}
 
-		fileutils.TempFile("uploads", func(zipFile *os.File, err error) {
-			if err != nil {
-				apiResponse = net.NewApiResponseWithMessage("%s", err.Error())
-				return
-			}
-
-			err = repo.zipper.Zip(uploadDir, zipFile)
-			if err != nil {
-				apiResponse = net.NewApiResponseWithError("Error zipping application", err)
-				return
-			}
-
-			stat, err := zipFile.Stat()
-			if err != nil {
-				apiResponse = net.NewApiResponseWithError("Error zipping application", err)
-				return
-			}
-			cb(appDir, uint64(stat.Size()), cf.CountFiles(uploadDir))
-
-			apiResponse = repo.uploadBits(appGuid, zipFile, presentResourcesJson)
-			if apiResponse.IsNotSuccessful() {
-				return
-			}
-		})
+		zipApp := func(destination io.Writer) error {
+			return repo.zipper.ZipFiles(sourceDir, appFilesToUpload, destination)
+		}
+
+		// The zip is written twice, once to learn its size and once while it is sent, so that
+		// it never has to be stored on disk.
+		zipSize := &net.CountingWriter{}
+		err = zipApp(zipSize)
+		if err != nil {
+			apiResponse = net.NewApiResponseWithError("Error zipping application", err)
+			return
+		}
+		cb(appDir, uint64(zipSize.Count), uint64(len(appFilesToUpload)))
+
+		apiResponse = repo.uploadBits(appGuid, zipApp, zipSize.Count, presentResourcesJson, progressCb)
 	})
 	return
 }
 
-func (repo CloudControllerApplicationBitsRepository) uploadBits(appGuid string, zipFile *os.File, presentResourcesJson []byte) (apiResponse net.ApiResponse) {
-	url := fmt.Sprintf("%s/v2/apps/%s/bits", repo.config.ApiEndpoint(), appGuid)
-	fileutils.TempFile("requests", func(requestFile *os.File, err error) {
+// AppFiles returns the files of the app that are not excluded by the ignore files, without
+// talking to the cloud controller.
+func (repo CloudControllerApplicationBitsRepository) AppFiles(appDir string, ignoreFiles []string) (files []models.AppFileFields, apiResponse net.ApiResponse) {
+	repo.sourceDir(appDir, func(sourceDir string, err error) {
 		if err != nil {
-			apiResponse = net.NewApiResponseWithError("Error creating tmp file: %s", err)
+			apiResponse = net.NewApiResponseWithMessage("%s", err)
 			return
 		}
 
-		boundary, err := repo.writeUploadBody(zipFile, requestFile, presentResourcesJson)
+		files, err = repo.appFilesInDir(appDir, sourceDir, ignoreFiles)
 		if err != nil {
-			apiResponse = net.NewApiResponseWithError("Error writing to tmp file: %s", err)
+			apiResponse = net.NewApiResponseWithMessage("%s", err)
 			return
 		}
+	})
+	return
+}
 
-		var request *net.Request
-		request, apiResponse = repo.gateway.NewRequest("PUT", url, repo.config.AccessToken(), requestFile)
-		if apiResponse.IsNotSuccessful() {
-			return
-		}
+// FilesToUpload returns the files of the app that the cloud controller does not have yet, without
+// uploading anything.
+func (repo CloudControllerApplicationBitsRepository) FilesToUpload(appDir string, ignoreFiles []string) (files []models.AppFileFields, apiResponse net.ApiResponse) {
+	allAppFiles, apiResponse := repo.AppFiles(appDir, ignoreFiles)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
 
-		contentType := fmt.Sprintf("multipart/form-data; boundary=%s", boundary)
-		request.HttpReq.Header.Set("Content-Type", contentType)
+	files, _, apiResponse = repo.getFilesToUpload(allAppFiles)
+	return
+}
 
-		response := &Resource{}
-		_, apiResponse = repo.gateway.PerformPollingRequestForJSONResponse(request, response, 5*time.Minute)
-		if apiResponse.IsNotSuccessful() {
-			return
-		}
+// DownloadApp writes the zip of source bits that were last uploaded for the app to destination.
+func (repo CloudControllerApplicationBitsRepository) DownloadApp(appGuid string, destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	url := fmt.Sprintf("%s/v2/apps/%s/download", repo.config.ApiEndpoint(), appGuid)
+	return downloadResource(repo.gateway, url, repo.config.AccessToken(), destination, progressCb)
+}
+
+// DownloadDroplet writes the staged droplet of the app, a gzipped tarball, to destination.
+func (repo CloudControllerApplicationBitsRepository) DownloadDroplet(appGuid string, destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	url := fmt.Sprintf("%s/v2/apps/%s/droplet/download", repo.config.ApiEndpoint(), appGuid)
+	return downloadResource(repo.gateway, url, repo.config.AccessToken(), destination, progressCb)
+}
+
+// UploadDroplet replaces the staged droplet of the app with droplet, without staging it again.
+func (repo CloudControllerApplicationBitsRepository) UploadDroplet(appGuid string, droplet *os.File, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	url := fmt.Sprintf("%s/v2/apps/%s/droplet/upload", repo.config.ApiEndpoint(), appGuid)
+
+	dropletStats, err := droplet.Stat()
+	if err != nil {
+		apiResponse = net.NewApiResponseWithError("Error reading droplet", err)
+		return
+	}
+
+	body := net.NewMultipartBody()
+	body.AddFile("droplet", "droplet.tgz", droplet, dropletStats.Size())
+
+	return repo.performMultipartUpload(url, body, progressCb)
+}
+
+// CopyBits has the Cloud Controller copy the source bits of one app to another, which may be in a
+// different space, and waits for the copy to finish. statusCb is called with the status of the
+// copy job every time it changes.
+func (repo CloudControllerApplicationBitsRepository) CopyBits(sourceAppGuid, targetAppGuid string, statusCb func(status string)) (apiResponse net.ApiResponse) {
+	url := fmt.Sprintf("%s/v2/apps/%s/copy_bits", repo.config.ApiEndpoint(), targetAppGuid)
+	body := fmt.Sprintf(`{"source_app_guid":"%s"}`, sourceAppGuid)
+
+	request, apiResponse := repo.gateway.NewRequest("POST", url, repo.config.AccessToken(), strings.NewReader(body))
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+
+	job := new(CopyBitsJobResource)
+	_, apiResponse = repo.gateway.PerformRequestForJSONResponse(request, job)
+	if apiResponse.IsNotSuccessful() || job.Metadata.Url == "" {
+		return
+	}
+
+	jobUrl := repo.config.ApiEndpoint() + job.Metadata.Url
+	return repo.gateway.WaitForJob(jobUrl, repo.config.AccessToken(), 5*time.Minute, statusCb)
+}
+
+// downloadResource writes the body of a GET request to destination as it is, failing when less
+// was received than the server announced.
+func downloadResource(gateway net.Gateway, url, accessToken string, destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	request, apiResponse := gateway.NewRequest("GET", url, accessToken, nil)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+
+	response, apiResponse := gateway.PerformRequestForResponse(request)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+	defer response.Body.Close()
+
+	writer := net.NewProgressWriter(destination, response.ContentLength, progressCb)
+	_, err := io.Copy(writer, response.Body)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		apiResponse = net.NewApiResponseWithError("Error downloading", err)
+		return
+	}
+
+	if err == io.ErrUnexpectedEOF || (response.ContentLength >= 0 && writer.BytesWritten() != response.ContentLength) {
+		apiResponse = net.NewApiResponseWithMessage("Download incomplete, received %d of %d bytes", writer.BytesWritten(), response.ContentLength)
+	}
+	return
+}
+
+func (repo CloudControllerApplicationBitsRepository) uploadBits(appGuid string, zipApp func(io.Writer) error, zipSize int64, presentResourcesJson []byte, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	url := fmt.Sprintf("%s/v2/apps/%s/bits", repo.config.ApiEndpoint(), appGuid)
+	return repo.performMultipartUpload(url, uploadBody(zipApp, zipSize, presentResourcesJson), progressCb)
+}
+
+func (repo CloudControllerApplicationBitsRepository) performMultipartUpload(url string, body *net.MultipartBody, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	request, apiResponse := repo.gateway.NewStreamingRequest("PUT", url, repo.config.AccessToken(), body.Length(), func() io.ReadCloser {
+		return body.Reader(progressCb)
 	})
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+	request.HttpReq.Header.Set("Content-Type", body.ContentType())
 
+	_, apiResponse = repo.gateway.PerformPollingRequestForJSONResponse(request, &Resource{}, 5*time.Minute)
 	return
 }
 
 func (repo CloudControllerApplicationBitsRepository) sourceDir(appDir string, cb func(sourceDir string, err error)) {
+	if cf.IsRemoteAppSource(appDir) {
+		fetchAppSource(appDir, repo.zipper, cb)
+		return
+	}
+
 	// If appDir is a zip, first extract it to a temporary directory
 	zipReader, err := zip.OpenReader(appDir)
 	if err != nil {
 		cb(appDir, nil)
 		return
 	}
+	zipReader.Close()
 
 	fileutils.TempDir("unzipped-app", func(tmpDir string, err error) {
 		if err != nil {
@@ -138,15 +238,13 @@ func (repo CloudControllerApplicationBitsRepository) sourceDir(appDir string, cb
 			return
 		}
 
-		err = repo.extractZip(zipReader, tmpDir)
-		zipReader.Close()
+		err = repo.zipper.Unzip(appDir, tmpDir)
 		cb(tmpDir, err)
 	})
 }
 
-func (repo CloudControllerApplicationBitsRepository) copyUploadableFiles(appDir string, uploadDir string) (presentResourcesJson []byte, err error) {
-	// Find which files need to be uploaded
-	allAppFiles, err := cf.AppFilesInDir(appDir)
+func (repo CloudControllerApplicationBitsRepository) uploadableFiles(appDir, sourceDir string, ignoreFiles []string) (appFilesToUpload []models.AppFileFields, presentResourcesJson []byte, err error) {
+	allAppFiles, err := repo.appFilesInDir(appDir, sourceDir, ignoreFiles)
 	if err != nil {
 		return
 	}
@@ -154,52 +252,23 @@ func (repo CloudControllerApplicationBitsRepository) copyUploadableFiles(appDir
 	appFilesToUpload, presentResourcesJson, apiResponse := repo.getFilesToUpload(allAppFiles)
 	if apiResponse.IsNotSuccessful() {
 		err = errors.New(apiResponse.Message)
-		return
-	}
-
-	// Copy files into a temporary directory and return it
-	err = cf.CopyFiles(appFilesToUpload, appDir, uploadDir)
-	if err != nil {
-		return
 	}
-
 	return
 }
 
-func (repo CloudControllerApplicationBitsRepository) extractZip(r *zip.ReadCloser, destDir string) (err error) {
-	for _, f := range r.File {
-		func() {
-			// Don't try to extract directories
-			if f.FileInfo().IsDir() {
-				return
-			}
-
-			if err != nil {
-				return
-			}
-
-			var rc io.ReadCloser
-			rc, err = f.Open()
-			if err != nil {
-				return
-			}
-
-			defer rc.Close()
-
-			destFilePath := filepath.Join(destDir, f.Name)
-
-			err = fileutils.CopyReaderToPath(rc, destFilePath)
-			if err != nil {
-				return
-			}
-
-			err = fileutils.SetExecutableBits(destFilePath, f.FileInfo())
-			if err != nil {
-				return
-			}
-		}()
+func (repo CloudControllerApplicationBitsRepository) appFilesInDir(appDir, sourceDir string, ignoreFiles []string) (files []models.AppFileFields, err error) {
+	// files unpacked into a temporary directory are new every time, so caching their hashes is no use
+	if sourceDir != appDir {
+		return cf.AppFilesInDir(sourceDir, ignoreFiles)
 	}
 
+	files, err = cf.AppFilesInDirWithCache(sourceDir, ignoreFiles, repo.hashCache)
+	if err != nil {
+		return
+	}
+
+	// the hashes are only a shortcut, the push goes on without them
+	repo.hashCache.Save()
 	return
 }
 
@@ -259,48 +328,23 @@ func (repo CloudControllerApplicationBitsRepository) deleteAppFile(appFiles []mo
 	return appFiles
 }
 
-func (repo CloudControllerApplicationBitsRepository) writeUploadBody(zipFile *os.File, body *os.File, presentResourcesJson []byte) (boundary string, err error) {
-	writer := multipart.NewWriter(body)
-	defer writer.Close()
-
-	boundary = writer.Boundary()
-
-	part, err := writer.CreateFormField("resources")
-	if err != nil {
-		return
-	}
-
-	_, err = io.Copy(part, bytes.NewBuffer(presentResourcesJson))
-	if err != nil {
-		return
-	}
-
-	zipStats, err := zipFile.Stat()
-	if err != nil {
-		return
-	}
+func uploadBody(zipApp func(io.Writer) error, zipSize int64, presentResourcesJson []byte) (body *net.MultipartBody) {
+	body = net.NewMultipartBody()
+	body.AddField("resources", presentResourcesJson)
 
-	if zipStats.Size() == 0 {
+	if zipSize == 0 {
 		return
 	}
 
-	part, err = createZipPartWriter(zipStats, writer)
-	if err != nil {
-		return
-	}
-
-	_, err = io.Copy(part, zipFile)
-	if err != nil {
-		return
-	}
+	body.AddWrittenPart(zipPartHeader(zipSize), zipSize, zipApp)
 	return
 }
 
-func createZipPartWriter(zipStats os.FileInfo, writer *multipart.Writer) (io.Writer, error) {
+func zipPartHeader(zipSize int64) textproto.MIMEHeader {
 	h := make(textproto.MIMEHeader)
 	h.Set("Content-Disposition", `form-data; name="application"; filename="application.zip"`)
 	h.Set("Content-Type", "application/zip")
-	h.Set("Content-Length", fmt.Sprintf("%d", zipStats.Size()))
+	h.Set("Content-Length", fmt.Sprintf("%d", zipSize))
 	h.Set("Content-Transfer-Encoding", "binary")
-	return writer.CreatePart(h)
+	return h
 }
@@ -2,6 +2,7 @@ package api_test
 
 import (
 	"archive/zip"
+	"bytes"
 	"cf"
 	. "cf/api"
 	"cf/models"
@@ -10,10 +11,14 @@ import (
 	"fmt"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	testapi "testhelpers/api"
@@ -205,18 +210,27 @@ func testUploadApp(dir string, requests []testnet.TestRequest) (app models.Appli
 	gateway := net.NewCloudControllerGateway()
 	gateway.PollingThrottle = time.Duration(0)
 	zipper := cf.ApplicationZipper{}
-	repo := NewCloudControllerApplicationBitsRepository(configRepo, gateway, zipper)
+	repo := NewCloudControllerApplicationBitsRepository(configRepo, gateway, zipper, nil)
 
 	var (
 		reportedPath                          string
 		reportedFileCount, reportedUploadSize uint64
+		reportedBytesRead, reportedTotalBytes int64
 	)
-	apiResponse = repo.UploadApp("my-cool-app-guid", dir, func(path string, uploadSize, fileCount uint64) {
+	apiResponse = repo.UploadApp("my-cool-app-guid", dir, cf.CfIgnoreFiles, func(path string, uploadSize, fileCount uint64) {
 		reportedPath = path
 		reportedUploadSize = uploadSize
 		reportedFileCount = fileCount
+	}, func(bytesRead, totalBytes int64) {
+		reportedBytesRead = bytesRead
+		reportedTotalBytes = totalBytes
 	})
 
+	if apiResponse.IsSuccessful() {
+		Expect(reportedTotalBytes > 0).To(BeTrue())
+		Expect(reportedBytesRead).To(Equal(reportedTotalBytes))
+	}
+
 	Expect(reportedPath).To(Equal(dir))
 	Expect(reportedFileCount).To(Equal(uint64(len(expectedApplicationContent))))
 	Expect(reportedUploadSize).To(Equal(uint64(759)))
@@ -231,9 +245,9 @@ var _ = Describe("Testing with ginkgo", func() {
 		gateway := net.NewCloudControllerGateway()
 		zipper := &cf.ApplicationZipper{}
 
-		repo := NewCloudControllerApplicationBitsRepository(config, gateway, zipper)
+		repo := NewCloudControllerApplicationBitsRepository(config, gateway, zipper, nil)
 
-		apiResponse := repo.UploadApp("app-guid", "/foo/bar", func(path string, uploadSize, fileCount uint64) {})
+		apiResponse := repo.UploadApp("app-guid", "/foo/bar", cf.CfIgnoreFiles, func(path string, uploadSize, fileCount uint64) {}, nil)
 		Expect(apiResponse.IsNotSuccessful()).To(BeTrue())
 		Expect(apiResponse.Message).To(ContainSubstring(filepath.Join("foo", "bar")))
 	})
@@ -282,4 +296,342 @@ var _ = Describe("Testing with ginkgo", func() {
 		_, apiResponse := testUploadApp(dir, requests)
 		Expect(apiResponse.IsSuccessful()).To(BeFalse())
 	})
+
+	It("TestFilesToUploadOnlyAsksWhichFilesAreMissing", func() {
+		dir, err := os.Getwd()
+		Expect(err).NotTo(HaveOccurred())
+		dir = filepath.Join(dir, "../../fixtures/example-app")
+
+		ts, handler := testnet.NewTLSServer([]testnet.TestRequest{matchResourceRequest})
+		defer ts.Close()
+
+		configRepo := testconfig.NewRepositoryWithDefaults()
+		configRepo.SetApiEndpoint(ts.URL)
+		repo := NewCloudControllerApplicationBitsRepository(configRepo, net.NewCloudControllerGateway(), cf.ApplicationZipper{}, nil)
+
+		files, apiResponse := repo.FilesToUpload(dir, cf.CfIgnoreFiles)
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+
+		Expect(appFilePaths(files)).To(Equal([]string{"Gemfile", "Gemfile.lock", "manifest.yml"}))
+	})
+	It("TestAppFilesListsFilesWithoutAskingTheCloudController", func() {
+		dir, err := os.Getwd()
+		Expect(err).NotTo(HaveOccurred())
+		dir = filepath.Join(dir, "../../fixtures/example-app")
+
+		repo := NewCloudControllerApplicationBitsRepository(testconfig.NewRepositoryWithDefaults(), net.NewCloudControllerGateway(), cf.ApplicationZipper{}, nil)
+
+		files, apiResponse := repo.AppFiles(dir, cf.CfIgnoreFiles)
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+
+		Expect(appFilePaths(files)).To(Equal([]string{"Gemfile", "Gemfile.lock", "app.rb", "config.ru", "manifest.yml"}))
+	})
+	It("TestAppFilesFetchesArchivesFromUrls", func() {
+		archive := &bytes.Buffer{}
+		writer := zip.NewWriter(archive)
+		for _, name := range []string{"my-app-1.0/app.rb", "my-app-1.0/lib/util.rb"} {
+			file, err := writer.Create(name)
+			Expect(err).NotTo(HaveOccurred())
+			file.Write([]byte("puts 'hello'"))
+		}
+		writer.Close()
+
+		ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.URL.Path != "/my-app.zip" {
+				writer.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writer.Write(archive.Bytes())
+		}))
+		defer ts.Close()
+
+		repo := NewCloudControllerApplicationBitsRepository(testconfig.NewRepositoryWithDefaults(), net.NewCloudControllerGateway(), cf.ApplicationZipper{}, nil)
+
+		files, apiResponse := repo.AppFiles(ts.URL+"/my-app.zip", cf.CfIgnoreFiles)
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(appFilePaths(files)).To(Equal([]string{"app.rb", filepath.Join("lib", "util.rb")}))
+
+		_, apiResponse = repo.AppFiles(ts.URL+"/missing.zip", cf.CfIgnoreFiles)
+		Expect(apiResponse.IsNotSuccessful()).To(BeTrue())
+		Expect(apiResponse.Message).To(ContainSubstring("404"))
+	})
+	It("TestAppFilesClonesGitSourcesAtTheGivenRef", func() {
+		if _, err := exec.LookPath("git"); err != nil {
+			Skip("git is not installed")
+		}
+
+		fileutils.TempDir("git-app", func(repoDir string, err error) {
+			git := func(args ...string) {
+				command := exec.Command("git", append([]string{"-c", "user.name=me", "-c", "user.email=me@example.com"}, args...)...)
+				command.Dir = repoDir
+				output, err := command.CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(output))
+			}
+
+			git("init", "--quiet")
+			ioutil.WriteFile(filepath.Join(repoDir, "app.rb"), []byte("puts 'v1'"), 0600)
+			git("add", ".")
+			git("commit", "--quiet", "-m", "v1")
+			git("tag", "v1")
+			ioutil.WriteFile(filepath.Join(repoDir, "new.rb"), []byte("puts 'v2'"), 0600)
+			git("add", ".")
+			git("commit", "--quiet", "-m", "v2")
+
+			// git+file:// sources are refused, so point an https url at the local repository instead
+			os.Setenv("GIT_CONFIG_COUNT", "1")
+			os.Setenv("GIT_CONFIG_KEY_0", "url.file://"+repoDir+".insteadOf")
+			os.Setenv("GIT_CONFIG_VALUE_0", "https://example.com/app.git")
+			defer func() {
+				os.Unsetenv("GIT_CONFIG_COUNT")
+				os.Unsetenv("GIT_CONFIG_KEY_0")
+				os.Unsetenv("GIT_CONFIG_VALUE_0")
+			}()
+
+			repo := NewCloudControllerApplicationBitsRepository(testconfig.NewRepositoryWithDefaults(), net.NewCloudControllerGateway(), cf.ApplicationZipper{}, nil)
+
+			files, apiResponse := repo.AppFiles("git+https://example.com/app.git#v1", cf.CfIgnoreFiles)
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(appFilePaths(files)).To(Equal([]string{"app.rb"}))
+
+			files, apiResponse = repo.AppFiles("git+https://example.com/app.git", cf.CfIgnoreFiles)
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(appFilePaths(files)).To(Equal([]string{"app.rb", "new.rb"}))
+
+			_, apiResponse = repo.AppFiles("git+https://example.com/app.git#no-such-ref", cf.CfIgnoreFiles)
+			Expect(apiResponse.IsNotSuccessful()).To(BeTrue())
+			Expect(apiResponse.Message).To(ContainSubstring("Error running git checkout"))
+		})
+	})
+	It("TestAppFilesDoesNotPassHostileGitSourcesToGit", func() {
+		fileutils.TempDir("git-app", func(dir string, err error) {
+			marker := filepath.Join(dir, "pwned")
+			repo := NewCloudControllerApplicationBitsRepository(testconfig.NewRepositoryWithDefaults(), net.NewCloudControllerGateway(), cf.ApplicationZipper{}, nil)
+
+			sources := []string{
+				"git+--upload-pack=touch " + marker,
+				"git+ext::sh -c touch% " + marker,
+				"git+file://" + dir,
+			}
+			for _, source := range sources {
+				_, apiResponse := repo.AppFiles(source, cf.CfIgnoreFiles)
+				Expect(apiResponse.IsNotSuccessful()).To(BeTrue())
+				Expect(apiResponse.Message).NotTo(ContainSubstring("Error running git"))
+			}
+
+			_, apiResponse := repo.AppFiles("git+https://example.com/app.git#--upload-pack=touch "+marker, cf.CfIgnoreFiles)
+			Expect(apiResponse.IsNotSuccessful()).To(BeTrue())
+			Expect(apiResponse.Message).To(ContainSubstring("Invalid git app source"))
+
+			_, err = os.Stat(marker)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+	It("TestDownloadAppStreamsTheSourceBits", func() {
+		ts, handler := testnet.NewTLSServer([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "GET",
+				Path:     "/v2/apps/my-app-guid/download",
+				Response: testnet.TestResponse{Status: http.StatusOK, Body: "zip contents"},
+			}),
+		})
+		defer ts.Close()
+
+		configRepo := testconfig.NewRepositoryWithDefaults()
+		configRepo.SetApiEndpoint(ts.URL)
+		repo := NewCloudControllerApplicationBitsRepository(configRepo, net.NewCloudControllerGateway(), cf.ApplicationZipper{}, nil)
+
+		destination := &bytes.Buffer{}
+		var reportedBytes, reportedTotal int64
+		apiResponse := repo.DownloadApp("my-app-guid", destination, func(bytesRead, totalBytes int64) {
+			reportedBytes = bytesRead
+			reportedTotal = totalBytes
+		})
+
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(destination.String()).To(Equal("zip contents\n"))
+		Expect(reportedBytes).To(Equal(int64(13)))
+		Expect(reportedTotal).To(Equal(int64(13)))
+	})
+
+	It("TestDownloadAppDoesNotSendTheTokenToTheBlobstore", func() {
+		var blobstoreAuthorization []string
+		blobstore := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			blobstoreAuthorization = append(blobstoreAuthorization, request.Header.Get("Authorization"))
+			fmt.Fprint(writer, "zip contents")
+		}))
+		defer blobstore.Close()
+
+		ts, handler := testnet.NewTLSServer([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method: "GET",
+				Path:   "/v2/apps/my-app-guid/download",
+				Response: testnet.TestResponse{
+					Status: http.StatusFound,
+					Header: http.Header{"Location": {blobstore.URL + "/my-app.zip?signature=abc"}},
+				},
+			}),
+		})
+		defer ts.Close()
+
+		configRepo := testconfig.NewRepositoryWithDefaults()
+		configRepo.SetApiEndpoint(ts.URL)
+		repo := NewCloudControllerApplicationBitsRepository(configRepo, net.NewCloudControllerGateway(), cf.ApplicationZipper{}, nil)
+
+		destination := &bytes.Buffer{}
+		apiResponse := repo.DownloadApp("my-app-guid", destination, nil)
+
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(destination.String()).To(Equal("zip contents"))
+		Expect(blobstoreAuthorization).To(Equal([]string{""}))
+	})
+
+	It("TestDownloadDropletUsesTheDropletEndpoint", func() {
+		ts, handler := testnet.NewTLSServer([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "GET",
+				Path:     "/v2/apps/my-app-guid/droplet/download",
+				Response: testnet.TestResponse{Status: http.StatusOK, Body: "droplet contents"},
+			}),
+		})
+		defer ts.Close()
+
+		configRepo := testconfig.NewRepositoryWithDefaults()
+		configRepo.SetApiEndpoint(ts.URL)
+		repo := NewCloudControllerApplicationBitsRepository(configRepo, net.NewCloudControllerGateway(), cf.ApplicationZipper{}, nil)
+
+		destination := &bytes.Buffer{}
+		apiResponse := repo.DownloadDroplet("my-app-guid", destination, func(bytesRead, totalBytes int64) {})
+
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(destination.String()).To(Equal("droplet contents\n"))
+	})
+
+	It("TestDownloadAppFailsWhenTheBodyIsShorterThanItsContentLength", func() {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Content-Type", "application/zip")
+			writer.Header().Set("Content-Length", "100")
+			writer.WriteHeader(http.StatusOK)
+			writer.Write([]byte("truncated"))
+			writer.(http.Flusher).Flush()
+		}))
+		defer ts.Close()
+
+		configRepo := testconfig.NewRepositoryWithDefaults()
+		configRepo.SetApiEndpoint(ts.URL)
+		repo := NewCloudControllerApplicationBitsRepository(configRepo, net.NewCloudControllerGateway(), cf.ApplicationZipper{}, nil)
+
+		apiResponse := repo.DownloadApp("my-app-guid", &bytes.Buffer{}, func(bytesRead, totalBytes int64) {})
+
+		Expect(apiResponse.IsNotSuccessful()).To(BeTrue())
+		Expect(apiResponse.Message).To(ContainSubstring("Download incomplete, received 9 of 100 bytes"))
+	})
+	It("TestUploadDropletSendsTheDropletAsMultipartForm", func() {
+		ts, handler := testnet.NewTLSServer([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method: "PUT",
+				Path:   "/v2/apps/my-app-guid/droplet/upload",
+				Matcher: func(request *http.Request) {
+					file, _, err := request.FormFile("droplet")
+					Expect(err).NotTo(HaveOccurred())
+					contents, err := ioutil.ReadAll(file)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(contents)).To(Equal("droplet contents"))
+				},
+				Response: testnet.TestResponse{Status: http.StatusCreated, Body: `{"metadata":{"guid":"my-job-guid"}}`},
+			}),
+		})
+		defer ts.Close()
+
+		configRepo := testconfig.NewRepositoryWithDefaults()
+		configRepo.SetApiEndpoint(ts.URL)
+		repo := NewCloudControllerApplicationBitsRepository(configRepo, net.NewCloudControllerGateway(), cf.ApplicationZipper{}, nil)
+
+		fileutils.TempFile("droplet", func(droplet *os.File, err error) {
+			droplet.WriteString("droplet contents")
+			droplet.Seek(0, 0)
+
+			apiResponse := repo.UploadDroplet("my-app-guid", droplet, func(bytesRead, totalBytes int64) {})
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(handler.AllRequestsCalled()).To(BeTrue())
+		})
+	})
+
+	It("copies the bits of one app to another and waits for the copy job", func() {
+		ts, handler := testnet.NewTLSServer([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:  "POST",
+				Path:    "/v2/apps/target-app-guid/copy_bits",
+				Matcher: testnet.RequestBodyMatcher(`{"source_app_guid":"source-app-guid"}`),
+				Response: testnet.TestResponse{Status: http.StatusCreated, Body: `{
+					"metadata": {"guid": "my-job-guid", "url": "/v2/jobs/my-job-guid"},
+					"entity": {"guid": "my-job-guid", "status": "queued"}
+				}`},
+			}),
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "GET",
+				Path:     "/v2/jobs/my-job-guid",
+				Response: testnet.TestResponse{Status: http.StatusOK, Body: `{"entity": {"status": "running"}}`},
+			}),
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "GET",
+				Path:     "/v2/jobs/my-job-guid",
+				Response: testnet.TestResponse{Status: http.StatusOK, Body: `{"entity": {"status": "finished"}}`},
+			}),
+		})
+		defer ts.Close()
+
+		configRepo := testconfig.NewRepositoryWithDefaults()
+		configRepo.SetApiEndpoint(ts.URL)
+		gateway := net.NewCloudControllerGateway()
+		gateway.PollingThrottle = time.Duration(0)
+		repo := NewCloudControllerApplicationBitsRepository(configRepo, gateway, cf.ApplicationZipper{}, nil)
+
+		statuses := []string{}
+		apiResponse := repo.CopyBits("source-app-guid", "target-app-guid", func(status string) {
+			statuses = append(statuses, status)
+		})
+
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(statuses).To(Equal([]string{"running", "finished"}))
+	})
+
+	It("fails when the copy job fails", func() {
+		ts, handler := testnet.NewTLSServer([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "POST",
+				Path:     "/v2/apps/target-app-guid/copy_bits",
+				Response: testnet.TestResponse{Status: http.StatusCreated, Body: `{"metadata": {"url": "/v2/jobs/my-job-guid"}}`},
+			}),
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "GET",
+				Path:     "/v2/jobs/my-job-guid",
+				Response: testnet.TestResponse{Status: http.StatusOK, Body: `{"entity": {"status": "failed", "error": "source app has no bits"}}`},
+			}),
+		})
+		defer ts.Close()
+
+		configRepo := testconfig.NewRepositoryWithDefaults()
+		configRepo.SetApiEndpoint(ts.URL)
+		gateway := net.NewCloudControllerGateway()
+		gateway.PollingThrottle = time.Duration(0)
+		repo := NewCloudControllerApplicationBitsRepository(configRepo, gateway, cf.ApplicationZipper{}, nil)
+
+		apiResponse := repo.CopyBits("source-app-guid", "target-app-guid", nil)
+
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsNotSuccessful()).To(BeTrue())
+	})
 })
+
+func appFilePaths(files []models.AppFileFields) (paths []string) {
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	sort.Strings(paths)
+	return
+}
@@ -40,6 +40,7 @@ type ApplicationEntity struct {
 	SpaceGuid          *string             `json:"space_guid,omitempty"`
 	Instances          *int                `json:"instances,omitempty"`
 	Memory             *uint64             `json:"memory,omitempty"`
+	DiskQuota          *uint64             `json:"disk_quota,omitempty"`
 	StackGuid          *string             `json:"stack_guid,omitempty"`
 	Stack              *StackResource      `json:"stack,omitempty"`
 	Routes             *[]AppRouteResource `json:"routes,omitempty"`
@@ -60,6 +61,7 @@ func NewApplicationEntityFromAppParams(app models.AppParams) ApplicationEntity {
 		SpaceGuid:          app.SpaceGuid,
 		Instances:          app.InstanceCount,
 		Memory:             app.Memory,
+		DiskQuota:          app.DiskQuota,
 		StackGuid:          app.StackGuid,
 		Command:            app.Command,
 		HealthCheckTimeout: app.HealthCheckTimeout,
@@ -68,7 +70,7 @@ func NewApplicationEntityFromAppParams(app models.AppParams) ApplicationEntity {
 		state := strings.ToUpper(*app.State)
 		entity.State = &state
 	}
-	if app.EnvironmentVars != nil && len(*app.EnvironmentVars) > 0 {
+	if app.EnvironmentVars != nil {
 		entity.EnvironmentJson = app.EnvironmentVars
 	}
 	return entity
@@ -84,6 +86,15 @@ func (resource ApplicationResource) ToFields() (app models.ApplicationFields) {
 	if entity.Memory != nil {
 		app.Memory = uint64(*entity.Memory)
 	}
+	if entity.DiskQuota != nil {
+		app.DiskQuota = *entity.DiskQuota
+	}
+	if entity.Command != nil {
+		app.Command = *entity.Command
+	}
+	if entity.Buildpack != nil {
+		app.BuildpackUrl = *entity.Buildpack
+	}
 	if entity.Instances != nil {
 		app.InstanceCount = *entity.Instances
 	}
@@ -116,6 +127,23 @@ func (resource ApplicationResource) ToModel() (app models.Application) {
 	return
 }
 
+type ApplicationEnvResource struct {
+	System      map[string]interface{} `json:"system_env_json"`
+	Application map[string]interface{} `json:"application_env_json"`
+	Environment map[string]interface{} `json:"environment_json"`
+	Running     map[string]interface{} `json:"running_env_json"`
+	Staging     map[string]interface{} `json:"staging_env_json"`
+}
+
+func (resource ApplicationEnvResource) ToModel() (env models.AppEnvironment) {
+	env.System = resource.System
+	env.Application = resource.Application
+	env.User = resource.Environment
+	env.Running = resource.Running
+	env.Staging = resource.Staging
+	return
+}
+
 type PaginatedApplicationResources struct {
 	Resources []ApplicationResource
 }
@@ -123,7 +151,9 @@ type PaginatedApplicationResources struct {
 type ApplicationRepository interface {
 	Create(params models.AppParams) (createdApp models.Application, apiResponse net.ApiResponse)
 	Read(name string) (app models.Application, apiResponse net.ApiResponse)
+	ReadFromSpace(name string, spaceGuid string) (app models.Application, apiResponse net.ApiResponse)
 	Update(appGuid string, params models.AppParams) (updatedApp models.Application, apiResponse net.ApiResponse)
+	ReadEnv(appGuid string) (env models.AppEnvironment, apiResponse net.ApiResponse)
 	Delete(appGuid string) (apiResponse net.ApiResponse)
 }
 
@@ -157,7 +187,12 @@ func (repo CloudControllerApplicationRepository) Create(params models.AppParams)
 }
 
 func (repo CloudControllerApplicationRepository) Read(name string) (app models.Application, apiResponse net.ApiResponse) {
-	path := fmt.Sprintf("%s/v2/spaces/%s/apps?q=%s&inline-relations-depth=1", repo.config.ApiEndpoint(), repo.config.SpaceFields().Guid, url.QueryEscape("name:"+name))
+	return repo.ReadFromSpace(name, repo.config.SpaceFields().Guid)
+}
+
+// ReadFromSpace finds an app by name in a space other than the targeted one.
+func (repo CloudControllerApplicationRepository) ReadFromSpace(name string, spaceGuid string) (app models.Application, apiResponse net.ApiResponse) {
+	path := fmt.Sprintf("%s/v2/spaces/%s/apps?q=%s&inline-relations-depth=1", repo.config.ApiEndpoint(), spaceGuid, url.QueryEscape("name:"+name))
 	appResources := new(PaginatedApplicationResources)
 	apiResponse = repo.gateway.GetResource(path, repo.config.AccessToken(), appResources)
 	if apiResponse.IsNotSuccessful() {
@@ -192,6 +227,20 @@ func (repo CloudControllerApplicationRepository) Update(appGuid string, params m
 	return
 }
 
+// ReadEnv gets the whole environment of the app, with the variables the system provides such as
+// VCAP_SERVICES and the environment variable groups along with the ones set by the user.
+func (repo CloudControllerApplicationRepository) ReadEnv(appGuid string) (env models.AppEnvironment, apiResponse net.ApiResponse) {
+	path := fmt.Sprintf("%s/v2/apps/%s/env", repo.config.ApiEndpoint(), appGuid)
+	resource := new(ApplicationEnvResource)
+	apiResponse = repo.gateway.GetResource(path, repo.config.AccessToken(), resource)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+
+	env = resource.ToModel()
+	return
+}
+
 func (repo CloudControllerApplicationRepository) formatAppJSON(input models.AppParams) (data string, err error) {
 	appResource := NewApplicationEntityFromAppParams(input)
 	bytes, err := json.Marshal(appResource)
@@ -24,13 +24,29 @@ var _ = Describe("Testing with ginkgo", func() {
 		Expect(app.Name).To(Equal("My App"))
 		Expect(app.Guid).To(Equal("app1-guid"))
 		Expect(app.Memory).To(Equal(uint64(128)))
+		Expect(app.DiskQuota).To(Equal(uint64(1024)))
 		Expect(app.InstanceCount).To(Equal(1))
+		Expect(app.Command).To(Equal("bundle exec rackup"))
+		Expect(app.BuildpackUrl).To(Equal("https://github.com/cloudfoundry/heroku-buildpack-ruby.git"))
 		Expect(app.EnvironmentVars).To(Equal(map[string]string{"foo": "bar", "baz": "boom"}))
 		Expect(app.Routes[0].Host).To(Equal("app1"))
 		Expect(app.Routes[0].Domain.Name).To(Equal("cfapps.io"))
 		Expect(app.Stack.Name).To(Equal("awesome-stacks-ahoy"))
 	})
 
+	It("TestFindByNameInOtherSpace", func() {
+		request := testapi.NewCloudControllerTestRequest(findAppRequest)
+		request.Path = "/v2/spaces/other-space-guid/apps?q=name%3AMy+App&inline-relations-depth=1"
+
+		ts, handler, repo := createAppRepo([]testnet.TestRequest{request})
+		defer ts.Close()
+
+		app, apiResponse := repo.ReadFromSpace("My App", "other-space-guid")
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(app.Guid).To(Equal("app1-guid"))
+	})
+
 	It("TestFindByNameWhenAppIsNotFound", func() {
 
 		request := testapi.NewCloudControllerTestRequest(findAppRequest)
@@ -144,6 +160,42 @@ var _ = Describe("Testing with ginkgo", func() {
 		Expect(apiResponse.IsNotSuccessful()).To(BeFalse())
 	})
 
+	It("TestReadEnv", func() {
+		request := testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+			Method: "GET",
+			Path:   "/v2/apps/my-cool-app-guid/env",
+			Response: testnet.TestResponse{Status: http.StatusOK, Body: `
+{
+  "staging_env_json": {"STAGING_MIRROR": "http://mirror.example.com"},
+  "running_env_json": {"JAVA_OPTS": "-Xmx512m"},
+  "environment_json": {"DATABASE_URL": "mysql://example.com/my-db"},
+  "system_env_json": {
+    "VCAP_SERVICES": {"p-mysql": [{"name": "my-db", "credentials": {"port": 3306}}]}
+  },
+  "application_env_json": {
+    "VCAP_APPLICATION": {"name": "my-cool-app", "instance_index": 0}
+  }
+}`},
+		})
+
+		ts, handler, repo := createAppRepo([]testnet.TestRequest{request})
+		defer ts.Close()
+
+		env, apiResponse := repo.ReadEnv("my-cool-app-guid")
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+
+		Expect(env.User).To(Equal(map[string]interface{}{"DATABASE_URL": "mysql://example.com/my-db"}))
+		Expect(env.Running).To(Equal(map[string]interface{}{"JAVA_OPTS": "-Xmx512m"}))
+		Expect(env.Staging).To(Equal(map[string]interface{}{"STAGING_MIRROR": "http://mirror.example.com"}))
+		Expect(env.RuntimeVars()).To(Equal(map[string]string{
+			"DATABASE_URL":     "mysql://example.com/my-db",
+			"JAVA_OPTS":        "-Xmx512m",
+			"VCAP_SERVICES":    `{"p-mysql":[{"credentials":{"port":3306},"name":"my-db"}]}`,
+			"VCAP_APPLICATION": `{"instance_index":0,"name":"my-cool-app"}`,
+		}))
+	})
+
 	It("TestDeleteApplication", func() {
 		deleteApplicationRequest := testapi.NewCloudControllerTestRequest(testnet.TestRequest{
 			Method:   "DELETE",
@@ -176,7 +228,10 @@ var singleAppResponse = testnet.TestResponse{
       		"baz": "boom"
     	},
         "memory": 128,
+        "disk_quota": 1024,
         "instances": 1,
+        "command": "bundle exec rackup",
+        "buildpack": "https://github.com/cloudfoundry/heroku-buildpack-ruby.git",
         "state": "STOPPED",
         "stack": {
 			"metadata": {
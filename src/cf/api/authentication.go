@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 )
 
 type AuthenticationRepository interface {
@@ -18,13 +19,15 @@ type AuthenticationRepository interface {
 }
 
 type UAAAuthenticationRepository struct {
-	config  configuration.ReadWriter
-	gateway net.Gateway
+	config       configuration.ReadWriter
+	gateway      net.Gateway
+	refreshMutex *sync.Mutex
 }
 
 func NewUAAAuthenticationRepository(gateway net.Gateway, config configuration.ReadWriter) (uaa UAAAuthenticationRepository) {
 	uaa.gateway = gateway
 	uaa.config = config
+	uaa.refreshMutex = &sync.Mutex{}
 	return
 }
 
@@ -72,7 +75,20 @@ func (uaa UAAAuthenticationRepository) GetLoginPrompts() (prompts map[string]con
 	return
 }
 
+// RefreshAuthToken gets a new access token with the refresh token. Requests sent at the same time,
+// e.g. by apps pushed in parallel, can all find their token expired, so only one refresh runs at
+// a time, and whoever waited for it uses the token it got rather than refreshing again.
 func (uaa UAAAuthenticationRepository) RefreshAuthToken() (updatedToken string, apiResponse net.ApiResponse) {
+	expiredToken := uaa.config.AccessToken()
+
+	uaa.refreshMutex.Lock()
+	defer uaa.refreshMutex.Unlock()
+
+	if uaa.config.AccessToken() != expiredToken {
+		updatedToken = uaa.config.AccessToken()
+		return
+	}
+
 	data := url.Values{
 		"refresh_token": {uaa.config.RefreshToken()},
 		"grant_type":    {"refresh_token"},
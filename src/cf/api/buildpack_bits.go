@@ -11,7 +11,6 @@ import (
 	"fileutils"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"os"
 	"path"
@@ -20,7 +19,7 @@ import (
 )
 
 type BuildpackBitsRepository interface {
-	UploadBuildpack(buildpack models.Buildpack, dir string) (apiResponse net.ApiResponse)
+	UploadBuildpack(buildpack models.Buildpack, dir string, progressCb net.ProgressCallback) (apiResponse net.ApiResponse)
 }
 
 type CloudControllerBuildpackBitsRepository struct {
@@ -36,7 +35,7 @@ func NewCloudControllerBuildpackBitsRepository(config configuration.Reader, gate
 	return
 }
 
-func (repo CloudControllerBuildpackBitsRepository) UploadBuildpack(buildpack models.Buildpack, buildpackLocation string) (apiResponse net.ApiResponse) {
+func (repo CloudControllerBuildpackBitsRepository) UploadBuildpack(buildpack models.Buildpack, buildpackLocation string, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
 	fileutils.TempFile("buildpack-upload", func(zipFileToUpload *os.File, err error) {
 		if err != nil {
 			apiResponse = net.NewApiResponseWithError("Couldn't create temp file for upload", err)
@@ -80,7 +79,7 @@ func (repo CloudControllerBuildpackBitsRepository) UploadBuildpack(buildpack mod
 			return
 		}
 
-		apiResponse = repo.uploadBits(buildpack, zipFileToUpload, buildpackFileName)
+		apiResponse = repo.uploadBits(buildpack, zipFileToUpload, buildpackFileName, progressCb)
 	})
 
 	return
@@ -167,13 +166,7 @@ func downloadBuildpack(url string, cb func(*os.File, error)) {
 			return
 		}
 
-		client := &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-				Proxy:           http.ProxyFromEnvironment,
-			},
-		}
-		response, err := client.Get(url)
+		response, err := newDownloadClient().Get(url)
 		if err != nil {
 			cb(nil, err)
 			return
@@ -185,47 +178,35 @@ func downloadBuildpack(url string, cb func(*os.File, error)) {
 	})
 }
 
-func (repo CloudControllerBuildpackBitsRepository) uploadBits(buildpack models.Buildpack, body io.Reader, buildpackName string) net.ApiResponse {
-	return repo.performMultiPartUpload(
-		fmt.Sprintf("%s/v2/buildpacks/%s/bits", repo.config.ApiEndpoint(), buildpack.Guid),
-		"buildpack",
-		buildpackName,
-		body)
+func newDownloadClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			Proxy:           http.ProxyFromEnvironment,
+		},
+	}
 }
 
-func (repo CloudControllerBuildpackBitsRepository) performMultiPartUpload(url string, fieldName string, fileName string, body io.Reader) (apiResponse net.ApiResponse) {
-	fileutils.TempFile("requests", func(requestFile *os.File, err error) {
-		if err != nil {
-			apiResponse = net.NewApiResponseWithMessage(err.Error())
-			return
-		}
-
-		writer := multipart.NewWriter(requestFile)
-		part, err := writer.CreateFormFile(fieldName, fileName)
-
-		if err != nil {
-			writer.Close()
-			return
-		}
-
-		_, err = io.Copy(part, body)
-		writer.Close()
+func (repo CloudControllerBuildpackBitsRepository) uploadBits(buildpack models.Buildpack, zipFile *os.File, buildpackName string, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	url := fmt.Sprintf("%s/v2/buildpacks/%s/bits", repo.config.ApiEndpoint(), buildpack.Guid)
 
-		if err != nil {
-			apiResponse = net.NewApiResponseWithError("Error creating upload", err)
-			return
-		}
+	zipStats, err := zipFile.Stat()
+	if err != nil {
+		apiResponse = net.NewApiResponseWithError("Error creating upload", err)
+		return
+	}
 
-		var request *net.Request
-		request, apiResponse = repo.gateway.NewRequest("PUT", url, repo.config.AccessToken(), requestFile)
-		contentType := fmt.Sprintf("multipart/form-data; boundary=%s", writer.Boundary())
-		request.HttpReq.Header.Set("Content-Type", contentType)
-		if apiResponse.IsNotSuccessful() {
-			return
-		}
+	body := net.NewMultipartBody()
+	body.AddFile("buildpack", buildpackName, zipFile, zipStats.Size())
 
-		apiResponse = repo.gateway.PerformRequest(request)
+	request, apiResponse := repo.gateway.NewStreamingRequest("PUT", url, repo.config.AccessToken(), body.Length(), func() io.ReadCloser {
+		return body.Reader(progressCb)
 	})
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+	request.HttpReq.Header.Set("Content-Type", body.ContentType())
 
+	apiResponse = repo.gateway.PerformRequest(request)
 	return
 }
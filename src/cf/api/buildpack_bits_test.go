@@ -51,7 +51,7 @@ var _ = Describe("BuildpackBitsRepository", func() {
 
 	Describe("#UploadBuildpack", func() {
 		It("fails to upload a buildpack with an invalid directory", func() {
-			apiResponse := repo.UploadBuildpack(buildpack, "/foo/bar")
+			apiResponse := repo.UploadBuildpack(buildpack, "/foo/bar", nil)
 			Expect(apiResponse.IsNotSuccessful()).To(BeTrue())
 			Expect(apiResponse.Message).To(ContainSubstring("Error opening buildpack file"))
 		})
@@ -64,7 +64,7 @@ var _ = Describe("BuildpackBitsRepository", func() {
 			err := os.Chmod(filepath.Join(buildpackPath, "bin/release"), 0755)
 			Expect(err).NotTo(HaveOccurred())
 
-			apiResponse := repo.UploadBuildpack(buildpack, buildpackPath)
+			apiResponse := repo.UploadBuildpack(buildpack, buildpackPath, nil)
 			Expect(testServerHandler.AllRequestsCalled()).To(BeTrue())
 			Expect(apiResponse.IsSuccessful()).To(BeTrue())
 		})
@@ -72,16 +72,30 @@ var _ = Describe("BuildpackBitsRepository", func() {
 		It("uploads a valid zipped buildpack", func() {
 			buildpackPath := filepath.Join(buildpacksDir, "example-buildpack.zip")
 
-			apiResponse := repo.UploadBuildpack(buildpack, buildpackPath)
+			apiResponse := repo.UploadBuildpack(buildpack, buildpackPath, nil)
 			Expect(testServerHandler.AllRequestsCalled()).To(BeTrue())
 			Expect(apiResponse.IsSuccessful()).To(BeTrue())
 		})
 
+		It("reports the progress of the upload", func() {
+			buildpackPath := filepath.Join(buildpacksDir, "example-buildpack.zip")
+
+			var reportedBytesRead, reportedTotalBytes int64
+			apiResponse := repo.UploadBuildpack(buildpack, buildpackPath, func(bytesRead, totalBytes int64) {
+				reportedBytesRead = bytesRead
+				reportedTotalBytes = totalBytes
+			})
+
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(reportedTotalBytes > 0).To(BeTrue())
+			Expect(reportedBytesRead).To(Equal(reportedTotalBytes))
+		})
+
 		Describe("when the buildpack is wrapped in an extra top-level directory", func() {
 			It("uploads a zip file containing only the actual buildpack", func() {
 				buildpackPath := filepath.Join(buildpacksDir, "example-buildpack-in-dir.zip")
 
-				apiResponse := repo.UploadBuildpack(buildpack, buildpackPath)
+				apiResponse := repo.UploadBuildpack(buildpack, buildpackPath, nil)
 				Expect(testServerHandler.AllRequestsCalled()).To(BeTrue())
 				Expect(apiResponse.IsSuccessful()).To(BeTrue())
 			})
@@ -102,7 +116,7 @@ var _ = Describe("BuildpackBitsRepository", func() {
 					fileServer := httptest.NewServer(buildpackFileServerHandler("bad-buildpack.zip"))
 					defer fileServer.Close()
 
-					apiResponse := repo.UploadBuildpack(buildpack, fileServer.URL+"/place/bad-buildpack.zip")
+					apiResponse := repo.UploadBuildpack(buildpack, fileServer.URL+"/place/bad-buildpack.zip", nil)
 					Expect(testServerHandler.AllRequestsCalled()).To(BeFalse())
 					Expect(apiResponse.IsSuccessful()).To(BeFalse())
 				})
@@ -112,7 +126,7 @@ var _ = Describe("BuildpackBitsRepository", func() {
 				fileServer := httptest.NewServer(buildpackFileServerHandler("example-buildpack.zip"))
 				defer fileServer.Close()
 
-				apiResponse := repo.UploadBuildpack(buildpack, fileServer.URL+"/place/example-buildpack.zip")
+				apiResponse := repo.UploadBuildpack(buildpack, fileServer.URL+"/place/example-buildpack.zip", nil)
 				Expect(testServerHandler.AllRequestsCalled()).To(BeTrue())
 				Expect(apiResponse.IsSuccessful()).To(BeTrue())
 			})
@@ -121,7 +135,7 @@ var _ = Describe("BuildpackBitsRepository", func() {
 				fileServer := httptest.NewTLSServer(buildpackFileServerHandler("example-buildpack.zip"))
 				defer fileServer.Close()
 
-				apiResponse := repo.UploadBuildpack(buildpack, fileServer.URL+"/place/example-buildpack.zip")
+				apiResponse := repo.UploadBuildpack(buildpack, fileServer.URL+"/place/example-buildpack.zip", nil)
 				Expect(testServerHandler.AllRequestsCalled()).To(BeTrue())
 				Expect(apiResponse.IsSuccessful()).To(BeTrue())
 			})
@@ -131,14 +145,14 @@ var _ = Describe("BuildpackBitsRepository", func() {
 					fileServer := httptest.NewTLSServer(buildpackFileServerHandler("example-buildpack-in-dir.zip"))
 					defer fileServer.Close()
 
-					apiResponse := repo.UploadBuildpack(buildpack, fileServer.URL+"/place/example-buildpack-in-dir.zip")
+					apiResponse := repo.UploadBuildpack(buildpack, fileServer.URL+"/place/example-buildpack-in-dir.zip", nil)
 					Expect(testServerHandler.AllRequestsCalled()).To(BeTrue())
 					Expect(apiResponse.IsSuccessful()).To(BeTrue())
 				})
 			})
 
 			It("returns an unsuccessful response when the server cannot be reached", func() {
-				apiResponse := repo.UploadBuildpack(buildpack, "https://domain.bad-domain:223453/no-place/example-buildpack.zip")
+				apiResponse := repo.UploadBuildpack(buildpack, "https://domain.bad-domain:223453/no-place/example-buildpack.zip", nil)
 				Expect(testServerHandler.AllRequestsCalled()).To(BeFalse())
 				Expect(apiResponse.IsSuccessful()).To(BeFalse())
 			})
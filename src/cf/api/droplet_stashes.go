@@ -0,0 +1,219 @@
+package api
+
+import (
+	"cf/configuration"
+	"cf/models"
+	"cf/net"
+	"encoding/json"
+	"fileutils"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// MaxDropletStashes is how many droplets are kept for each app, older ones are deleted.
+const MaxDropletStashes = 5
+
+const (
+	dropletStashFile  = "droplet.tgz"
+	dropletStashInfo  = "stash.json"
+	dropletStashesDir = "droplets"
+)
+
+type DropletStashRepository interface {
+	Stash(app models.Application, progressCb net.ProgressCallback) (stash models.DropletStash, apiResponse net.ApiResponse)
+	ListStashes(appName string) (stashes []models.DropletStash, apiResponse net.ApiResponse)
+	Restore(appGuid string, stash models.DropletStash, progressCb net.ProgressCallback) (apiResponse net.ApiResponse)
+	Delete(stash models.DropletStash) (apiResponse net.ApiResponse)
+}
+
+// LocalDropletStashRepository keeps droplets on disk, in a directory for each app in the
+// targeted space, so that apps can be rolled back without the cloud controller keeping them.
+type LocalDropletStashRepository struct {
+	config      configuration.Reader
+	appBitsRepo ApplicationBitsRepository
+	dir         string
+	now         func() time.Time
+}
+
+func NewLocalDropletStashRepository(config configuration.Reader, appBitsRepo ApplicationBitsRepository, dir string) (repo LocalDropletStashRepository) {
+	repo.config = config
+	repo.appBitsRepo = appBitsRepo
+	repo.dir = dir
+	repo.now = time.Now
+	return
+}
+
+// DefaultDropletStashDir is the directory next to the config file that droplets are stashed in.
+func DefaultDropletStashDir() string {
+	return filepath.Join(filepath.Dir(configuration.DefaultFilePath()), dropletStashesDir)
+}
+
+type DropletStashResource struct {
+	Version         int               `json:"version"`
+	AppName         string            `json:"app_name"`
+	StashedAt       time.Time         `json:"stashed_at"`
+	BuildpackUrl    string            `json:"buildpack"`
+	Command         string            `json:"command"`
+	DiskQuota       uint64            `json:"disk_quota"`
+	EnvironmentVars map[string]string `json:"environment_json"`
+	InstanceCount   int               `json:"instances"`
+	Memory          uint64            `json:"memory"`
+}
+
+func NewDropletStashResource(stash models.DropletStash) DropletStashResource {
+	return DropletStashResource{
+		Version:         stash.Version,
+		AppName:         stash.AppName,
+		StashedAt:       stash.StashedAt,
+		BuildpackUrl:    stash.BuildpackUrl,
+		Command:         stash.Command,
+		DiskQuota:       stash.DiskQuota,
+		EnvironmentVars: stash.EnvironmentVars,
+		InstanceCount:   stash.InstanceCount,
+		Memory:          stash.Memory,
+	}
+}
+
+func (resource DropletStashResource) ToModel() models.DropletStash {
+	return models.DropletStash{
+		Version:         resource.Version,
+		AppName:         resource.AppName,
+		StashedAt:       resource.StashedAt,
+		BuildpackUrl:    resource.BuildpackUrl,
+		Command:         resource.Command,
+		DiskQuota:       resource.DiskQuota,
+		EnvironmentVars: resource.EnvironmentVars,
+		InstanceCount:   resource.InstanceCount,
+		Memory:          resource.Memory,
+	}
+}
+
+// Stash downloads the current droplet of app and saves it with the app's settings as the newest
+// version. Only the newest MaxDropletStashes versions are kept.
+func (repo LocalDropletStashRepository) Stash(app models.Application, progressCb net.ProgressCallback) (stash models.DropletStash, apiResponse net.ApiResponse) {
+	stashes, apiResponse := repo.ListStashes(app.Name)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+
+	stash = models.NewDropletStash(app)
+	stash.StashedAt = repo.now()
+	stash.Version = 1
+	if len(stashes) > 0 {
+		stash.Version = stashes[0].Version + 1
+	}
+
+	stashDir := repo.stashDir(stash)
+	dropletFile, err := fileutils.CreateFile(filepath.Join(stashDir, dropletStashFile))
+	if err != nil {
+		apiResponse = net.NewApiResponseWithError("Error creating droplet stash", err)
+		return
+	}
+
+	apiResponse = repo.appBitsRepo.DownloadDroplet(app.Guid, dropletFile, progressCb)
+	dropletFile.Close()
+	if apiResponse.IsNotSuccessful() {
+		os.RemoveAll(stashDir)
+		return
+	}
+
+	info, err := json.MarshalIndent(NewDropletStashResource(stash), "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(stashDir, dropletStashInfo), info, 0600)
+	}
+	if err != nil {
+		os.RemoveAll(stashDir)
+		apiResponse = net.NewApiResponseWithError("Error saving droplet stash", err)
+		return
+	}
+
+	for _, oldStash := range stashes {
+		if stash.Version-oldStash.Version >= MaxDropletStashes {
+			repo.Delete(oldStash)
+		}
+	}
+	return
+}
+
+// ListStashes returns the stashed droplets of the app, newest first.
+func (repo LocalDropletStashRepository) ListStashes(appName string) (stashes []models.DropletStash, apiResponse net.ApiResponse) {
+	stashes = []models.DropletStash{}
+
+	entries, err := ioutil.ReadDir(repo.appDir(appName))
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		apiResponse = net.NewApiResponseWithError("Error reading droplet stashes", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil || !entry.IsDir() {
+			continue
+		}
+
+		info, err := ioutil.ReadFile(filepath.Join(repo.appDir(appName), entry.Name(), dropletStashInfo))
+		if err != nil {
+			continue
+		}
+
+		resource := DropletStashResource{}
+		err = json.Unmarshal(info, &resource)
+		if err != nil {
+			apiResponse = net.NewApiResponseWithError(fmt.Sprintf("Invalid droplet stash in %s", entry.Name()), err)
+			return
+		}
+		stashes = append(stashes, resource.ToModel())
+	}
+
+	sort.Sort(dropletStashesByVersion(stashes))
+	return
+}
+
+// Restore uploads the stashed droplet as the current droplet of the app with the given guid.
+func (repo LocalDropletStashRepository) Restore(appGuid string, stash models.DropletStash, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	dropletFile, err := os.Open(filepath.Join(repo.stashDir(stash), dropletStashFile))
+	if err != nil {
+		apiResponse = net.NewApiResponseWithError("Error opening stashed droplet", err)
+		return
+	}
+	defer dropletFile.Close()
+
+	return repo.appBitsRepo.UploadDroplet(appGuid, dropletFile, progressCb)
+}
+
+func (repo LocalDropletStashRepository) Delete(stash models.DropletStash) (apiResponse net.ApiResponse) {
+	err := os.RemoveAll(repo.stashDir(stash))
+	if err != nil {
+		apiResponse = net.NewApiResponseWithError("Error deleting droplet stash", err)
+	}
+	return
+}
+
+func (repo LocalDropletStashRepository) appDir(appName string) string {
+	return filepath.Join(repo.dir, repo.config.SpaceFields().Guid, appName)
+}
+
+func (repo LocalDropletStashRepository) stashDir(stash models.DropletStash) string {
+	return filepath.Join(repo.appDir(stash.AppName), strconv.Itoa(stash.Version))
+}
+
+type dropletStashesByVersion []models.DropletStash
+
+func (stashes dropletStashesByVersion) Len() int {
+	return len(stashes)
+}
+
+func (stashes dropletStashesByVersion) Swap(i, j int) {
+	stashes[i], stashes[j] = stashes[j], stashes[i]
+}
+
+func (stashes dropletStashesByVersion) Less(i, j int) bool {
+	return stashes[i].Version > stashes[j].Version
+}
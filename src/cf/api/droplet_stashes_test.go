@@ -0,0 +1,110 @@
+package api_test
+
+import (
+	. "cf/api"
+	"cf/models"
+	"fileutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	testapi "testhelpers/api"
+	testconfig "testhelpers/configuration"
+)
+
+var _ = Describe("LocalDropletStashRepository", func() {
+	var (
+		appBitsRepo *testapi.FakeApplicationBitsRepository
+		app         models.Application
+	)
+
+	BeforeEach(func() {
+		appBitsRepo = &testapi.FakeApplicationBitsRepository{DownloadContents: "droplet v1"}
+
+		app = models.Application{}
+		app.Name = "my-app"
+		app.Guid = "my-app-guid"
+		app.Memory = 256
+		app.InstanceCount = 2
+		app.Command = "run.sh"
+		app.EnvironmentVars = map[string]string{"FOO": "bar"}
+	})
+
+	withRepo := func(test func(repo LocalDropletStashRepository)) {
+		fileutils.TempDir("droplet_stashes_test", func(dir string, err error) {
+			Expect(err).NotTo(HaveOccurred())
+			test(NewLocalDropletStashRepository(testconfig.NewRepositoryWithDefaults(), appBitsRepo, dir))
+		})
+	}
+
+	It("stashes the droplet of the app with its settings", func() {
+		withRepo(func(repo LocalDropletStashRepository) {
+			stash, apiResponse := repo.Stash(app, func(bytesRead, totalBytes int64) {})
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(stash.Version).To(Equal(1))
+			Expect(appBitsRepo.DownloadedDropletGuid).To(Equal("my-app-guid"))
+
+			stashes, apiResponse := repo.ListStashes("my-app")
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(len(stashes)).To(Equal(1))
+			Expect(stashes[0].Memory).To(Equal(uint64(256)))
+			Expect(stashes[0].InstanceCount).To(Equal(2))
+			Expect(stashes[0].Command).To(Equal("run.sh"))
+			Expect(stashes[0].EnvironmentVars).To(Equal(map[string]string{"FOO": "bar"}))
+			Expect(stashes[0].StashedAt.IsZero()).To(BeFalse())
+		})
+	})
+
+	It("lists the newest stash first and restores its droplet", func() {
+		withRepo(func(repo LocalDropletStashRepository) {
+			repo.Stash(app, func(bytesRead, totalBytes int64) {})
+			appBitsRepo.DownloadContents = "droplet v2"
+			repo.Stash(app, func(bytesRead, totalBytes int64) {})
+
+			stashes, _ := repo.ListStashes("my-app")
+			Expect(len(stashes)).To(Equal(2))
+			Expect(stashes[0].Version).To(Equal(2))
+			Expect(stashes[1].Version).To(Equal(1))
+
+			apiResponse := repo.Restore("other-app-guid", stashes[0], func(bytesRead, totalBytes int64) {})
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(appBitsRepo.UploadedDropletGuid).To(Equal("other-app-guid"))
+			Expect(appBitsRepo.UploadedDropletContents).To(Equal("droplet v2"))
+		})
+	})
+
+	It("only keeps the newest stashes", func() {
+		withRepo(func(repo LocalDropletStashRepository) {
+			for i := 0; i < MaxDropletStashes+2; i++ {
+				repo.Stash(app, func(bytesRead, totalBytes int64) {})
+			}
+
+			stashes, _ := repo.ListStashes("my-app")
+			Expect(len(stashes)).To(Equal(MaxDropletStashes))
+			Expect(stashes[0].Version).To(Equal(MaxDropletStashes + 2))
+			Expect(stashes[len(stashes)-1].Version).To(Equal(3))
+		})
+	})
+
+	It("deletes stashes", func() {
+		withRepo(func(repo LocalDropletStashRepository) {
+			stash, _ := repo.Stash(app, func(bytesRead, totalBytes int64) {})
+
+			apiResponse := repo.Delete(stash)
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+
+			stashes, _ := repo.ListStashes("my-app")
+			Expect(stashes).To(BeEmpty())
+		})
+	})
+
+	It("does not keep anything when the droplet cannot be downloaded", func() {
+		withRepo(func(repo LocalDropletStashRepository) {
+			appBitsRepo.DownloadErr = true
+
+			_, apiResponse := repo.Stash(app, func(bytesRead, totalBytes int64) {})
+			Expect(apiResponse.IsNotSuccessful()).To(BeTrue())
+
+			stashes, _ := repo.ListStashes("my-app")
+			Expect(stashes).To(BeEmpty())
+		})
+	})
+})
@@ -15,6 +15,7 @@ type RepositoryLocator struct {
 	spaceRepo                       CloudControllerSpaceRepository
 	appRepo                         CloudControllerApplicationRepository
 	appBitsRepo                     CloudControllerApplicationBitsRepository
+	dropletStashRepo                LocalDropletStashRepository
 	appSummaryRepo                  CloudControllerAppSummaryRepository
 	appInstancesRepo                CloudControllerAppInstancesRepository
 	appEventsRepo                   CloudControllerAppEventsRepository
@@ -46,7 +47,8 @@ func NewRepositoryLocator(config configuration.ReadWriter, gatewaysByName map[st
 	cloudControllerGateway.SetTokenRefresher(loc.authRepo)
 	uaaGateway.SetTokenRefresher(loc.authRepo)
 
-	loc.appBitsRepo = NewCloudControllerApplicationBitsRepository(config, cloudControllerGateway, cf.ApplicationZipper{})
+	loc.appBitsRepo = NewCloudControllerApplicationBitsRepository(config, cloudControllerGateway, cf.ApplicationZipper{}, cf.NewFileHashCache(DefaultFileHashCachePath()))
+	loc.dropletStashRepo = NewLocalDropletStashRepository(config, loc.appBitsRepo, DefaultDropletStashDir())
 	loc.appEventsRepo = NewCloudControllerAppEventsRepository(config, cloudControllerGateway)
 	loc.appFilesRepo = NewCloudControllerAppFilesRepository(config, cloudControllerGateway)
 	loc.appSshRepo = NewCloudControllerAppSshRepository(config, cloudControllerGateway)
@@ -108,6 +110,10 @@ func (locator RepositoryLocator) GetApplicationBitsRepository() ApplicationBitsR
 	return locator.appBitsRepo
 }
 
+func (locator RepositoryLocator) GetDropletStashRepository() DropletStashRepository {
+	return locator.dropletStashRepo
+}
+
 func (locator RepositoryLocator) GetAppSummaryRepository() AppSummaryRepository {
 	return locator.appSummaryRepo
 }
@@ -46,7 +46,11 @@ func NewApp(cmdRunner commands.Runner) (app *cli.App, err error) {
 		{
 			Name:        "app",
 			Description: "Display health and status for app",
-			Usage:       fmt.Sprintf("%s app APP", cf.Name()),
+			Usage:       fmt.Sprintf("%s app APP [--watch [--interval SECONDS]]", cf.Name()),
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: "watch", Usage: "Keep showing the stats of the instances, redrawn in place, until q is pressed"},
+				NewIntFlagWithValue("interval", "Seconds between updates with --watch", 3),
+			},
 			Action: func(c *cli.Context) {
 				cmdRunner.RunCmdByName("app", c)
 			},
@@ -72,6 +76,25 @@ func NewApp(cmdRunner commands.Runner) (app *cli.App, err error) {
 				cmdRunner.RunCmdByName("auth", c)
 			},
 		},
+		{
+			Name:        "autoscale",
+			Description: "Keep scaling an app to hold the cpu usage of its instances at a target",
+			Usage: fmt.Sprintf("%s autoscale APP --max INSTANCES [--min INSTANCES] [--cpu-target PERCENT] [--interval SECONDS] [--cooldown SECONDS] [--dry-run] [--log FILE]\n\n", cf.Name()) +
+				"   Runs until it is stopped with Ctrl-C, polling the stats of the instances and changing the\n" +
+				"   instance count in proportion to how far the average cpu usage is from the target.",
+			Flags: []cli.Flag{
+				NewIntFlagWithValue("min", "Minimum number of instances", 1),
+				NewIntFlagWithValue("max", "Maximum number of instances", -1),
+				NewIntFlagWithValue("cpu-target", "Average cpu usage of the running instances to aim for, in percent of a core", 70),
+				NewIntFlagWithValue("interval", "Seconds between polls of the instance stats", 30),
+				NewIntFlagWithValue("cooldown", "Seconds to wait after scaling before scaling again", 300),
+				cli.BoolFlag{Name: "dry-run", Usage: "Only log what would be scaled, without scaling the app"},
+				NewStringFlag("log", "Append every decision to this file"),
+			},
+			Action: func(c *cli.Context) {
+				cmdRunner.RunCmdByName("autoscale", c)
+			},
+		},
 		{
 			Name:        "bind-service",
 			ShortName:   "bs",
@@ -89,6 +112,32 @@ func NewApp(cmdRunner commands.Runner) (app *cli.App, err error) {
 				cmdRunner.RunCmdByName("buildpacks", c)
 			},
 		},
+		{
+			Name:        "copy-source",
+			Description: "Copy the source bits of an app to another app, which may be in another space",
+			Usage: fmt.Sprintf("%s copy-source SOURCE-APP TARGET-APP [--space SPACE [--org ORG]] [--restart]\n\n", cf.Name()) +
+				"EXAMPLE:\n" +
+				fmt.Sprintf("   %s copy-source my-app my-app --space production --restart (ship the bits tested in the targeted space)", cf.Name()),
+			Flags: []cli.Flag{
+				NewStringFlag("space", "Space of the target app, defaults to the targeted space"),
+				NewStringFlag("org", "Org of the target space, defaults to the targeted org"),
+				cli.BoolFlag{Name: "restart", Usage: "Restart the target app once the bits are copied, to stage them"},
+			},
+			Action: func(c *cli.Context) {
+				cmdRunner.RunCmdByName("copy-source", c)
+			},
+		},
+		{
+			Name:        "create-app-manifest",
+			Description: "Create an app manifest for an app that has been pushed successfully",
+			Usage:       fmt.Sprintf("%s create-app-manifest APP [-p /path/to/<app-name>_manifest.yml]", cf.Name()),
+			Flags: []cli.Flag{
+				NewStringFlag("p", "Specify a path for file creation. If path not specified, manifest file is created in current working directory."),
+			},
+			Action: func(c *cli.Context) {
+				cmdRunner.RunCmdByName("create-app-manifest", c)
+			},
+		},
 		{
 			Name:        "create-buildpack",
 			Description: "Create a buildpack",
@@ -347,19 +396,64 @@ func NewApp(cmdRunner commands.Runner) (app *cli.App, err error) {
 				cmdRunner.RunCmdByName("domains", c)
 			},
 		},
+		{
+			Name:        "download",
+			Description: "Download the source bits or the droplet of an app",
+			Usage: fmt.Sprintf("%s download APP [--droplet] [-o PATH]\n", cf.Name()) +
+				fmt.Sprintf("   %s download APP --unpack [-o DIRECTORY]", cf.Name()),
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: "droplet", Usage: "Download the staged droplet instead of the source bits"},
+				NewStringFlag("o", "Path to save the download to, defaults to APP.zip, APP-droplet.tgz or APP when unpacking"),
+				cli.BoolFlag{Name: "unpack", Usage: "Unpack the source bits into a directory"},
+			},
+			Action: func(c *cli.Context) {
+				cmdRunner.RunCmdByName("download", c)
+			},
+		},
 		{
 			Name:        "env",
 			ShortName:   "e",
 			Description: "Show all env variables for an app",
-			Usage:       fmt.Sprintf("%s env APP", cf.Name()),
+			Usage: fmt.Sprintf("%s env APP [--export dotenv|json|shell]\n\n", cf.Name()) +
+				"EXAMPLE:\n" +
+				fmt.Sprintf("   %s env my-app --export dotenv > .env (write the env of the running app, VCAP_SERVICES included, to a file)", cf.Name()),
+			Flags: []cli.Flag{
+				NewStringFlag("export", "Print only the env variables the app runs with, as dotenv, json or shell (dotenv and shell skip names a shell would not accept)"),
+			},
 			Action: func(c *cli.Context) {
 				cmdRunner.RunCmdByName("env", c)
 			},
 		},
+		{
+			Name:        "env-diff",
+			Description: "Show how the configuration of two apps differs",
+			Usage: fmt.Sprintf("%s env-diff APP_REF OTHER_APP_REF [--json]\n\n", cf.Name()) +
+				"   An app is referred to as APP in the targeted space, SPACE/APP in the targeted org, or ORG/SPACE/APP.\n" +
+				"   Env variables, memory, disk, instances, buildpack, command, stack, routes and bound services are\n" +
+				"   compared, and the values of env variables that look like secrets are masked.\n\n" +
+				"EXAMPLE:\n" +
+				fmt.Sprintf("   %s env-diff staging/my-app production/my-app", cf.Name()),
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: "json", Usage: "Print the differences as JSON"},
+			},
+			Action: func(c *cli.Context) {
+				cmdRunner.RunCmdByName("env-diff", c)
+			},
+		},
 		{
 			Name:        "events",
 			Description: "Show recent app events",
-			Usage:       fmt.Sprintf("%s events APP", cf.Name()),
+			Usage: fmt.Sprintf("%s events APP [--since TIME] [--until TIME] [--type TYPE[,TYPE...]] [--follow]\n\n", cf.Name()) +
+				"TIME is a date, a time like 2014-01-21T15:04:05Z or a duration before now like 2h\n\n" +
+				"EXAMPLE:\n" +
+				fmt.Sprintf("   %s events my-app --since 24h --type crash (crashes of the last day)\n", cf.Name()) +
+				fmt.Sprintf("   %s events my-app --follow (keep printing new events)", cf.Name()),
+			Flags: []cli.Flag{
+				NewStringFlag("since", "Only show events from this time on"),
+				NewStringFlag("until", "Only show events up to this time"),
+				NewStringFlag("type", "Only show events of these types, like crash, update or audit.app.create"),
+				cli.BoolFlag{Name: "follow", Usage: "Keep printing new events until stopped with Ctrl-C"},
+			},
 			Action: func(c *cli.Context) {
 				cmdRunner.RunCmdByName("events", c)
 			},
@@ -368,7 +462,18 @@ func NewApp(cmdRunner commands.Runner) (app *cli.App, err error) {
 			Name:        "files",
 			ShortName:   "f",
 			Description: "Print out a list of files in a directory or the contents of a specific file",
-			Usage:       fmt.Sprintf("%s files APP [PATH]", cf.Name()),
+			Usage: fmt.Sprintf("%s files APP [PATH] [-i INSTANCE] [-o LOCAL_PATH [--recursive] | --tail]\n\n", cf.Name()) +
+				"EXAMPLE:\n" +
+				fmt.Sprintf("   %s files my-app /app/logs (list the files in a directory of instance 0)\n", cf.Name()) +
+				fmt.Sprintf("   %s files my-app /app/core -i 2 -o core (download a file of instance 2 as it is)\n", cf.Name()) +
+				fmt.Sprintf("   %s files my-app /app/logs -o logs --recursive (download a whole directory)\n", cf.Name()) +
+				fmt.Sprintf("   %s files my-app /app/logs/stdout.log --tail (print lines as they are appended to a file)", cf.Name()),
+			Flags: []cli.Flag{
+				NewIntFlagWithValue("i", "Index of the instance to get the files of", 0),
+				NewStringFlag("o", "Download the file to this local path instead of printing it"),
+				cli.BoolFlag{Name: "recursive", Usage: "Download the directory with all its files and subdirectories into the local path given with -o"},
+				cli.BoolFlag{Name: "tail", Usage: "Keep printing what is appended to the file until stopped with Ctrl-C"},
+			},
 			Action: func(c *cli.Context) {
 				cmdRunner.RunCmdByName("files", c)
 			},
@@ -495,24 +600,36 @@ func NewApp(cmdRunner commands.Runner) (app *cli.App, err error) {
 			Usage: "Push a single app (with or without a manifest):\n" +
 				fmt.Sprintf("   %s push APP [-b BUILDPACK_NAME] [-c COMMAND] [-d DOMAIN] [-f MANIFEST_PATH]\n", cf.Name()) +
 				"   [-i NUM_INSTANCES] [-m MEMORY] [-n HOST] [-p PATH] [-s STACK] [-t TIMEOUT]\n" +
-				"   [--no-hostname] [--no-manifest] [--no-route] [--no-start]" +
+				"   [--no-hostname] [--no-manifest] [--no-route] [--no-start] [--unmap-unlisted-routes]\n" +
+				"   [--strategy blue-green] [--var KEY=VALUE] [--vars-file VARS_FILE_PATH] [--plan [--json]]\n" +
+				"   [--gitignore] [--show-files] [--stash]" +
 				"\n\n   Push multiple apps with a manifest:\n" +
-				fmt.Sprintf("   %s push [-f MANIFEST_PATH]\n", cf.Name()),
+				fmt.Sprintf("   %s push [-f MANIFEST_PATH] [--parallel NUM_APPS]\n", cf.Name()),
 			Flags: []cli.Flag{
 				NewStringFlag("b", "Custom buildpack by name (e.g. my-buildpack) or GIT URL (e.g. https://github.com/heroku/heroku-buildpack-play.git)"),
 				NewStringFlag("c", "Startup command, set to null to reset to default start command"),
-				NewStringFlag("d", "Domain (e.g. example.com)"),
+				NewStringSliceFlag("d", "Domain (e.g. example.com), flag can be specified multiple times"),
 				NewStringFlag("f", "Path to manifest"),
 				NewStringFlag("i", "Number of instances"),
 				NewStringFlag("m", "Memory limit (e.g. 256M, 1024M, 1G)"),
-				NewStringFlag("n", "Hostname (e.g. my-subdomain)"),
-				NewStringFlag("p", "Path of app directory or zip file"),
+				NewStringSliceFlag("n", "Hostname (e.g. my-subdomain), flag can be specified multiple times"),
+				NewStringFlag("p", "Path of app directory or zip file, URL of a zip archive, or git+URL#REF of a git repository"),
 				NewStringFlag("s", "Stack to use"),
 				NewStringFlag("t", "Start timeout in seconds"),
 				cli.BoolFlag{Name: "no-hostname", Usage: "Map the root domain to this app"},
 				cli.BoolFlag{Name: "no-manifest", Usage: "Ignore manifest file"},
 				cli.BoolFlag{Name: "no-route", Usage: "Do not map a route to this app"},
 				cli.BoolFlag{Name: "no-start", Usage: "Do not start an app after pushing"},
+				cli.BoolFlag{Name: "unmap-unlisted-routes", Usage: "Unmap routes that are not given with -n and -d or listed in the manifest"},
+				NewIntFlagWithValue("parallel", "Number of apps from the manifest to push at the same time", 1),
+				cli.BoolFlag{Name: "plan", Usage: "Show what the push would change without changing anything"},
+				cli.BoolFlag{Name: "json", Usage: "Print the plan as JSON (with --plan)"},
+				cli.BoolFlag{Name: "gitignore", Usage: "Also leave out files ignored by .gitignore files"},
+				cli.BoolFlag{Name: "show-files", Usage: "List the files that would be uploaded without pushing"},
+				cli.BoolFlag{Name: "stash", Usage: "Keep the current droplet locally so that the push can be rolled back"},
+				NewStringFlag("strategy", "Deployment strategy, 'blue-green' starts the new version next to the old one and switches routes over without downtime"),
+				NewStringSliceFlag("var", "Value for ${KEY} in the manifest (e.g. instances=3, write $${ for a literal ${), flag can be specified multiple times"),
+				NewStringSliceFlag("vars-file", "Path to a YAML file of manifest variables, flag can be specified multiple times"),
 			},
 			Action: func(c *cli.Context) {
 				cmdRunner.RunCmdByName("push", c)
@@ -570,11 +687,33 @@ func NewApp(cmdRunner commands.Runner) (app *cli.App, err error) {
 			Name:        "restart",
 			ShortName:   "rs",
 			Description: "Restart an app",
-			Usage:       fmt.Sprintf("%s restart APP", cf.Name()),
+			Usage:       fmt.Sprintf("%s restart APP [--rolling]", cf.Name()),
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: "rolling", Usage: "Restart the instances one at a time, each once the one before it is running again"},
+			},
 			Action: func(c *cli.Context) {
 				cmdRunner.RunCmdByName("restart", c)
 			},
 		},
+		{
+			Name:        "restart-app-instance",
+			Description: "Restart a single instance of an app",
+			Usage:       fmt.Sprintf("%s restart-app-instance APP INDEX", cf.Name()),
+			Action: func(c *cli.Context) {
+				cmdRunner.RunCmdByName("restart-app-instance", c)
+			},
+		},
+		{
+			Name:        "rollback",
+			Description: "Restore the droplet and settings an app had before a push with --stash",
+			Usage:       fmt.Sprintf("%s rollback APP [--list]", cf.Name()),
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: "list", Usage: "List the stashed droplets instead of rolling back"},
+			},
+			Action: func(c *cli.Context) {
+				cmdRunner.RunCmdByName("rollback", c)
+			},
+		},
 		{
 			Name:        "routes",
 			ShortName:   "r",
@@ -652,7 +791,16 @@ func NewApp(cmdRunner commands.Runner) (app *cli.App, err error) {
 			Name:        "set-env",
 			ShortName:   "se",
 			Description: "Set an env variable for an app",
-			Usage:       fmt.Sprintf("%s set-env APP NAME VALUE", cf.Name()),
+			Usage: fmt.Sprintf("%s set-env APP NAME VALUE [--restart]\n", cf.Name()) +
+				fmt.Sprintf("   %s set-env APP --from-file FILE [--replace] [--restart]\n\n", cf.Name()) +
+				"EXAMPLE:\n" +
+				fmt.Sprintf("   %s set-env my-app --from-file vars.env (set all the variables in a dotenv file)\n", cf.Name()) +
+				fmt.Sprintf("   %s set-env my-app --from-file vars.yml --replace --restart (make the env exactly match a YAML file and restart once)", cf.Name()),
+			Flags: []cli.Flag{
+				NewStringFlag("from-file", "Set the env variables in a dotenv file, or a YAML file when its name ends in .yml or .yaml"),
+				cli.BoolFlag{Name: "replace", Usage: "Remove the env variables that are not in the file given with --from-file"},
+				cli.BoolFlag{Name: "restart", Usage: "Restart the app once the env variables are set"},
+			},
 			Action: func(c *cli.Context) {
 				cmdRunner.RunCmdByName("set-env", c)
 			},
@@ -707,6 +855,20 @@ func NewApp(cmdRunner commands.Runner) (app *cli.App, err error) {
 				cmdRunner.RunCmdByName("space", c)
 			},
 		},
+		{
+			Name:        "space-events",
+			Description: "Show recent events of all apps in the targeted space",
+			Usage:       fmt.Sprintf("%s space-events [--since TIME] [--until TIME] [--type TYPE[,TYPE...]] [--json]", cf.Name()),
+			Flags: []cli.Flag{
+				NewStringFlag("since", "Only show events from this time on"),
+				NewStringFlag("until", "Only show events up to this time"),
+				NewStringFlag("type", "Only show events of these types, like crash, update or audit.app.create"),
+				cli.BoolFlag{Name: "json", Usage: "Print the events as JSON"},
+			},
+			Action: func(c *cli.Context) {
+				cmdRunner.RunCmdByName("space-events", c)
+			},
+		},
 		{
 			Name:        "space-users",
 			Description: "Show space users by role",
@@ -120,20 +120,29 @@ func newAppPresenter(app *cli.App) (presenter appPresenter) {
 				}, {
 					newCmdPresenter(app, maxNameLen, "push"),
 					newCmdPresenter(app, maxNameLen, "scale"),
+					newCmdPresenter(app, maxNameLen, "autoscale"),
 					newCmdPresenter(app, maxNameLen, "delete"),
 					newCmdPresenter(app, maxNameLen, "rename"),
+					newCmdPresenter(app, maxNameLen, "copy-source"),
 				}, {
 					newCmdPresenter(app, maxNameLen, "start"),
 					newCmdPresenter(app, maxNameLen, "stop"),
 					newCmdPresenter(app, maxNameLen, "restart"),
+					newCmdPresenter(app, maxNameLen, "restart-app-instance"),
+					newCmdPresenter(app, maxNameLen, "rollback"),
 				}, {
 					newCmdPresenter(app, maxNameLen, "events"),
+					newCmdPresenter(app, maxNameLen, "space-events"),
 					newCmdPresenter(app, maxNameLen, "files"),
+					newCmdPresenter(app, maxNameLen, "download"),
 					newCmdPresenter(app, maxNameLen, "logs"),
 				}, {
 					newCmdPresenter(app, maxNameLen, "env"),
 					newCmdPresenter(app, maxNameLen, "set-env"),
 					newCmdPresenter(app, maxNameLen, "unset-env"),
+					newCmdPresenter(app, maxNameLen, "env-diff"),
+				}, {
+					newCmdPresenter(app, maxNameLen, "create-app-manifest"),
 				}, {
 					newCmdPresenter(app, maxNameLen, "stacks"),
 				}, {
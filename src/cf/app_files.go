@@ -2,14 +2,11 @@ package cf
 
 import (
 	"cf/models"
-	"crypto/sha1"
 	"fileutils"
-	"fmt"
-	"glob"
 	"os"
-	"path"
 	"path/filepath"
-	"strings"
+	"runtime"
+	"sync"
 )
 
 var DefaultIgnoreFiles = []string{
@@ -20,39 +17,76 @@ var DefaultIgnoreFiles = []string{
 	"_darcs",
 }
 
-type Globs []glob.Glob
+// HashWorkers is how many files are hashed at the same time.
+var HashWorkers = runtime.NumCPU()
 
-func AppFilesInDir(dir string) (appFiles []models.AppFileFields, err error) {
+// CfIgnoreFiles are the files that list app files not to upload.
+var CfIgnoreFiles = []string{".cfignore"}
+
+// CfAndGitIgnoreFiles also leave out the files that git ignores.
+var CfAndGitIgnoreFiles = []string{".cfignore", ".gitignore"}
+
+func AppFilesInDir(dir string, ignoreFiles []string) (appFiles []models.AppFileFields, err error) {
+	return AppFilesInDirWithCache(dir, ignoreFiles, nil)
+}
+
+// AppFilesInDirWithCache hashes the files of the app with a pool of workers, taking the hashes
+// of unchanged files from cache. The files are returned in the order they were walked.
+func AppFilesInDirWithCache(dir string, ignoreFiles []string, cache *FileHashCache) (appFiles []models.AppFileFields, err error) {
 	dir, err = filepath.Abs(dir)
 	if err != nil {
 		return
 	}
 
-	err = WalkAppFiles(dir, func(fileName string, fullPath string) (err error) {
-		fileInfo, err := os.Lstat(fullPath)
-		if err != nil {
-			return
-		}
-		size := fileInfo.Size()
-
-		h := sha1.New()
+	fullPaths := []string{}
+	err = WalkAppFilesIgnoring(dir, ignoreFiles, func(fileName string, fullPath string) (err error) {
+		appFiles = append(appFiles, models.AppFileFields{Path: fileName})
+		fullPaths = append(fullPaths, fullPath)
+		return
+	})
+	if err != nil {
+		return
+	}
 
-		err = fileutils.CopyPathToWriter(fullPath, h)
-		if err != nil {
-			return
-		}
+	indexes := make(chan int)
+	errs := make(chan error, len(fullPaths))
+	workers := &sync.WaitGroup{}
+
+	for i := 0; i < HashWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for index := range indexes {
+				hashErr := hashAppFile(&appFiles[index], fullPaths[index], cache)
+				if hashErr != nil {
+					errs <- hashErr
+				}
+			}
+		}()
+	}
 
-		sha1Bytes := h.Sum(nil)
-		sha1 := fmt.Sprintf("%x", sha1Bytes)
+	for index := range fullPaths {
+		indexes <- index
+	}
+	close(indexes)
+	workers.Wait()
 
-		appFiles = append(appFiles, models.AppFileFields{
-			Path: fileName,
-			Sha1: sha1,
-			Size: size,
-		})
+	select {
+	case err = <-errs:
+		appFiles = nil
+	default:
+	}
+	return
+}
 
+func hashAppFile(appFile *models.AppFileFields, fullPath string, cache *FileHashCache) (err error) {
+	fileInfo, err := os.Lstat(fullPath)
+	if err != nil {
 		return
-	})
+	}
+
+	appFile.Size = fileInfo.Size()
+	appFile.Sha1, err = cache.Sha1(fullPath, fileInfo)
 	return
 }
 
@@ -85,15 +119,35 @@ func CountFiles(directory string) uint64 {
 
 type walkAppFileFunc func(fileName, fullPath string) (err error)
 
+// WalkAppFiles calls onEachFile for every file in dir that is not excluded by a .cfignore file.
 func WalkAppFiles(dir string, onEachFile walkAppFileFunc) (err error) {
-	exclusions := readCfIgnore(dir)
+	return WalkAppFilesIgnoring(dir, CfIgnoreFiles, onEachFile)
+}
+
+// WalkAppFilesIgnoring calls onEachFile for every file in dir that is not excluded by the
+// default ignore rules or by an ignore file named in ignoreFiles. Ignore files are read in every
+// directory and follow the rules of .gitignore files.
+func WalkAppFilesIgnoring(dir string, ignoreFiles []string, onEachFile walkAppFileFunc) (err error) {
+	rules := defaultIgnoreRules()
+	rules = append(rules, readIgnoreFiles(dir, "", ignoreFiles)...)
+
 	walkFunc := func(fullPath string, f os.FileInfo, inErr error) (err error) {
 		err = inErr
 		if err != nil {
 			return
 		}
 
+		fileRelativePath, _ := filepath.Rel(dir, fullPath)
+		fileRelativeUnixPath := filepath.ToSlash(fileRelativePath)
+		if fileRelativePath == "." {
+			return
+		}
+
 		if f.IsDir() {
+			if rules.ignores(fileRelativeUnixPath, true) {
+				return filepath.SkipDir
+			}
+			rules = append(rules, readIgnoreFiles(fullPath, fileRelativeUnixPath, ignoreFiles)...)
 			return
 		}
 
@@ -101,9 +155,7 @@ func WalkAppFiles(dir string, onEachFile walkAppFileFunc) (err error) {
 			return
 		}
 
-		fileRelativePath, _ := filepath.Rel(dir, fullPath)
-		fileRelativeUnixPath := filepath.ToSlash(fileRelativePath)
-		if fileShouldBeIgnored(exclusions, fileRelativeUnixPath) {
+		if rules.ignores(fileRelativeUnixPath, false) {
 			return
 		}
 
@@ -116,49 +168,15 @@ func WalkAppFiles(dir string, onEachFile walkAppFileFunc) (err error) {
 	return
 }
 
-func fileShouldBeIgnored(exclusions Globs, relPath string) bool {
-	for _, exclusion := range exclusions {
-		if strings.HasPrefix(exclusion.Pattern, "/") && !strings.HasPrefix(relPath, "/") {
-			relPath = "/" + relPath
-		}
-
-		if exclusion.Match(relPath) {
-			return true
-		}
-	}
-	return false
-}
-
-func readCfIgnore(dir string) (exclusions Globs) {
-	cfIgnore, err := os.Open(filepath.Join(dir, ".cfignore"))
-	if err != nil {
-		return
-	}
-
-	ignores := strings.Split(fileutils.ReadFile(cfIgnore), "\n")
-	ignores = append(DefaultIgnoreFiles, ignores...)
-
-	for _, pattern := range ignores {
-		pattern = strings.TrimSpace(pattern)
-		if pattern == "" {
+func readIgnoreFiles(dir, base string, ignoreFiles []string) (rules ignoreRules) {
+	for _, ignoreFile := range ignoreFiles {
+		file, err := os.Open(filepath.Join(dir, ignoreFile))
+		if err != nil {
 			continue
 		}
-		pattern = path.Clean(pattern)
-		patternExclusions := exclusionsForPattern(pattern)
-		exclusions = append(exclusions, patternExclusions...)
-	}
-	return
-}
-
-func exclusionsForPattern(cfignorePattern string) (exclusions Globs) {
-	exclusions = append(exclusions, glob.MustCompileGlob(cfignorePattern))
-	exclusions = append(exclusions, glob.MustCompileGlob(path.Join(cfignorePattern, "*")))
-	exclusions = append(exclusions, glob.MustCompileGlob(path.Join(cfignorePattern, "**", "*")))
 
-	if !strings.HasPrefix(cfignorePattern, "/") {
-		exclusions = append(exclusions, glob.MustCompileGlob(path.Join("**", cfignorePattern)))
-		exclusions = append(exclusions, glob.MustCompileGlob(path.Join("**", cfignorePattern, "*")))
-		exclusions = append(exclusions, glob.MustCompileGlob(path.Join("**", cfignorePattern, "**", "*")))
+		rules = append(rules, parseIgnoreRules(base, fileutils.ReadFile(file))...)
+		file.Close()
 	}
 	return
 }
@@ -0,0 +1,112 @@
+package cf_test
+
+import (
+	. "cf"
+	"fileutils"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The benchmarks compare the way pushes used to prepare the upload, hashing every file one after
+// the other and zipping a copy of the files, with hashing in parallel, with a warm hash cache, and
+// with zipping the files where they are.
+
+const (
+	benchmarkFileCount = 200
+	benchmarkFileSize  = 64 * 1024
+)
+
+func withBenchmarkApp(b *testing.B, benchmark func(dir string)) {
+	fileutils.TempDir("app_files_benchmark", func(dir string, err error) {
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		contents := make([]byte, benchmarkFileSize)
+		for i := 0; i < benchmarkFileCount; i++ {
+			path := filepath.Join(dir, fmt.Sprintf("dir%d", i%10), fmt.Sprintf("file%d", i))
+			contents[0] = byte(i)
+			err = os.MkdirAll(filepath.Dir(path), 0755)
+			if err == nil {
+				err = ioutil.WriteFile(path, contents, 0644)
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.SetBytes(benchmarkFileCount * benchmarkFileSize)
+		b.ResetTimer()
+		benchmark(dir)
+	})
+}
+
+func withHashWorkers(workers int, benchmark func()) {
+	defaultWorkers := HashWorkers
+	HashWorkers = workers
+	defer func() { HashWorkers = defaultWorkers }()
+
+	benchmark()
+}
+
+func BenchmarkHashingAppFilesSequentially(b *testing.B) {
+	withBenchmarkApp(b, func(dir string) {
+		withHashWorkers(1, func() {
+			for i := 0; i < b.N; i++ {
+				AppFilesInDir(dir, CfIgnoreFiles)
+			}
+		})
+	})
+}
+
+func BenchmarkHashingAppFilesInParallel(b *testing.B) {
+	withBenchmarkApp(b, func(dir string) {
+		for i := 0; i < b.N; i++ {
+			AppFilesInDir(dir, CfIgnoreFiles)
+		}
+	})
+}
+
+func BenchmarkHashingAppFilesWithWarmCache(b *testing.B) {
+	withBenchmarkApp(b, func(dir string) {
+		cache := NewFileHashCache("")
+		AppFilesInDirWithCache(dir, CfIgnoreFiles, cache)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			AppFilesInDirWithCache(dir, CfIgnoreFiles, cache)
+		}
+	})
+}
+
+func BenchmarkZippingACopyOfAppFiles(b *testing.B) {
+	withBenchmarkApp(b, func(dir string) {
+		files, _ := AppFilesInDir(dir, CfIgnoreFiles)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			fileutils.TempDir("upload", func(uploadDir string, err error) {
+				fileutils.TempFile("upload", func(zipFile *os.File, err error) {
+					CopyFiles(files, dir, uploadDir)
+					ApplicationZipper{}.Zip(uploadDir, zipFile)
+				})
+			})
+		}
+	})
+}
+
+func BenchmarkZippingAppFilesInPlace(b *testing.B) {
+	withBenchmarkApp(b, func(dir string) {
+		files, _ := AppFilesInDir(dir, CfIgnoreFiles)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			fileutils.TempFile("upload", func(zipFile *os.File, err error) {
+				ApplicationZipper{}.ZipFiles(dir, files, zipFile)
+			})
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package cf_test
+
+import (
+	. "cf"
+	"cf/models"
+	"fileutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func appFilePaths(dir string, files map[string]string, ignoreFiles []string) (paths []string) {
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		Expect(err).NotTo(HaveOccurred())
+		err = ioutil.WriteFile(path, []byte(contents), 0644)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	err := WalkAppFilesIgnoring(dir, ignoreFiles, func(fileName, fullPath string) error {
+		paths = append(paths, filepath.ToSlash(fileName))
+		return nil
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	sort.Strings(paths)
+	return
+}
+
+var _ = Describe("walking app files", func() {
+	var walk = func(files map[string]string, ignoreFiles []string) (paths []string) {
+		fileutils.TempDir("app_files_test", func(dir string, err error) {
+			Expect(err).NotTo(HaveOccurred())
+			paths = appFilePaths(dir, files, ignoreFiles)
+		})
+		return
+	}
+
+	It("leaves out version control files and ignore files", func() {
+		paths := walk(map[string]string{
+			"app.rb":       "",
+			".cfignore":    "",
+			".gitignore":   "",
+			".git/config":  "",
+			"lib/.svn/foo": "",
+			"lib/lib.rb":   "",
+		}, CfIgnoreFiles)
+
+		Expect(paths).To(Equal([]string{"app.rb", "lib/lib.rb"}))
+	})
+
+	It("includes files again that match a negated pattern", func() {
+		paths := walk(map[string]string{
+			".cfignore":     "*.log\n!important.log\n",
+			"app.rb":        "",
+			"debug.log":     "",
+			"important.log": "",
+			"logs/app.log":  "",
+		}, CfIgnoreFiles)
+
+		Expect(paths).To(Equal([]string{"app.rb", "important.log"}))
+	})
+
+	It("matches character classes", func() {
+		paths := walk(map[string]string{
+			".cfignore": "file[0-9].txt\nnote[!a].txt\n",
+			"file1.txt": "",
+			"fileA.txt": "",
+			"notea.txt": "",
+			"noteb.txt": "",
+		}, CfIgnoreFiles)
+
+		Expect(paths).To(Equal([]string{"fileA.txt", "notea.txt"}))
+	})
+
+	It("only matches directories with patterns ending in a slash", func() {
+		paths := walk(map[string]string{
+			".cfignore":     "build/\n",
+			"build/out.o":   "",
+			"src/build/a.o": "",
+			"src/build.rb":  "",
+			"lib/build":     "",
+		}, CfIgnoreFiles)
+
+		Expect(paths).To(Equal([]string{"lib/build", "src/build.rb"}))
+	})
+
+	It("anchors patterns containing a slash to the directory of the ignore file", func() {
+		paths := walk(map[string]string{
+			".cfignore":         "/tmp\nconfig/*.yml\ndocs/**/draft.md\n",
+			"tmp/cache":         "",
+			"lib/tmp/cache":     "",
+			"config/app.yml":    "",
+			"lib/config/a.yml":  "",
+			"docs/draft.md":     "",
+			"docs/a/b/draft.md": "",
+			"docs/final.md":     "",
+		}, CfIgnoreFiles)
+
+		Expect(paths).To(Equal([]string{"docs/final.md", "lib/config/a.yml", "lib/tmp/cache"}))
+	})
+
+	It("applies ignore files in subdirectories to files below them", func() {
+		paths := walk(map[string]string{
+			".cfignore":         "*.tmp\n",
+			"app.tmp":           "",
+			"vendor/.cfignore":  "/cache\n!keep.tmp\n",
+			"vendor/cache/a.rb": "",
+			"vendor/keep.tmp":   "",
+			"vendor/lib.rb":     "",
+			"cache/b.rb":        "",
+		}, CfIgnoreFiles)
+
+		Expect(paths).To(Equal([]string{"cache/b.rb", "vendor/keep.tmp", "vendor/lib.rb"}))
+	})
+
+	It("treats escaped characters literally and skips comments", func() {
+		paths := walk(map[string]string{
+			".cfignore":   "# comment.txt\n\\#hash.txt\n\\!bang.txt\nstar\\*.txt\n",
+			"#hash.txt":   "",
+			"!bang.txt":   "",
+			"comment.txt": "",
+			"star*.txt":   "",
+			"starry.txt":  "",
+		}, CfIgnoreFiles)
+
+		Expect(paths).To(Equal([]string{"comment.txt", "starry.txt"}))
+	})
+
+	It("honours .gitignore files when asked to", func() {
+		files := map[string]string{
+			".gitignore":                    "node_modules/\n",
+			"app.js":                        "",
+			"node_modules/express/index.js": "",
+		}
+
+		Expect(walk(files, CfIgnoreFiles)).To(Equal([]string{"app.js", "node_modules/express/index.js"}))
+		Expect(walk(files, CfAndGitIgnoreFiles)).To(Equal([]string{"app.js"}))
+	})
+})
+
+var _ = Describe("hashing app files", func() {
+	It("hashes the files with several workers in the order they are walked", func() {
+		fileutils.TempDir("app_files_test", func(dir string, err error) {
+			Expect(err).NotTo(HaveOccurred())
+			appFilePaths(dir, map[string]string{
+				"a.rb":       "hello",
+				"lib/b.rb":   "hello",
+				"lib/c/d.rb": "bye",
+				"e.rb":       "",
+			}, CfIgnoreFiles)
+
+			files, err := AppFilesInDirWithCache(dir, CfIgnoreFiles, NewFileHashCache(""))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(files).To(Equal([]models.AppFileFields{
+				{Path: "a.rb", Sha1: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", Size: 5},
+				{Path: "e.rb", Sha1: "da39a3ee5e6b4b0d3255bfef95601890afd80709", Size: 0},
+				{Path: filepath.Join("lib", "b.rb"), Sha1: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", Size: 5},
+				{Path: filepath.Join("lib", "c", "d.rb"), Sha1: "78c9a53e2f28b543ea62c8266acfdf36d5c63e61", Size: 3},
+			}))
+		})
+	})
+})
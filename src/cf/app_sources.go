@@ -0,0 +1,33 @@
+package cf
+
+import "strings"
+
+// IsRemoteAppSource tells whether an app path is not on disk but has to be fetched first, either
+// as an archive from an http(s) URL or as a git repository given as git+<url>#<ref>.
+func IsRemoteAppSource(path string) bool {
+	return IsGitAppSource(path) || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// gitAppSourceSchemes are the only transports git app sources may use. Others, such as ext:: or
+// file://, would let a manifest run commands or read files on the machine pushing the app.
+var gitAppSourceSchemes = []string{"git+https://", "git+ssh://", "git+git://"}
+
+func IsGitAppSource(path string) bool {
+	for _, scheme := range gitAppSourceSchemes {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseGitAppSource splits a git+<url>#<ref> app path into the url of the repository and the
+// branch, tag or commit to check out. The ref is empty when the default branch should be used.
+func ParseGitAppSource(path string) (repoUrl, ref string) {
+	repoUrl = strings.TrimPrefix(path, "git+")
+	if index := strings.LastIndex(repoUrl, "#"); index >= 0 {
+		ref = repoUrl[index+1:]
+		repoUrl = repoUrl[:index]
+	}
+	return
+}
@@ -0,0 +1,41 @@
+package cf_test
+
+import (
+	. "cf"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("app sources", func() {
+	It("knows which app paths have to be fetched", func() {
+		Expect(IsRemoteAppSource("https://example.com/app.zip")).To(BeTrue())
+		Expect(IsRemoteAppSource("http://example.com/app.zip")).To(BeTrue())
+		Expect(IsRemoteAppSource("git+https://example.com/app.git#v1")).To(BeTrue())
+		Expect(IsRemoteAppSource("/path/to/app")).To(BeFalse())
+		Expect(IsRemoteAppSource("app.zip")).To(BeFalse())
+
+		Expect(IsGitAppSource("git+ssh://git@example.com/app.git")).To(BeTrue())
+		Expect(IsGitAppSource("https://example.com/app.zip")).To(BeFalse())
+	})
+
+	It("only treats git sources over https, ssh and git as remote", func() {
+		Expect(IsGitAppSource("git+https://example.com/app.git")).To(BeTrue())
+		Expect(IsGitAppSource("git+git://example.com/app.git")).To(BeTrue())
+
+		Expect(IsGitAppSource("git+--upload-pack=touch /tmp/pwned")).To(BeFalse())
+		Expect(IsGitAppSource("git+ext::sh -c touch% /tmp/pwned")).To(BeFalse())
+		Expect(IsGitAppSource("git+file:///etc")).To(BeFalse())
+		Expect(IsGitAppSource("git+http://example.com/app.git")).To(BeFalse())
+		Expect(IsRemoteAppSource("git+file:///etc")).To(BeFalse())
+	})
+
+	It("splits git app sources into the repository and the ref", func() {
+		repoUrl, ref := ParseGitAppSource("git+https://example.com/app.git#release-1.2")
+		Expect(repoUrl).To(Equal("https://example.com/app.git"))
+		Expect(ref).To(Equal("release-1.2"))
+
+		repoUrl, ref = ParseGitAppSource("git+https://example.com/app.git")
+		Expect(repoUrl).To(Equal("https://example.com/app.git"))
+		Expect(ref).To(Equal(""))
+	})
+})
@@ -0,0 +1,163 @@
+package autoscaler
+
+import (
+	"cf"
+	"cf/models"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Policy says how many instances an app may have and how busy they should be. CpuTarget is the
+// share of a core each running instance should use, 0.7 for 70%.
+type Policy struct {
+	MinInstances int
+	MaxInstances int
+	CpuTarget    float64
+
+	// Tolerance is how far, as a share of the target, the cpu usage may be off before the app is
+	// scaled. It keeps apps that run close to the target from being scaled up and down in turns.
+	Tolerance float64
+
+	// Cooldown is how long to wait after scaling before scaling again, so that new instances are
+	// running and take their share of the load before the cpu usage is judged again.
+	Cooldown time.Duration
+}
+
+type Decision struct {
+	Time             time.Time
+	CurrentInstances int
+	RunningInstances int
+	Cpu              float64
+	DesiredInstances int
+	Reason           string
+}
+
+func (decision Decision) Scales() bool {
+	return decision.DesiredInstances != decision.CurrentInstances
+}
+
+func (decision Decision) ScalesUp() bool {
+	return decision.DesiredInstances > decision.CurrentInstances
+}
+
+type Autoscaler struct {
+	policy     Policy
+	clock      cf.Clock
+	lastScaled time.Time
+	hasScaled  bool
+}
+
+func New(policy Policy, clock cf.Clock) *Autoscaler {
+	return &Autoscaler{policy: policy, clock: clock}
+}
+
+// Decide works out how many instances an app should have from the stats of its instances. The
+// instance count is changed in proportion to how far the average cpu usage of the running
+// instances is from the target.
+func (autoscaler *Autoscaler) Decide(instances []models.AppInstanceFields) (decision Decision) {
+	policy := autoscaler.policy
+
+	decision.Time = autoscaler.clock.Now()
+	decision.CurrentInstances = len(instances)
+	decision.RunningInstances, decision.Cpu = averageCpu(instances)
+	decision.DesiredInstances = decision.CurrentInstances
+
+	switch {
+	case decision.CurrentInstances < policy.MinInstances:
+		decision.DesiredInstances = policy.MinInstances
+		decision.Reason = fmt.Sprintf("below the minimum of %d instances", policy.MinInstances)
+		return
+	case decision.CurrentInstances > policy.MaxInstances:
+		decision.DesiredInstances = policy.MaxInstances
+		decision.Reason = fmt.Sprintf("above the maximum of %d instances", policy.MaxInstances)
+		return
+	case decision.RunningInstances == 0:
+		decision.Reason = "no running instances to measure"
+		return
+	}
+
+	load := decision.Cpu / policy.CpuTarget
+	if math.Abs(load-1) <= policy.Tolerance {
+		decision.Reason = fmt.Sprintf("cpu is within %s of the %s target", percentage(policy.Tolerance), percentage(policy.CpuTarget))
+		return
+	}
+
+	// the running instances may be fewer than the current ones, so the count worked out from them
+	// is never allowed to move against the load: a busy app is not scaled down, an idle one not up
+	desired := int(math.Ceil(float64(decision.RunningInstances) * load))
+	if load > 1 {
+		desired = max(desired, decision.CurrentInstances)
+	} else {
+		desired = min(desired, decision.CurrentInstances)
+	}
+	desired = max(policy.MinInstances, min(policy.MaxInstances, desired))
+
+	switch {
+	case desired == decision.CurrentInstances && load > 1:
+		decision.Reason = fmt.Sprintf("cpu is above the %s target, but the instances that are not running yet will take their share", percentage(policy.CpuTarget))
+		if decision.CurrentInstances == policy.MaxInstances {
+			decision.Reason = fmt.Sprintf("cpu is above the %s target, but the app is at the maximum of %d instances", percentage(policy.CpuTarget), policy.MaxInstances)
+		}
+		return
+	case desired == decision.CurrentInstances:
+		decision.Reason = fmt.Sprintf("cpu is below the %s target, but fewer instances would take it above", percentage(policy.CpuTarget))
+		if decision.CurrentInstances == policy.MinInstances {
+			decision.Reason = fmt.Sprintf("cpu is below the %s target, but the app is at the minimum of %d instances", percentage(policy.CpuTarget), policy.MinInstances)
+		}
+		return
+	}
+
+	cooldownEnd := autoscaler.lastScaled.Add(policy.Cooldown)
+	if autoscaler.hasScaled && decision.Time.Before(cooldownEnd) {
+		decision.Reason = fmt.Sprintf("cooling down until %s", cooldownEnd.Format("15:04:05"))
+		return
+	}
+
+	decision.DesiredInstances = desired
+	if load > 1 {
+		decision.Reason = fmt.Sprintf("cpu is above the %s target", percentage(policy.CpuTarget))
+	} else {
+		decision.Reason = fmt.Sprintf("cpu is below the %s target", percentage(policy.CpuTarget))
+	}
+	return
+}
+
+// Scaled starts the cooldown, once the instance count of a decision has been applied.
+func (autoscaler *Autoscaler) Scaled(decision Decision) {
+	autoscaler.lastScaled = decision.Time
+	autoscaler.hasScaled = true
+}
+
+func averageCpu(instances []models.AppInstanceFields) (running int, cpu float64) {
+	for _, instance := range instances {
+		if instance.State != models.InstanceRunning {
+			continue
+		}
+		running++
+		cpu += instance.CpuUsage
+	}
+
+	if running > 0 {
+		cpu = cpu / float64(running)
+	}
+	return
+}
+
+func percentage(share float64) string {
+	return fmt.Sprintf("%.0f%%", share*100)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,145 @@
+package autoscaler_test
+
+import (
+	. "cf/autoscaler"
+	"cf/models"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math"
+	testtime "testhelpers/time"
+	"time"
+)
+
+var _ = Describe("Autoscaler", func() {
+	var (
+		clock      *testtime.FakeClock
+		autoscaler *Autoscaler
+	)
+
+	running := func(cpus ...float64) (instances []models.AppInstanceFields) {
+		for _, cpu := range cpus {
+			instances = append(instances, models.AppInstanceFields{State: models.InstanceRunning, CpuUsage: cpu})
+		}
+		return
+	}
+
+	BeforeEach(func() {
+		clock = &testtime.FakeClock{CurrentTime: time.Date(2014, 5, 1, 10, 0, 0, 0, time.UTC)}
+		autoscaler = New(Policy{
+			MinInstances: 2,
+			MaxInstances: 10,
+			CpuTarget:    0.7,
+			Tolerance:    0.1,
+			Cooldown:     5 * time.Minute,
+		}, clock)
+	})
+
+	It("scales up in proportion to how far the cpu is above the target", func() {
+		decision := autoscaler.Decide(running(0.9, 0.8, 1.0))
+
+		Expect(decision.Time).To(Equal(clock.CurrentTime))
+		Expect(decision.CurrentInstances).To(Equal(3))
+		Expect(decision.RunningInstances).To(Equal(3))
+		Expect(math.Abs(decision.Cpu-0.9) < 0.001).To(BeTrue())
+		Expect(decision.DesiredInstances).To(Equal(4))
+		Expect(decision.Scales()).To(BeTrue())
+		Expect(decision.ScalesUp()).To(BeTrue())
+		Expect(decision.Reason).To(Equal("cpu is above the 70% target"))
+	})
+
+	It("scales down when the cpu is below the target", func() {
+		decision := autoscaler.Decide(running(0.1, 0.2, 0.1, 0.2))
+
+		Expect(decision.DesiredInstances).To(Equal(2))
+		Expect(decision.ScalesUp()).To(BeFalse())
+		Expect(decision.Reason).To(Equal("cpu is below the 70% target"))
+	})
+
+	It("keeps the instances while the cpu is within the tolerance of the target", func() {
+		decision := autoscaler.Decide(running(0.75, 0.74))
+		Expect(decision.Scales()).To(BeFalse())
+		Expect(decision.Reason).To(Equal("cpu is within 10% of the 70% target"))
+
+		decision = autoscaler.Decide(running(0.65, 0.64))
+		Expect(decision.Scales()).To(BeFalse())
+	})
+
+	It("stays between the minimum and the maximum number of instances", func() {
+		decision := autoscaler.Decide(running(0.1, 0.1, 0.1))
+		Expect(decision.DesiredInstances).To(Equal(2))
+
+		decision = autoscaler.Decide(running(1, 1, 1, 1, 1, 1, 1, 1))
+		Expect(decision.DesiredInstances).To(Equal(10))
+
+		decision = autoscaler.Decide(running(1, 1, 1, 1, 1, 1, 1, 1, 1, 1))
+		Expect(decision.Scales()).To(BeFalse())
+		Expect(decision.Reason).To(ContainSubstring("at the maximum of 10 instances"))
+
+		decision = autoscaler.Decide(running(0.1))
+		Expect(decision.DesiredInstances).To(Equal(2))
+		Expect(decision.Reason).To(Equal("below the minimum of 2 instances"))
+
+		decision = autoscaler.Decide(running(0.7, 0.7, 0.7, 0.7, 0.7, 0.7, 0.7, 0.7, 0.7, 0.7, 0.7))
+		Expect(decision.DesiredInstances).To(Equal(10))
+		Expect(decision.Reason).To(Equal("above the maximum of 10 instances"))
+	})
+
+	It("judges the cpu by the running instances only", func() {
+		instances := running(0.9, 0.9)
+		instances = append(instances, models.AppInstanceFields{State: models.InstanceStarting})
+
+		decision := autoscaler.Decide(instances)
+
+		Expect(decision.RunningInstances).To(Equal(2))
+		Expect(math.Abs(decision.Cpu-0.9) < 0.001).To(BeTrue())
+		Expect(decision.Scales()).To(BeFalse())
+		Expect(decision.Reason).To(ContainSubstring("not running yet"))
+	})
+
+	It("does not scale a busy app down while some of its instances are still starting", func() {
+		autoscaler = New(Policy{MinInstances: 1, MaxInstances: 10, CpuTarget: 0.5, Tolerance: 0.1}, clock)
+
+		instances := running(1.0, 1.0)
+		for i := 0; i < 3; i++ {
+			instances = append(instances, models.AppInstanceFields{State: models.InstanceStarting})
+		}
+
+		decision := autoscaler.Decide(instances)
+
+		Expect(decision.CurrentInstances).To(Equal(5))
+		Expect(decision.DesiredInstances).To(Equal(5))
+		Expect(decision.Scales()).To(BeFalse())
+		Expect(decision.Reason).To(ContainSubstring("not running yet"))
+	})
+
+	It("does not scale without running instances", func() {
+		decision := autoscaler.Decide([]models.AppInstanceFields{{State: models.InstanceDown}, {State: models.InstanceDown}})
+
+		Expect(decision.Scales()).To(BeFalse())
+		Expect(decision.Reason).To(Equal("no running instances to measure"))
+	})
+
+	It("waits for the cooldown after scaling before scaling again", func() {
+		decision := autoscaler.Decide(running(1, 1))
+		Expect(decision.Scales()).To(BeTrue())
+		autoscaler.Scaled(decision)
+
+		clock.Sleep(4 * time.Minute)
+		decision = autoscaler.Decide(running(1, 1, 1))
+		Expect(decision.Scales()).To(BeFalse())
+		Expect(decision.Reason).To(Equal("cooling down until 10:05:00"))
+
+		clock.Sleep(time.Minute)
+		decision = autoscaler.Decide(running(1, 1, 1))
+		Expect(decision.DesiredInstances).To(Equal(5))
+	})
+
+	It("does not start a cooldown for decisions that were not applied", func() {
+		decision := autoscaler.Decide(running(1, 1))
+		Expect(decision.Scales()).To(BeTrue())
+
+		clock.Sleep(time.Minute)
+		decision = autoscaler.Decide(running(1, 1))
+		Expect(decision.Scales()).To(BeTrue())
+	})
+})
@@ -0,0 +1,26 @@
+package cf
+
+import (
+	"time"
+)
+
+// Clock tells the time and waits, so that code that runs for a long time can be tested with a
+// clock that is moved forward by hand.
+type Clock interface {
+	Now() time.Time
+	Sleep(duration time.Duration)
+}
+
+type realClock struct{}
+
+func NewClock() Clock {
+	return realClock{}
+}
+
+func (clock realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (clock realClock) Sleep(duration time.Duration) {
+	time.Sleep(duration)
+}
@@ -0,0 +1,221 @@
+package application
+
+import (
+	"cf"
+	"cf/formatters"
+	"cf/models"
+	"cf/terminal"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sparklineLength = 20
+	maxWatchEvents  = 5
+)
+
+var sparklineTicks = []rune("▁▂▃▄▅▆▇█")
+
+type instanceHistory struct {
+	state  models.InstanceState
+	cpu    []float64
+	memory []float64
+}
+
+// appWatch keeps what earlier polls of an app's instances saw, to draw their trends and to
+// notice instances changing state.
+type appWatch struct {
+	instances map[int]*instanceHistory
+	changed   map[int]models.InstanceState
+	events    []string
+	polled    bool
+}
+
+func newAppWatch() *appWatch {
+	return &appWatch{instances: map[int]*instanceHistory{}}
+}
+
+func (cmd *ShowApp) watchApp(app models.Application, interval time.Duration) {
+	dashboard := cmd.ui.Dashboard()
+	defer dashboard.Close()
+
+	watch := newAppWatch()
+	for {
+		dashboard.Draw(cmd.watchScreen(app, interval, watch))
+		if dashboard.WaitForQuit(interval) {
+			return
+		}
+	}
+}
+
+func (cmd *ShowApp) watchScreen(app models.Application, interval time.Duration, watch *appWatch) (lines []string) {
+	now := cmd.now()
+
+	lines = append(lines,
+		fmt.Sprintf("Watching app %s in org %s / space %s as %s, every %s. Press q to quit.",
+			terminal.EntityNameColor(app.Name),
+			terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+			terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+			terminal.EntityNameColor(cmd.config.Username()),
+			interval,
+		),
+		"",
+	)
+
+	appSummary, apiResponse := cmd.appSummaryRepo.GetSummary(app.Guid)
+	appIsStopped := apiResponse.ErrorCode == cf.APP_STOPPED ||
+		apiResponse.ErrorCode == cf.APP_NOT_STAGED ||
+		appSummary.State == "stopped"
+
+	// errors are shown on the screen, the next poll may well succeed again
+	if apiResponse.IsNotSuccessful() && !appIsStopped {
+		return append(lines, terminal.FailureColor(apiResponse.Message))
+	}
+
+	lines = append(lines,
+		fmt.Sprintf("%s %s", terminal.HeaderColor("requested state:"), coloredAppState(appSummary.ApplicationFields)),
+		fmt.Sprintf("%s %s", terminal.HeaderColor("instances:"), coloredAppInstances(appSummary.ApplicationFields)),
+		fmt.Sprintf("%s %s x %d instances", terminal.HeaderColor("usage:"), formatters.ByteSize(appSummary.Memory*formatters.MEGABYTE), appSummary.InstanceCount),
+		fmt.Sprintf("%s %s", terminal.HeaderColor("updated:"), now.Format("15:04:05")),
+		"",
+	)
+
+	var instances []models.AppInstanceFields
+	if !appIsStopped {
+		instances, apiResponse = cmd.appInstancesRepo.GetInstances(app.Guid)
+		if apiResponse.IsNotSuccessful() {
+			return append(lines, terminal.FailureColor(apiResponse.Message))
+		}
+	}
+
+	watch.record(instances, now)
+
+	if len(instances) == 0 {
+		lines = append(lines, "There are no running instances of this app.")
+	} else {
+		lines = append(lines, terminal.FormatTable(watch.table(instances))...)
+	}
+
+	if len(watch.events) > 0 {
+		lines = append(lines, "", terminal.HeaderColor("recent events:"))
+		lines = append(lines, watch.events...)
+	}
+	return
+}
+
+func (watch *appWatch) record(instances []models.AppInstanceFields, now time.Time) {
+	watch.changed = map[int]models.InstanceState{}
+
+	for index, instance := range instances {
+		history, found := watch.instances[index]
+		if !found {
+			history = &instanceHistory{state: instance.State}
+			watch.instances[index] = history
+			if watch.polled {
+				watch.addEvent(now, fmt.Sprintf("#%d started as %s", index, instance.State), instance.State)
+			}
+		} else if history.state != instance.State {
+			watch.changed[index] = history.state
+			watch.addEvent(now, fmt.Sprintf("#%d %s -> %s", index, history.state, instance.State), instance.State)
+			history.state = instance.State
+		}
+
+		history.cpu = appendSample(history.cpu, instance.CpuUsage)
+		history.memory = appendSample(history.memory, fraction(instance.MemUsage, instance.MemQuota))
+	}
+
+	indexes := []int{}
+	for index := range watch.instances {
+		if index >= len(instances) {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		delete(watch.instances, index)
+		watch.addEvent(now, fmt.Sprintf("#%d is gone", index), models.InstanceDown)
+	}
+
+	watch.polled = true
+}
+
+func (watch *appWatch) addEvent(now time.Time, event string, state models.InstanceState) {
+	event = fmt.Sprintf("%s %s", now.Format("15:04:05"), event)
+	if isCrashedState(state) {
+		event = terminal.CrashedColor(event)
+	}
+
+	watch.events = append(watch.events, event)
+	if len(watch.events) > maxWatchEvents {
+		watch.events = watch.events[len(watch.events)-maxWatchEvents:]
+	}
+}
+
+func (watch *appWatch) table(instances []models.AppInstanceFields) (table [][]string) {
+	table = [][]string{
+		[]string{"", "state", "since", "cpu", "memory", "disk", "cpu history", "memory history"},
+	}
+
+	for index, instance := range instances {
+		state := coloredInstanceState(instance)
+		if previousState, changed := watch.changed[index]; changed {
+			state = fmt.Sprintf("%s (was %s)", instance.State, previousState)
+			if isCrashedState(instance.State) {
+				state = terminal.CrashedColor(state)
+			} else {
+				state = terminal.AdvisoryColor(state)
+			}
+		}
+
+		history := watch.instances[index]
+		table = append(table, []string{
+			fmt.Sprintf("#%d", index),
+			state,
+			instance.Since.Format("2006-01-02 03:04:05 PM"),
+			fmt.Sprintf("%.1f%%", instance.CpuUsage*100),
+			fmt.Sprintf("%s of %s", formatters.ByteSize(instance.MemUsage), formatters.ByteSize(instance.MemQuota)),
+			fmt.Sprintf("%s of %s", formatters.ByteSize(instance.DiskUsage), formatters.ByteSize(instance.DiskQuota)),
+			sparkline(history.cpu),
+			sparkline(history.memory),
+		})
+	}
+	return
+}
+
+func isCrashedState(state models.InstanceState) bool {
+	return state == models.InstanceFlapping || state == models.InstanceDown || state == "crashed"
+}
+
+func appendSample(samples []float64, sample float64) []float64 {
+	samples = append(samples, sample)
+	if len(samples) > sparklineLength {
+		samples = samples[len(samples)-sparklineLength:]
+	}
+	return samples
+}
+
+func fraction(usage, quota uint64) float64 {
+	if quota == 0 {
+		return 0
+	}
+	return float64(usage) / float64(quota)
+}
+
+// sparkline draws values between 0 and 1 as bars of growing height. CPU usage above one core
+// and memory above the quota are drawn as full bars.
+func sparkline(values []float64) string {
+	line := make([]string, len(values))
+	for index, value := range values {
+		tick := int(value*float64(len(sparklineTicks)-1) + 0.5)
+		if tick < 0 {
+			tick = 0
+		}
+		if tick >= len(sparklineTicks) {
+			tick = len(sparklineTicks) - 1
+		}
+		line[index] = string(sparklineTicks[tick])
+	}
+	return strings.Join(line, "")
+}
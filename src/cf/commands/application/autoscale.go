@@ -0,0 +1,179 @@
+package application
+
+import (
+	"cf"
+	"cf/api"
+	"cf/autoscaler"
+	"cf/configuration"
+	"cf/models"
+	"cf/requirements"
+	"cf/terminal"
+	"errors"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"io"
+	"os"
+	"time"
+)
+
+const autoscaleTolerance = 0.1
+
+type Autoscale struct {
+	ui               terminal.UI
+	config           configuration.Reader
+	appRepo          api.ApplicationRepository
+	appInstancesRepo api.AppInstancesRepository
+	appReq           requirements.ApplicationRequirement
+	clock            cf.Clock
+
+	// MaxPolls stops autoscaling after that many polls, it goes on until the process is stopped
+	// when it is 0.
+	MaxPolls int
+}
+
+func NewAutoscale(ui terminal.UI, config configuration.Reader, appRepo api.ApplicationRepository, appInstancesRepo api.AppInstancesRepository, clock cf.Clock) (cmd *Autoscale) {
+	cmd = new(Autoscale)
+	cmd.ui = ui
+	cmd.config = config
+	cmd.appRepo = appRepo
+	cmd.appInstancesRepo = appInstancesRepo
+	cmd.clock = clock
+	return
+}
+
+func (cmd *Autoscale) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
+	if len(c.Args()) != 1 || c.Int("max") == -1 {
+		err = errors.New("Incorrect Usage")
+		cmd.ui.FailWithUsage(c, "autoscale")
+		return
+	}
+
+	cmd.appReq = reqFactory.NewApplicationRequirement(c.Args()[0])
+
+	reqs = []requirements.Requirement{
+		reqFactory.NewLoginRequirement(),
+		reqFactory.NewTargetedSpaceRequirement(),
+		cmd.appReq,
+	}
+	return
+}
+
+func (cmd *Autoscale) Run(c *cli.Context) {
+	policy, interval, err := autoscalePolicy(c)
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+		return
+	}
+
+	dryRun := c.Bool("dry-run")
+	decisionLog := io.Writer(nil)
+	if c.String("log") != "" {
+		file, err := os.OpenFile(c.String("log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			cmd.ui.Failed("Could not open decision log %s\n%s", c.String("log"), err.Error())
+			return
+		}
+		defer file.Close()
+		decisionLog = file
+	}
+
+	app := cmd.appReq.GetApplication()
+	cmd.ui.Say("Autoscaling app %s in org %s / space %s as %s between %s and %s instances at %s cpu...",
+		terminal.EntityNameColor(app.Name),
+		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+		terminal.EntityNameColor(cmd.config.Username()),
+		terminal.EntityNameColor(fmt.Sprintf("%d", policy.MinInstances)),
+		terminal.EntityNameColor(fmt.Sprintf("%d", policy.MaxInstances)),
+		terminal.EntityNameColor(fmt.Sprintf("%d%%", c.Int("cpu-target"))),
+	)
+	if dryRun {
+		cmd.ui.Warn("This is a dry run, the app is not scaled.")
+	}
+	cmd.ui.Say("Polling every %s, press Ctrl-C to stop.\n", interval)
+
+	scaler := autoscaler.New(policy, cmd.clock)
+	for polls := 0; cmd.MaxPolls == 0 || polls < cmd.MaxPolls; polls++ {
+		if polls > 0 {
+			cmd.clock.Sleep(interval)
+		}
+		cmd.autoscale(app, scaler, dryRun, decisionLog)
+	}
+}
+
+func autoscalePolicy(c *cli.Context) (policy autoscaler.Policy, interval time.Duration, err error) {
+	policy = autoscaler.Policy{
+		MinInstances: c.Int("min"),
+		MaxInstances: c.Int("max"),
+		CpuTarget:    float64(c.Int("cpu-target")) / 100,
+		Tolerance:    autoscaleTolerance,
+		Cooldown:     time.Duration(c.Int("cooldown")) * time.Second,
+	}
+	interval = time.Duration(c.Int("interval")) * time.Second
+
+	switch {
+	case policy.MinInstances < 1:
+		err = errors.New("The minimum number of instances must be at least 1")
+	case policy.MaxInstances < policy.MinInstances:
+		err = errors.New("The maximum number of instances must not be less than the minimum")
+	case policy.CpuTarget <= 0:
+		err = errors.New("The cpu target must be a positive percentage")
+	case interval <= 0:
+		err = errors.New("Interval must be a positive number of seconds")
+	case policy.Cooldown < 0:
+		err = errors.New("Cooldown must not be a negative number of seconds")
+	}
+	return
+}
+
+// autoscale polls the stats of the instances once and applies what the autoscaler decides. Errors
+// are logged instead of failing, as the next poll may well succeed again.
+func (cmd *Autoscale) autoscale(app models.Application, scaler *autoscaler.Autoscaler, dryRun bool, decisionLog io.Writer) {
+	instances, apiResponse := cmd.appInstancesRepo.GetInstances(app.Guid)
+	if apiResponse.IsNotSuccessful() {
+		cmd.logDecision(decisionLog, cmd.clock.Now(), "could not get the instance stats: "+apiResponse.Message, terminal.FailureColor)
+		return
+	}
+
+	decision := scaler.Decide(instances)
+	stats := fmt.Sprintf("%d of %d instances running at %.1f%% cpu", decision.RunningInstances, decision.CurrentInstances, decision.Cpu*100)
+
+	if !decision.Scales() {
+		cmd.logDecision(decisionLog, decision.Time, fmt.Sprintf("%s, keeping %d (%s)", stats, decision.CurrentInstances, decision.Reason), nil)
+		return
+	}
+
+	direction := "down"
+	if decision.ScalesUp() {
+		direction = "up"
+	}
+
+	if dryRun {
+		cmd.logDecision(decisionLog, decision.Time, fmt.Sprintf("%s, would scale %s to %d (%s)", stats, direction, decision.DesiredInstances, decision.Reason), terminal.WarningColor)
+		scaler.Scaled(decision)
+		return
+	}
+
+	instanceCount := decision.DesiredInstances
+	_, apiResponse = cmd.appRepo.Update(app.Guid, models.AppParams{InstanceCount: &instanceCount})
+	if apiResponse.IsNotSuccessful() {
+		cmd.logDecision(decisionLog, decision.Time, fmt.Sprintf("%s, could not scale %s to %d: %s", stats, direction, decision.DesiredInstances, apiResponse.Message), terminal.FailureColor)
+		return
+	}
+
+	cmd.logDecision(decisionLog, decision.Time, fmt.Sprintf("%s, scaled %s to %d (%s)", stats, direction, decision.DesiredInstances, decision.Reason), terminal.AdvisoryColor)
+	scaler.Scaled(decision)
+}
+
+// logDecision prints a line of the decision log, in color when a color is given, and appends it to
+// the log file if there is one.
+func (cmd *Autoscale) logDecision(decisionLog io.Writer, at time.Time, message string, color func(string) string) {
+	if decisionLog != nil {
+		fmt.Fprintf(decisionLog, "%s %s\n", at.Format(time.RFC3339), message)
+	}
+
+	if color != nil {
+		message = color(message)
+	}
+	cmd.ui.Say("%s %s", at.Format("15:04:05"), message)
+}
@@ -0,0 +1,168 @@
+package application_test
+
+import (
+	. "cf/commands/application"
+	"cf/models"
+	"fileutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	testapi "testhelpers/api"
+	testassert "testhelpers/assert"
+	testcmd "testhelpers/commands"
+	testconfig "testhelpers/configuration"
+	testreq "testhelpers/requirements"
+	testterm "testhelpers/terminal"
+	testtime "testhelpers/time"
+	"time"
+)
+
+var _ = Describe("autoscale command", func() {
+	var (
+		appRepo          *testapi.FakeApplicationRepository
+		appInstancesRepo *testapi.FakeAppInstancesRepo
+		reqFactory       *testreq.FakeReqFactory
+		clock            *testtime.FakeClock
+		maxPolls         int
+	)
+
+	running := func(cpus ...float64) (instances []models.AppInstanceFields) {
+		for _, cpu := range cpus {
+			instances = append(instances, models.AppInstanceFields{State: models.InstanceRunning, CpuUsage: cpu})
+		}
+		return
+	}
+
+	BeforeEach(func() {
+		app := models.Application{}
+		app.Name = "my-app"
+		app.Guid = "my-app-guid"
+
+		appRepo = &testapi.FakeApplicationRepository{}
+		appInstancesRepo = &testapi.FakeAppInstancesRepo{}
+		reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true, Application: app}
+		clock = &testtime.FakeClock{CurrentTime: time.Date(2014, 5, 1, 10, 0, 0, 0, time.Local)}
+		maxPolls = 1
+	})
+
+	callAutoscale := func(args []string) (ui *testterm.FakeUI) {
+		ui = &testterm.FakeUI{}
+		cmd := NewAutoscale(ui, testconfig.NewRepositoryWithDefaults(), appRepo, appInstancesRepo, clock)
+		cmd.MaxPolls = maxPolls
+		testcmd.RunCommand(cmd, testcmd.NewContext("autoscale", args), reqFactory)
+		return
+	}
+
+	It("fails with usage without an app and a maximum number of instances", func() {
+		ui := callAutoscale([]string{"my-app"})
+		Expect(ui.FailedWithUsage).To(BeTrue())
+
+		ui = callAutoscale([]string{"--max", "3"})
+		Expect(ui.FailedWithUsage).To(BeTrue())
+
+		ui = callAutoscale([]string{"--max", "3", "my-app"})
+		Expect(ui.FailedWithUsage).To(BeFalse())
+	})
+
+	It("requires a user to be logged in, a space to be targeted and the app to exist", func() {
+		reqFactory.LoginSuccess = false
+		callAutoscale([]string{"--max", "3", "my-app"})
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+
+		reqFactory.LoginSuccess = true
+		reqFactory.TargetedSpaceSuccess = false
+		callAutoscale([]string{"--max", "3", "my-app"})
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+
+		reqFactory.TargetedSpaceSuccess = true
+		callAutoscale([]string{"--max", "3", "my-app"})
+		Expect(testcmd.CommandDidPassRequirements).To(BeTrue())
+		Expect(reqFactory.ApplicationName).To(Equal("my-app"))
+	})
+
+	It("fails when the bounds or the target make no sense", func() {
+		ui := callAutoscale([]string{"--min", "5", "--max", "3", "my-app"})
+		testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"maximum number of instances must not be less than the minimum"}})
+
+		ui = callAutoscale([]string{"--min", "0", "--max", "3", "my-app"})
+		testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"minimum number of instances must be at least 1"}})
+
+		ui = callAutoscale([]string{"--max", "3", "--cpu-target", "0", "my-app"})
+		testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"cpu target must be a positive percentage"}})
+
+		ui = callAutoscale([]string{"--max", "3", "--interval", "0", "my-app"})
+		testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Interval must be a positive number"}})
+	})
+
+	It("polls the instance stats and scales the app, waiting for the cooldown in between", func() {
+		maxPolls = 4
+		appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{
+			running(0.9, 0.9),
+			running(0.9, 0.9, 0.9),
+			running(0.9, 0.9, 0.9),
+			running(0.7, 0.7, 0.7),
+		}
+
+		ui := callAutoscale([]string{"--min", "2", "--max", "10", "--cpu-target", "60", "--interval", "20", "--cooldown", "30", "my-app"})
+
+		Expect(appInstancesRepo.GetInstancesAppGuid).To(Equal("my-app-guid"))
+		Expect(clock.Sleeps).To(Equal([]time.Duration{20 * time.Second, 20 * time.Second, 20 * time.Second}))
+
+		Expect(appRepo.UpdateAppGuid).To(Equal("my-app-guid"))
+		Expect(*appRepo.UpdateParams.InstanceCount).To(Equal(5))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Autoscaling app", "my-app", "my-org", "my-space", "my-user", "between", "2", "and", "10", "60%"},
+			{"Polling every 20s"},
+			{"10:00:00 2 of 2 instances running at 90.0% cpu, scaled up to 3 (cpu is above the 60% target)"},
+			{"10:00:20 3 of 3 instances running at 90.0% cpu, keeping 3 (cooling down until 10:00:30)"},
+			{"10:00:40 3 of 3 instances running at 90.0% cpu, scaled up to 5 (cpu is above the 60% target)"},
+			{"10:01:00 3 of 3 instances running at 70.0% cpu, keeping 3 (cooling down until 10:01:10)"},
+		})
+	})
+
+	It("only logs the decisions in a dry run", func() {
+		appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{running(0.1, 0.1, 0.1)}
+
+		ui := callAutoscale([]string{"--max", "10", "--dry-run", "my-app"})
+
+		Expect(appRepo.UpdateAppGuid).To(Equal(""))
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"dry run"},
+			{"3 of 3 instances running at 10.0% cpu, would scale down to 1 (cpu is below the 70% target)"},
+		})
+	})
+
+	It("keeps polling when the stats or the update fail", func() {
+		maxPolls = 2
+		appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{nil, running(1, 1)}
+		appInstancesRepo.GetInstancesErrorCodes = []string{"500"}
+		appRepo.UpdateErr = true
+
+		ui := callAutoscale([]string{"--max", "10", "my-app"})
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"10:00:00 could not get the instance stats", "Error staging app"},
+			{"10:00:30", "could not scale up to 3"},
+		})
+		testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{{"FAILED"}})
+	})
+
+	It("appends the decisions to a log file", func() {
+		fileutils.TempDir("autoscale", func(dir string, err error) {
+			Expect(err).NotTo(HaveOccurred())
+			logPath := filepath.Join(dir, "decisions.log")
+			Expect(ioutil.WriteFile(logPath, []byte("earlier decision\n"), os.ModePerm)).NotTo(HaveOccurred())
+
+			appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{running(0.7, 0.7)}
+			callAutoscale([]string{"--max", "10", "--log", logPath, "my-app"})
+
+			contents, err := ioutil.ReadFile(logPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("earlier decision\n" +
+				clock.CurrentTime.Format(time.RFC3339) + " 2 of 2 instances running at 70.0% cpu, keeping 2 (cpu is within 10% of the 70% target)\n"))
+		})
+	})
+})
@@ -0,0 +1,154 @@
+package application
+
+import (
+	"cf/api"
+	"cf/configuration"
+	"cf/models"
+	"cf/net"
+	"cf/requirements"
+	"cf/terminal"
+	"errors"
+	"fileutils"
+	"github.com/codegangsta/cli"
+	"os"
+)
+
+type CopySource struct {
+	ui          terminal.UI
+	config      configuration.Reader
+	appRepo     api.ApplicationRepository
+	appBitsRepo api.ApplicationBitsRepository
+	orgRepo     api.OrganizationRepository
+	spaceRepo   api.SpaceRepository
+	starter     ApplicationStarter
+	stopper     ApplicationStopper
+	appReq      requirements.ApplicationRequirement
+}
+
+func NewCopySource(ui terminal.UI, config configuration.Reader, appRepo api.ApplicationRepository, appBitsRepo api.ApplicationBitsRepository, orgRepo api.OrganizationRepository, spaceRepo api.SpaceRepository, starter ApplicationStarter, stopper ApplicationStopper) (cmd *CopySource) {
+	cmd = new(CopySource)
+	cmd.ui = ui
+	cmd.config = config
+	cmd.appRepo = appRepo
+	cmd.appBitsRepo = appBitsRepo
+	cmd.orgRepo = orgRepo
+	cmd.spaceRepo = spaceRepo
+	cmd.starter = starter
+	cmd.stopper = stopper
+	return
+}
+
+func (cmd *CopySource) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
+	if len(c.Args()) != 2 || (c.String("org") != "" && c.String("space") == "") {
+		err = errors.New("Incorrect Usage")
+		cmd.ui.FailWithUsage(c, "copy-source")
+		return
+	}
+
+	cmd.appReq = reqFactory.NewApplicationRequirement(c.Args()[0])
+
+	reqs = []requirements.Requirement{
+		reqFactory.NewLoginRequirement(),
+		reqFactory.NewTargetedSpaceRequirement(),
+		cmd.appReq,
+	}
+	return
+}
+
+func (cmd *CopySource) Run(c *cli.Context) {
+	sourceApp := cmd.appReq.GetApplication()
+
+	orgName := c.String("org")
+	if orgName == "" {
+		orgName = cmd.config.OrganizationFields().Name
+	}
+	spaceName := c.String("space")
+	if spaceName == "" {
+		spaceName = cmd.config.SpaceFields().Name
+	}
+
+	spaceGuid, apiResponse := findSpaceGuid(cmd.config, cmd.orgRepo, cmd.spaceRepo, orgName, spaceName)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	targetApp, apiResponse := cmd.appRepo.ReadFromSpace(c.Args()[1], spaceGuid)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	if targetApp.Guid == sourceApp.Guid {
+		cmd.ui.Failed("The source and the destination are the same app")
+		return
+	}
+
+	cmd.ui.Say("Copying source bits of app %s to app %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(sourceApp.Name),
+		terminal.EntityNameColor(targetApp.Name),
+		terminal.EntityNameColor(orgName),
+		terminal.EntityNameColor(spaceName),
+		terminal.EntityNameColor(cmd.config.Username()),
+	)
+
+	apiResponse = cmd.appBitsRepo.CopyBits(sourceApp.Guid, targetApp.Guid, func(status string) {
+		cmd.ui.Say("Copy job %s", terminal.EntityNameColor(status))
+	})
+	if apiResponse.IsNotFound() {
+		cmd.ui.Warn("The Cloud Controller cannot copy source bits, downloading and uploading them instead")
+		apiResponse = cmd.downloadAndUpload(sourceApp, targetApp)
+	}
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed("Error copying source bits\n%s", apiResponse.Message)
+		return
+	}
+
+	cmd.ui.Ok()
+
+	if c.Bool("restart") {
+		cmd.ui.Say("")
+		cmd.restart(targetApp)
+	}
+}
+
+// downloadAndUpload copies the source bits through a temporary file, for Cloud Controllers that
+// have no copy_bits endpoint.
+func (cmd *CopySource) downloadAndUpload(sourceApp, targetApp models.Application) (apiResponse net.ApiResponse) {
+	fileutils.TempFile("copy-source", func(file *os.File, err error) {
+		if err != nil {
+			apiResponse = net.NewApiResponseWithError("Error creating temporary file", err)
+			return
+		}
+
+		cmd.ui.Say("Downloading source bits of app %s...", terminal.EntityNameColor(sourceApp.Name))
+		progressBar := cmd.ui.ProgressBar()
+		apiResponse = cmd.appBitsRepo.DownloadApp(sourceApp.Guid, file, progressBar.Update)
+		progressBar.Done()
+		if apiResponse.IsNotSuccessful() {
+			return
+		}
+
+		cmd.ui.Say("Uploading source bits to app %s...", terminal.EntityNameColor(targetApp.Name))
+		progressBar = cmd.ui.ProgressBar()
+		apiResponse = cmd.appBitsRepo.UploadApp(targetApp.Guid, file.Name(), []string{}, func(path string, zipSize, fileCount uint64) {}, progressBar.Update)
+		progressBar.Done()
+	})
+	return
+}
+
+func (cmd *CopySource) restart(app models.Application) {
+	stoppedApp, err := cmd.stopper.ApplicationStop(app)
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+		return
+	}
+
+	cmd.ui.Say("")
+
+	_, err = cmd.starter.ApplicationStart(stoppedApp)
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+		return
+	}
+}
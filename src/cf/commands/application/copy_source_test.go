@@ -0,0 +1,171 @@
+package application_test
+
+import (
+	. "cf/commands/application"
+	"cf/models"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	testapi "testhelpers/api"
+	testassert "testhelpers/assert"
+	testcmd "testhelpers/commands"
+	testconfig "testhelpers/configuration"
+	testreq "testhelpers/requirements"
+	testterm "testhelpers/terminal"
+)
+
+var _ = Describe("copy-source command", func() {
+	var (
+		reqFactory  *testreq.FakeReqFactory
+		appRepo     *testapi.FakeApplicationRepository
+		appBitsRepo *testapi.FakeApplicationBitsRepository
+		orgRepo     *testapi.FakeOrgRepository
+		spaceRepo   *testapi.FakeSpaceRepository
+		starter     *testcmd.FakeAppStarter
+		stopper     *testcmd.FakeAppStopper
+	)
+
+	BeforeEach(func() {
+		sourceApp := models.Application{}
+		sourceApp.Name = "my-app"
+		sourceApp.Guid = "source-app-guid"
+		reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true, Application: sourceApp}
+
+		targetApp := models.Application{}
+		targetApp.Name = "my-app"
+		targetApp.Guid = "target-app-guid"
+		stagingApp := models.Application{}
+		stagingApp.Name = "my-staging-app"
+		stagingApp.Guid = "staging-app-guid"
+		appRepo = &testapi.FakeApplicationRepository{
+			ReadFromSpaceApps: map[string]map[string]models.Application{
+				"my-space-guid":   {"my-staging-app": stagingApp, "my-app": sourceApp},
+				"prod-space-guid": {"my-app": targetApp},
+			},
+		}
+
+		appBitsRepo = &testapi.FakeApplicationBitsRepository{CopyBitsStatuses: []string{"queued", "running", "finished"}}
+
+		org := models.Organization{}
+		org.Name = "prod-org"
+		org.Guid = "prod-org-guid"
+		orgRepo = &testapi.FakeOrgRepository{Organizations: []models.Organization{org}}
+
+		space := models.Space{}
+		space.Name = "prod-space"
+		space.Guid = "prod-space-guid"
+		spaceRepo = &testapi.FakeSpaceRepository{Spaces: []models.Space{space}, FindByNameInOrgSpace: space}
+
+		starter = &testcmd.FakeAppStarter{}
+		stopper = &testcmd.FakeAppStopper{}
+	})
+
+	callCopySource := func(args ...string) (ui *testterm.FakeUI) {
+		ui = &testterm.FakeUI{}
+		cmd := NewCopySource(ui, testconfig.NewRepositoryWithDefaults(), appRepo, appBitsRepo, orgRepo, spaceRepo, starter, stopper)
+		testcmd.RunCommand(cmd, testcmd.NewContext("copy-source", args), reqFactory)
+		return
+	}
+
+	It("fails with usage without a source and a target app, or with an org but no space", func() {
+		ui := callCopySource("my-app")
+		Expect(ui.FailedWithUsage).To(BeTrue())
+
+		ui = callCopySource("--org", "prod-org", "my-app", "my-app")
+		Expect(ui.FailedWithUsage).To(BeTrue())
+	})
+
+	It("requires a login, a targeted space and the source app", func() {
+		callCopySource("my-app", "my-staging-app")
+		Expect(testcmd.CommandDidPassRequirements).To(BeTrue())
+		Expect(reqFactory.ApplicationName).To(Equal("my-app"))
+
+		reqFactory.TargetedSpaceSuccess = false
+		callCopySource("my-app", "my-staging-app")
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+
+		reqFactory.TargetedSpaceSuccess = true
+		reqFactory.LoginSuccess = false
+		callCopySource("my-app", "my-staging-app")
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+	})
+
+	It("copies the source bits to an app in another org and space, showing the copy job", func() {
+		ui := callCopySource("--space", "prod-space", "--org", "prod-org", "my-app", "my-app")
+
+		Expect(orgRepo.FindByNameName).To(Equal("prod-org"))
+		Expect(spaceRepo.FindByNameInOrgName).To(Equal("prod-space"))
+		Expect(appBitsRepo.CopiedBitsSourceGuid).To(Equal("source-app-guid"))
+		Expect(appBitsRepo.CopiedBitsTargetGuid).To(Equal("target-app-guid"))
+		Expect(appBitsRepo.DownloadedAppGuid).To(Equal(""))
+		Expect(stopper.AppToStop.Guid).To(Equal(""))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Copying source bits of app", "my-app", "to app", "my-app", "prod-org", "prod-space", "my-user"},
+			{"Copy job", "queued"},
+			{"Copy job", "running"},
+			{"Copy job", "finished"},
+			{"OK"},
+		})
+	})
+
+	It("copies the source bits to an app in the targeted space", func() {
+		callCopySource("my-app", "my-staging-app")
+
+		Expect(appBitsRepo.CopiedBitsTargetGuid).To(Equal("staging-app-guid"))
+	})
+
+	It("fails when the source and target are the same app", func() {
+		ui := callCopySource("my-app", "my-app")
+
+		Expect(appBitsRepo.CopiedBitsTargetGuid).To(Equal(""))
+		testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"same app"}})
+	})
+
+	It("fails when the target app cannot be found", func() {
+		ui := callCopySource("--space", "prod-space", "my-app", "other-app")
+
+		Expect(spaceRepo.FindByNameName).To(Equal("prod-space"))
+		testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"App other-app not found"}})
+	})
+
+	It("downloads and uploads the source bits when the Cloud Controller cannot copy them", func() {
+		appBitsRepo.CopyBitsNotFound = true
+		appBitsRepo.DownloadContents = "zip contents"
+		uploadedContents := ""
+		appBitsRepo.UploadAppHook = func(appGuid string) {
+			contents, err := ioutil.ReadFile(appBitsRepo.UploadedDir)
+			Expect(err).NotTo(HaveOccurred())
+			uploadedContents = string(contents)
+		}
+
+		ui := callCopySource("--space", "prod-space", "my-app", "my-app")
+
+		Expect(appBitsRepo.DownloadedAppGuid).To(Equal("source-app-guid"))
+		Expect(appBitsRepo.UploadedAppGuid).To(Equal("target-app-guid"))
+		Expect(uploadedContents).To(Equal("zip contents"))
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"cannot copy source bits, downloading and uploading them instead"},
+			{"Downloading source bits of app", "my-app"},
+			{"Uploading source bits to app", "my-app"},
+			{"OK"},
+		})
+	})
+
+	It("fails when the copy fails", func() {
+		appBitsRepo.CopyBitsErr = true
+
+		ui := callCopySource("my-app", "my-staging-app")
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Error copying source bits"}, {"Error copying bits"}})
+		Expect(starter.AppsStarted).To(BeEmpty())
+	})
+
+	It("restarts the target app when asked to", func() {
+		callCopySource("--restart", "my-app", "my-staging-app")
+
+		Expect(stopper.AppToStop.Guid).To(Equal("staging-app-guid"))
+		Expect(len(starter.AppsStarted)).To(Equal(1))
+		Expect(starter.AppsStarted[0].Guid).To(Equal("staging-app-guid"))
+	})
+})
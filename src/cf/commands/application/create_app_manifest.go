@@ -0,0 +1,147 @@
+package application
+
+import (
+	"cf/api"
+	"cf/configuration"
+	"cf/manifest"
+	"cf/models"
+	"cf/requirements"
+	"cf/terminal"
+	"errors"
+	"fmt"
+	"github.com/codegangsta/cli"
+)
+
+type CreateAppManifest struct {
+	ui             terminal.UI
+	config         configuration.Reader
+	appSummaryRepo api.AppSummaryRepository
+	manifestRepo   manifest.ManifestRepository
+	appReq         requirements.ApplicationRequirement
+}
+
+func NewCreateAppManifest(ui terminal.UI, config configuration.Reader, appSummaryRepo api.AppSummaryRepository, manifestRepo manifest.ManifestRepository) (cmd *CreateAppManifest) {
+	cmd = new(CreateAppManifest)
+	cmd.ui = ui
+	cmd.config = config
+	cmd.appSummaryRepo = appSummaryRepo
+	cmd.manifestRepo = manifestRepo
+	return
+}
+
+func (cmd *CreateAppManifest) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
+	if len(c.Args()) != 1 {
+		err = errors.New("Incorrect Usage")
+		cmd.ui.FailWithUsage(c, "create-app-manifest")
+		return
+	}
+
+	cmd.appReq = reqFactory.NewApplicationRequirement(c.Args()[0])
+
+	reqs = []requirements.Requirement{
+		reqFactory.NewLoginRequirement(),
+		reqFactory.NewTargetedSpaceRequirement(),
+		cmd.appReq,
+	}
+	return
+}
+
+func (cmd *CreateAppManifest) Run(c *cli.Context) {
+	app := cmd.appReq.GetApplication()
+
+	cmd.ui.Say("Creating an app manifest from current settings of app %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(app.Name),
+		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+		terminal.EntityNameColor(cmd.config.Username()),
+	)
+
+	summary, apiResponse := cmd.appSummaryRepo.GetSummary(app.Guid)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	path := c.String("p")
+	if path == "" {
+		path = fmt.Sprintf("%s_manifest.yml", app.Name)
+	}
+
+	m := &manifest.Manifest{Applications: []models.AppParams{appParamsForManifest(app, summary)}}
+	err := cmd.manifestRepo.WriteManifest(m, path)
+	if err != nil {
+		cmd.ui.Failed("Error creating manifest file: %s", err.Error())
+		return
+	}
+
+	cmd.ui.Ok()
+	cmd.ui.Say("Manifest file created successfully at %s", path)
+
+	hosts, domains := routeHostsAndDomains(app.Routes)
+	if len(app.Routes) > 1 && len(hosts)*len(domains) != len(app.Routes) {
+		cmd.ui.Warn("Pushing with this manifest will map every host in it to every domain in it, which is more routes than the app has now")
+	}
+}
+
+func appParamsForManifest(app models.Application, summary models.AppSummary) (params models.AppParams) {
+	params.Name = &app.Name
+	params.Memory = &app.Memory
+	params.InstanceCount = &app.InstanceCount
+
+	if app.DiskQuota != 0 {
+		params.DiskQuota = &app.DiskQuota
+	}
+
+	if app.BuildpackUrl != "" {
+		params.BuildpackUrl = &app.BuildpackUrl
+	}
+	if app.Command != "" {
+		params.Command = &app.Command
+	}
+	if app.Stack.Name != "" {
+		params.StackName = &app.Stack.Name
+	}
+
+	if len(app.Routes) == 0 {
+		noRoute := true
+		params.NoRoute = &noRoute
+	} else if len(app.Routes) == 1 {
+		route := app.Routes[0]
+		params.Host = &route.Host
+		params.Domain = &route.Domain.Name
+	} else {
+		hosts, domains := routeHostsAndDomains(app.Routes)
+		params.Hosts = &hosts
+		params.Domains = &domains
+	}
+
+	services := []string{}
+	for _, service := range summary.Services {
+		services = append(services, service.Name)
+	}
+	params.Services = &services
+
+	envVars := map[string]string{}
+	for key, value := range app.EnvironmentVars {
+		envVars[key] = value
+	}
+	params.EnvironmentVars = &envVars
+
+	return
+}
+
+func routeHostsAndDomains(routes []models.RouteSummary) (hosts, domains []string) {
+	seenHosts := map[string]bool{}
+	seenDomains := map[string]bool{}
+	for _, route := range routes {
+		if !seenHosts[route.Host] {
+			seenHosts[route.Host] = true
+			hosts = append(hosts, route.Host)
+		}
+		if !seenDomains[route.Domain.Name] {
+			seenDomains[route.Domain.Name] = true
+			domains = append(domains, route.Domain.Name)
+		}
+	}
+	return
+}
@@ -0,0 +1,189 @@
+package application_test
+
+import (
+	. "cf/commands/application"
+	"cf/models"
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	testapi "testhelpers/api"
+	testassert "testhelpers/assert"
+	testcmd "testhelpers/commands"
+	testconfig "testhelpers/configuration"
+	testmanifest "testhelpers/manifest"
+	testreq "testhelpers/requirements"
+	testterm "testhelpers/terminal"
+)
+
+var _ = Describe("create-app-manifest command", func() {
+	var (
+		ui             *testterm.FakeUI
+		reqFactory     *testreq.FakeReqFactory
+		appSummaryRepo *testapi.FakeAppSummaryRepo
+		manifestRepo   *testmanifest.FakeManifestRepository
+	)
+
+	BeforeEach(func() {
+		ui = &testterm.FakeUI{}
+		appSummaryRepo = &testapi.FakeAppSummaryRepo{}
+		manifestRepo = &testmanifest.FakeManifestRepository{}
+
+		app := models.Application{}
+		app.Name = "my-app"
+		app.Guid = "my-app-guid"
+		app.Memory = 256
+		app.DiskQuota = 1024
+		app.InstanceCount = 2
+		app.BuildpackUrl = "https://example.com/my-buildpack.git"
+		app.Command = "bundle exec rackup"
+		app.Stack = models.Stack{Name: "lucid64"}
+		app.EnvironmentVars = map[string]string{"FOO": "bar"}
+
+		route := models.RouteSummary{}
+		route.Host = "my-host"
+		route.Domain = models.DomainFields{Name: "example.com"}
+		app.Routes = []models.RouteSummary{route}
+
+		reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true, Application: app}
+
+		summary := models.AppSummary{}
+		summary.Services = []models.ServiceInstanceFields{{Name: "my-db"}, {Name: "my-cache"}}
+		appSummaryRepo.GetSummarySummary = summary
+	})
+
+	runCommand := func(args ...string) {
+		ctxt := testcmd.NewContext("create-app-manifest", args)
+		cmd := NewCreateAppManifest(ui, testconfig.NewRepositoryWithDefaults(), appSummaryRepo, manifestRepo)
+		testcmd.RunCommand(cmd, ctxt, reqFactory)
+	}
+
+	It("requires an app name", func() {
+		runCommand()
+		Expect(ui.FailedWithUsage).To(BeTrue())
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+	})
+
+	It("requires the user to be logged in and targeting a space", func() {
+		reqFactory.LoginSuccess = false
+		runCommand("my-app")
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+
+		reqFactory.LoginSuccess = true
+		reqFactory.TargetedSpaceSuccess = false
+		runCommand("my-app")
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+	})
+
+	It("writes the current settings of the app to a manifest", func() {
+		runCommand("my-app")
+
+		Expect(reqFactory.ApplicationName).To(Equal("my-app"))
+		Expect(appSummaryRepo.GetSummaryAppGuid).To(Equal("my-app-guid"))
+		Expect(manifestRepo.WriteManifestArgs.Path).To(Equal("my-app_manifest.yml"))
+
+		apps := manifestRepo.WriteManifestArgs.Manifest.Applications
+		Expect(len(apps)).To(Equal(1))
+		Expect(*apps[0].Name).To(Equal("my-app"))
+		Expect(*apps[0].Memory).To(Equal(uint64(256)))
+		Expect(*apps[0].DiskQuota).To(Equal(uint64(1024)))
+		Expect(*apps[0].InstanceCount).To(Equal(2))
+		Expect(*apps[0].BuildpackUrl).To(Equal("https://example.com/my-buildpack.git"))
+		Expect(*apps[0].Command).To(Equal("bundle exec rackup"))
+		Expect(*apps[0].StackName).To(Equal("lucid64"))
+		Expect(*apps[0].Host).To(Equal("my-host"))
+		Expect(*apps[0].Domain).To(Equal("example.com"))
+		Expect(apps[0].NoRoute).To(BeNil())
+		Expect(*apps[0].Services).To(Equal([]string{"my-db", "my-cache"}))
+		Expect(*apps[0].EnvironmentVars).To(Equal(map[string]string{"FOO": "bar"}))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Creating an app manifest", "my-app", "my-org", "my-space", "my-user"},
+			{"OK"},
+			{"Manifest file created successfully at", "my-app_manifest.yml"},
+		})
+	})
+
+	It("writes hosts and domains for apps with several routes", func() {
+		app := reqFactory.Application
+		app.Routes = []models.RouteSummary{}
+		for _, host := range []string{"www", "shop"} {
+			for _, domainName := range []string{"example.com", "example.org"} {
+				route := models.RouteSummary{}
+				route.Host = host
+				route.Domain = models.DomainFields{Name: domainName}
+				app.Routes = append(app.Routes, route)
+			}
+		}
+		reqFactory.Application = app
+
+		runCommand("my-app")
+
+		apps := manifestRepo.WriteManifestArgs.Manifest.Applications
+		Expect(apps[0].Host).To(BeNil())
+		Expect(apps[0].Domain).To(BeNil())
+		Expect(*apps[0].Hosts).To(Equal([]string{"www", "shop"}))
+		Expect(*apps[0].Domains).To(Equal([]string{"example.com", "example.org"}))
+		testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{
+			{"more routes than the app has now"},
+		})
+	})
+
+	It("warns when the routes of the app are not every host on every domain", func() {
+		app := reqFactory.Application
+		route := models.RouteSummary{}
+		route.Host = "other-host"
+		route.Domain = models.DomainFields{Name: "example.org"}
+		app.Routes = append(app.Routes, route)
+		reqFactory.Application = app
+
+		runCommand("my-app")
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"more routes than the app has now"},
+		})
+	})
+
+	It("writes the manifest to the path given with -p", func() {
+		runCommand("-p", "/some/path/manifest.yml", "my-app")
+		Expect(manifestRepo.WriteManifestArgs.Path).To(Equal("/some/path/manifest.yml"))
+	})
+
+	It("leaves out settings the app does not have", func() {
+		app := reqFactory.Application
+		app.BuildpackUrl = ""
+		app.Command = ""
+		app.DiskQuota = 0
+		app.Routes = []models.RouteSummary{}
+		reqFactory.Application = app
+
+		runCommand("my-app")
+
+		apps := manifestRepo.WriteManifestArgs.Manifest.Applications
+		Expect(apps[0].BuildpackUrl).To(BeNil())
+		Expect(apps[0].Command).To(BeNil())
+		Expect(apps[0].DiskQuota).To(BeNil())
+		Expect(apps[0].Host).To(BeNil())
+		Expect(apps[0].Domain).To(BeNil())
+		Expect(*apps[0].NoRoute).To(BeTrue())
+	})
+
+	It("fails when the app summary cannot be fetched", func() {
+		appSummaryRepo.GetSummaryErrorCode = "some-error"
+		runCommand("my-app")
+
+		Expect(manifestRepo.WriteManifestArgs.Manifest).To(BeNil())
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+		})
+	})
+
+	It("fails when the manifest cannot be written", func() {
+		manifestRepo.WriteManifestReturns.Error = errors.New("permission denied")
+		runCommand("my-app")
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"Error creating manifest file", "permission denied"},
+		})
+	})
+})
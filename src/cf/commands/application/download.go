@@ -0,0 +1,147 @@
+package application
+
+import (
+	"cf"
+	"cf/api"
+	"cf/configuration"
+	"cf/net"
+	"cf/requirements"
+	"cf/terminal"
+	"errors"
+	"fileutils"
+	"github.com/codegangsta/cli"
+	"io"
+	"os"
+)
+
+type DownloadApp struct {
+	ui          terminal.UI
+	config      configuration.Reader
+	appBitsRepo api.ApplicationBitsRepository
+	zipper      cf.Zipper
+	appReq      requirements.ApplicationRequirement
+}
+
+func NewDownloadApp(ui terminal.UI, config configuration.Reader, appBitsRepo api.ApplicationBitsRepository, zipper cf.Zipper) (cmd *DownloadApp) {
+	cmd = new(DownloadApp)
+	cmd.ui = ui
+	cmd.config = config
+	cmd.appBitsRepo = appBitsRepo
+	cmd.zipper = zipper
+	return
+}
+
+func (cmd *DownloadApp) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
+	if len(c.Args()) != 1 {
+		err = errors.New("Incorrect Usage")
+		cmd.ui.FailWithUsage(c, "download")
+		return
+	}
+
+	cmd.appReq = reqFactory.NewApplicationRequirement(c.Args()[0])
+
+	reqs = []requirements.Requirement{
+		reqFactory.NewLoginRequirement(),
+		reqFactory.NewTargetedSpaceRequirement(),
+		cmd.appReq,
+	}
+	return
+}
+
+func (cmd *DownloadApp) Run(c *cli.Context) {
+	app := cmd.appReq.GetApplication()
+	droplet := c.Bool("droplet")
+	unpack := c.Bool("unpack")
+
+	if droplet && unpack {
+		cmd.ui.Failed("Only source bits can be unpacked, droplets are gzipped tarballs")
+		return
+	}
+
+	outputPath := c.String("o")
+	if outputPath == "" {
+		outputPath = defaultDownloadPath(app.Name, droplet, unpack)
+	}
+
+	contents := "source bits"
+	if droplet {
+		contents = "droplet"
+	}
+
+	cmd.ui.Say("Downloading %s of app %s in org %s / space %s as %s to %s...",
+		contents,
+		terminal.EntityNameColor(app.Name),
+		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+		terminal.EntityNameColor(cmd.config.Username()),
+		terminal.EntityNameColor(outputPath),
+	)
+
+	if unpack {
+		cmd.downloadAndUnpack(app.Guid, outputPath)
+	} else {
+		cmd.downloadToFile(app.Guid, droplet, outputPath)
+	}
+}
+
+func defaultDownloadPath(appName string, droplet, unpack bool) string {
+	switch {
+	case droplet:
+		return appName + "-droplet.tgz"
+	case unpack:
+		return appName
+	default:
+		return appName + ".zip"
+	}
+}
+
+func (cmd *DownloadApp) downloadToFile(appGuid string, droplet bool, outputPath string) {
+	file, err := fileutils.CreateFile(outputPath)
+	if err != nil {
+		cmd.ui.Failed("Error creating %s\n%s", outputPath, err.Error())
+		return
+	}
+
+	apiResponse := cmd.download(appGuid, droplet, file)
+	file.Close()
+	if apiResponse.IsNotSuccessful() {
+		os.Remove(outputPath)
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	cmd.ui.Ok()
+}
+
+func (cmd *DownloadApp) downloadAndUnpack(appGuid string, outputDir string) {
+	fileutils.TempFile("app-download", func(file *os.File, err error) {
+		if err != nil {
+			cmd.ui.Failed("Error creating temporary file\n%s", err.Error())
+			return
+		}
+
+		apiResponse := cmd.download(appGuid, false, file)
+		if apiResponse.IsNotSuccessful() {
+			cmd.ui.Failed(apiResponse.Message)
+			return
+		}
+
+		err = cmd.zipper.Unzip(file.Name(), outputDir)
+		if err != nil {
+			cmd.ui.Failed("Error unpacking source bits into %s\n%s", outputDir, err.Error())
+			return
+		}
+
+		cmd.ui.Ok()
+	})
+}
+
+func (cmd *DownloadApp) download(appGuid string, droplet bool, destination io.Writer) (apiResponse net.ApiResponse) {
+	progressBar := cmd.ui.ProgressBar()
+	defer progressBar.Done()
+
+	if droplet {
+		return cmd.appBitsRepo.DownloadDroplet(appGuid, destination, progressBar.Update)
+	}
+	return cmd.appBitsRepo.DownloadApp(appGuid, destination, progressBar.Update)
+}
@@ -0,0 +1,147 @@
+package application_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"cf"
+	. "cf/commands/application"
+	"cf/models"
+	"fileutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	testapi "testhelpers/api"
+	testassert "testhelpers/assert"
+	testcmd "testhelpers/commands"
+	testconfig "testhelpers/configuration"
+	testreq "testhelpers/requirements"
+	testterm "testhelpers/terminal"
+)
+
+var _ = Describe("download command", func() {
+	var (
+		appBitsRepo *testapi.FakeApplicationBitsRepository
+		reqFactory  *testreq.FakeReqFactory
+	)
+
+	BeforeEach(func() {
+		app := models.Application{}
+		app.Name = "my-app"
+		app.Guid = "my-app-guid"
+
+		appBitsRepo = &testapi.FakeApplicationBitsRepository{DownloadContents: "the bits"}
+		reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true, Application: app}
+	})
+
+	It("requires a user to be logged in, a space to be targeted and the app to exist", func() {
+		fileutils.TempDir("download_test", func(dir string, err error) {
+			outputPath := filepath.Join(dir, "app.zip")
+
+			callDownload([]string{"-o", outputPath, "my-app"}, reqFactory, appBitsRepo)
+			Expect(testcmd.CommandDidPassRequirements).To(BeTrue())
+			Expect(reqFactory.ApplicationName).To(Equal("my-app"))
+
+			reqFactory.LoginSuccess = false
+			callDownload([]string{"-o", outputPath, "my-app"}, reqFactory, appBitsRepo)
+			Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+		})
+	})
+
+	It("fails with usage when no app is given", func() {
+		ui := callDownload([]string{}, reqFactory, appBitsRepo)
+		Expect(ui.FailedWithUsage).To(BeTrue())
+	})
+
+	It("saves the source bits to a file", func() {
+		fileutils.TempDir("download_test", func(dir string, err error) {
+			outputPath := filepath.Join(dir, "app.zip")
+
+			ui := callDownload([]string{"-o", outputPath, "my-app"}, reqFactory, appBitsRepo)
+
+			Expect(appBitsRepo.DownloadedAppGuid).To(Equal("my-app-guid"))
+			Expect(appBitsRepo.DownloadedDropletGuid).To(Equal(""))
+			Expect(ui.ProgressBarUpdates).To(Equal([]int64{8}))
+			Expect(ui.ProgressBarDone).To(BeTrue())
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"Downloading source bits of app", "my-app", "my-org", "my-space", "my-user", outputPath},
+				{"OK"},
+			})
+
+			contents, err := ioutil.ReadFile(outputPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("the bits"))
+		})
+	})
+
+	It("saves the droplet with --droplet", func() {
+		fileutils.TempDir("download_test", func(dir string, err error) {
+			outputPath := filepath.Join(dir, "droplet.tgz")
+
+			ui := callDownload([]string{"--droplet", "-o", outputPath, "my-app"}, reqFactory, appBitsRepo)
+
+			Expect(appBitsRepo.DownloadedDropletGuid).To(Equal("my-app-guid"))
+			Expect(appBitsRepo.DownloadedAppGuid).To(Equal(""))
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"Downloading droplet of app", "my-app"},
+				{"OK"},
+			})
+		})
+	})
+
+	It("removes the partial file when the download fails", func() {
+		fileutils.TempDir("download_test", func(dir string, err error) {
+			outputPath := filepath.Join(dir, "app.zip")
+			appBitsRepo.DownloadErr = true
+
+			ui := callDownload([]string{"-o", outputPath, "my-app"}, reqFactory, appBitsRepo)
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"FAILED"},
+				{"Error downloading"},
+			})
+			_, err = os.Stat(outputPath)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	It("unpacks the source bits into a directory with --unpack", func() {
+		zipContents := &bytes.Buffer{}
+		writer := zip.NewWriter(zipContents)
+		file, err := writer.Create("lib/app.rb")
+		Expect(err).NotTo(HaveOccurred())
+		file.Write([]byte("puts 'hello'"))
+		writer.Close()
+		appBitsRepo.DownloadContents = zipContents.String()
+
+		fileutils.TempDir("download_test", func(dir string, err error) {
+			ui := callDownload([]string{"--unpack", "-o", dir, "my-app"}, reqFactory, appBitsRepo)
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"OK"}})
+			contents, err := ioutil.ReadFile(filepath.Join(dir, "lib", "app.rb"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("puts 'hello'"))
+		})
+	})
+
+	It("does not unpack droplets", func() {
+		ui := callDownload([]string{"--unpack", "--droplet", "my-app"}, reqFactory, appBitsRepo)
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"Only source bits can be unpacked"},
+		})
+		Expect(appBitsRepo.DownloadedDropletGuid).To(Equal(""))
+	})
+})
+
+func callDownload(args []string, reqFactory *testreq.FakeReqFactory, appBitsRepo *testapi.FakeApplicationBitsRepository) (ui *testterm.FakeUI) {
+	ui = &testterm.FakeUI{}
+	ctxt := testcmd.NewContext("download", args)
+
+	configRepo := testconfig.NewRepositoryWithDefaults()
+	cmd := NewDownloadApp(ui, configRepo, appBitsRepo, cf.ApplicationZipper{})
+	testcmd.RunCommand(cmd, ctxt, reqFactory)
+	return
+}
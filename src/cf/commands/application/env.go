@@ -1,23 +1,38 @@
 package application
 
 import (
+	"cf/api"
 	"cf/configuration"
+	"cf/models"
 	"cf/requirements"
 	"cf/terminal"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/codegangsta/cli"
+	"regexp"
+	"sort"
+	"strings"
 )
 
 type Env struct {
-	ui     terminal.UI
-	config configuration.Reader
-	appReq requirements.ApplicationRequirement
+	ui      terminal.UI
+	config  configuration.Reader
+	appRepo api.ApplicationRepository
+	appReq  requirements.ApplicationRequirement
 }
 
-func NewEnv(ui terminal.UI, config configuration.Reader) (cmd *Env) {
+type envSection struct {
+	title     string
+	emptyText string
+	vars      []map[string]interface{}
+}
+
+func NewEnv(ui terminal.UI, config configuration.Reader, appRepo api.ApplicationRepository) (cmd *Env) {
 	cmd = new(Env)
 	cmd.ui = ui
 	cmd.config = config
+	cmd.appRepo = appRepo
 	return
 }
 
@@ -40,22 +55,122 @@ func (cmd *Env) GetRequirements(reqFactory requirements.Factory, c *cli.Context)
 func (cmd *Env) Run(c *cli.Context) {
 	app := cmd.appReq.GetApplication()
 
+	format := c.String("export")
+	if format != "" {
+		cmd.export(app, format)
+		return
+	}
+
 	cmd.ui.Say("Getting env variables for app %s in org %s / space %s as %s...",
 		terminal.EntityNameColor(app.Name),
 		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
 		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
 		terminal.EntityNameColor(cmd.config.Username()),
 	)
-	envVars := app.EnvironmentVars
+
+	env, apiResponse := cmd.appRepo.ReadEnv(app.Guid)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
 
 	cmd.ui.Ok()
-	cmd.ui.Say("")
 
-	if len(envVars) == 0 {
-		cmd.ui.Say("No env variables exist")
+	sections := []envSection{
+		{"System-Provided", "No system-provided env variables have been set", []map[string]interface{}{env.System, env.Application}},
+		{"User-Provided", "No user-defined env variables have been set", []map[string]interface{}{env.User}},
+		{"Running Environment Variable Groups", "No running env variables have been set", []map[string]interface{}{env.Running}},
+		{"Staging Environment Variable Groups", "No staging env variables have been set", []map[string]interface{}{env.Staging}},
+	}
+	for _, section := range sections {
+		cmd.ui.Say("")
+		cmd.saySection(section)
+	}
+}
+
+func (cmd *Env) saySection(section envSection) {
+	values := map[string]string{}
+	for _, vars := range section.vars {
+		for key, value := range vars {
+			stringValue, ok := value.(string)
+			if !ok {
+				bytes, _ := json.MarshalIndent(value, "", "  ")
+				stringValue = string(bytes)
+			}
+			values[key] = stringValue
+		}
+	}
+
+	if len(values) == 0 {
+		cmd.ui.Say("%s", section.emptyText)
+		return
+	}
+
+	cmd.ui.Say("%s:", terminal.HeaderColor(section.title))
+	for _, key := range sortedEnvKeys(values) {
+		cmd.ui.Say("%s: %s", key, terminal.EntityNameColor(values[key]))
+	}
+}
+
+// export prints the variables the app sees when it runs, and nothing else, so that they can be
+// redirected to a file and used to run the app locally against the same services.
+func (cmd *Env) export(app models.Application, format string) {
+	var formatVar func(key, value string) string
+	switch format {
+	case "dotenv":
+		formatVar = dotenvVar
+	case "shell":
+		formatVar = shellVar
+	case "json":
+	default:
+		cmd.ui.Failed("Unknown export format %s, it has to be one of dotenv, json or shell", format)
+		return
+	}
+
+	env, apiResponse := cmd.appRepo.ReadEnv(app.Guid)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
 		return
 	}
-	for key, value := range envVars {
-		cmd.ui.Say("%s: %s", key, terminal.EntityNameColor(value))
+	vars := env.RuntimeVars()
+
+	if formatVar == nil {
+		bytes, err := json.MarshalIndent(vars, "", "  ")
+		if err != nil {
+			cmd.ui.Failed("Error writing env variables as JSON\n%s", err.Error())
+			return
+		}
+		cmd.ui.Say("%s", bytes)
+		return
+	}
+
+	for _, key := range sortedEnvKeys(vars) {
+		// names are chosen by whoever set the variable, and would run as commands when the output
+		// is evaluated, so only names a shell accepts are written. The warning is written as a
+		// comment, and without color, so that the output stays valid.
+		if !envVarNameRegex.MatchString(key) {
+			cmd.ui.Say("# Skipped %q, it is not a valid variable name", key)
+			continue
+		}
+		cmd.ui.Say("%s", formatVar(key, vars[key]))
+	}
+}
+
+var envVarNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func dotenvVar(key, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", `\$`).Replace(value)
+	return fmt.Sprintf(`%s="%s"`, key, value)
+}
+
+func shellVar(key, value string) string {
+	return fmt.Sprintf("export %s='%s'", key, strings.Replace(value, "'", `'\''`, -1))
+}
+
+func sortedEnvKeys(vars map[string]string) (keys []string) {
+	for key := range vars {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return
 }
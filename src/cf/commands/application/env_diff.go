@@ -0,0 +1,319 @@
+package application
+
+import (
+	"cf/api"
+	"cf/configuration"
+	"cf/formatters"
+	"cf/models"
+	"cf/net"
+	"cf/requirements"
+	"cf/terminal"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const envDiffMask = "****"
+
+// envDiffFields are compared before the env variables, in this order.
+var envDiffFields = []string{"memory", "disk", "instances", "buildpack", "command", "stack", "routes", "services"}
+
+var (
+	secretNameRegexp  = regexp.MustCompile(`(?i)pass|secret|token|key|credential|auth|private|cert`)
+	secretValueRegexp = regexp.MustCompile(`://[^/\s:@]+:[^/\s@]+@`)
+)
+
+type EnvDiff struct {
+	ui             terminal.UI
+	config         configuration.Reader
+	appRepo        api.ApplicationRepository
+	appSummaryRepo api.AppSummaryRepository
+	orgRepo        api.OrganizationRepository
+	spaceRepo      api.SpaceRepository
+	appRefs        []appReference
+}
+
+// appReference names an app as APP in the targeted space, SPACE/APP in the targeted org or
+// ORG/SPACE/APP.
+type appReference struct {
+	orgName   string
+	spaceName string
+	appName   string
+}
+
+type envDiffJSON struct {
+	Left        string             `json:"left"`
+	Right       string             `json:"right"`
+	Differences []envDiffFieldJSON `json:"differences"`
+}
+
+type envDiffFieldJSON struct {
+	Field string  `json:"field"`
+	Left  *string `json:"left"`
+	Right *string `json:"right"`
+}
+
+func NewEnvDiff(ui terminal.UI, config configuration.Reader, appRepo api.ApplicationRepository, appSummaryRepo api.AppSummaryRepository, orgRepo api.OrganizationRepository, spaceRepo api.SpaceRepository) (cmd *EnvDiff) {
+	cmd = new(EnvDiff)
+	cmd.ui = ui
+	cmd.config = config
+	cmd.appRepo = appRepo
+	cmd.appSummaryRepo = appSummaryRepo
+	cmd.orgRepo = orgRepo
+	cmd.spaceRepo = spaceRepo
+	return
+}
+
+func (cmd *EnvDiff) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
+	if len(c.Args()) != 2 {
+		err = errors.New("Incorrect Usage")
+		cmd.ui.FailWithUsage(c, "env-diff")
+		return
+	}
+
+	cmd.appRefs = []appReference{}
+	for _, arg := range c.Args() {
+		ref, ok := parseAppReference(arg)
+		if !ok {
+			err = errors.New("Incorrect Usage")
+			cmd.ui.FailWithUsage(c, "env-diff")
+			return
+		}
+		cmd.appRefs = append(cmd.appRefs, ref)
+	}
+
+	reqs = []requirements.Requirement{reqFactory.NewLoginRequirement()}
+	for _, ref := range cmd.appRefs {
+		switch {
+		case ref.spaceName == "":
+			reqs = append(reqs, reqFactory.NewTargetedSpaceRequirement())
+		case ref.orgName == "":
+			reqs = append(reqs, reqFactory.NewTargetedOrgRequirement())
+		}
+	}
+	return
+}
+
+func parseAppReference(arg string) (ref appReference, ok bool) {
+	parts := strings.Split(arg, "/")
+	for _, part := range parts {
+		if part == "" {
+			return
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		ref.appName = parts[0]
+	case 2:
+		ref.spaceName, ref.appName = parts[0], parts[1]
+	case 3:
+		ref.orgName, ref.spaceName, ref.appName = parts[0], parts[1], parts[2]
+	default:
+		return
+	}
+	ok = true
+	return
+}
+
+func (ref appReference) String() string {
+	return fmt.Sprintf("%s/%s/%s", ref.orgName, ref.spaceName, ref.appName)
+}
+
+func (cmd *EnvDiff) Run(c *cli.Context) {
+	left, right := cmd.resolve(cmd.appRefs[0]), cmd.resolve(cmd.appRefs[1])
+	asJSON := c.Bool("json")
+
+	if !asJSON {
+		cmd.ui.Say("Comparing app %s in org %s / space %s with app %s in org %s / space %s as %s...",
+			terminal.EntityNameColor(left.appName),
+			terminal.EntityNameColor(left.orgName),
+			terminal.EntityNameColor(left.spaceName),
+			terminal.EntityNameColor(right.appName),
+			terminal.EntityNameColor(right.orgName),
+			terminal.EntityNameColor(right.spaceName),
+			terminal.EntityNameColor(cmd.config.Username()),
+		)
+	}
+
+	leftFields, apiResponse := cmd.readAppFields(left)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+	rightFields, apiResponse := cmd.readAppFields(right)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	fieldNames := envDiffFieldNames(leftFields, rightFields)
+
+	if asJSON {
+		cmd.sayJSONDiff(left, right, fieldNames, leftFields, rightFields)
+		return
+	}
+
+	cmd.ui.Ok()
+	cmd.ui.Say("")
+
+	differences := 0
+	for _, name := range fieldNames {
+		leftValue, leftSet := leftFields[name]
+		rightValue, rightSet := rightFields[name]
+		if leftSet == rightSet && leftValue == rightValue {
+			continue
+		}
+
+		cmd.ui.Say("%s", diffLine(name, maskSecret(name, leftValue), leftSet, maskSecret(name, rightValue), rightSet))
+		differences++
+	}
+
+	if differences == 0 {
+		cmd.ui.Say("No differences")
+	}
+}
+
+// resolve fills in the targeted org and space for a reference that leaves them out.
+func (cmd *EnvDiff) resolve(ref appReference) appReference {
+	if ref.spaceName == "" {
+		ref.spaceName = cmd.config.SpaceFields().Name
+	}
+	if ref.orgName == "" {
+		ref.orgName = cmd.config.OrganizationFields().Name
+	}
+	return ref
+}
+
+// findSpaceGuid finds a space by its name and the name of its org, using the targeted ones when it can.
+func findSpaceGuid(config configuration.Reader, orgRepo api.OrganizationRepository, spaceRepo api.SpaceRepository, orgName, spaceName string) (spaceGuid string, apiResponse net.ApiResponse) {
+	if orgName == config.OrganizationFields().Name && spaceName == config.SpaceFields().Name {
+		spaceGuid = config.SpaceFields().Guid
+		return
+	}
+
+	var space models.Space
+	if orgName == config.OrganizationFields().Name {
+		space, apiResponse = spaceRepo.FindByName(spaceName)
+	} else {
+		var org models.Organization
+		org, apiResponse = orgRepo.FindByName(orgName)
+		if apiResponse.IsNotSuccessful() {
+			return
+		}
+		space, apiResponse = spaceRepo.FindByNameInOrg(spaceName, org.Guid)
+	}
+
+	spaceGuid = space.Guid
+	return
+}
+
+// readAppFields reads the configuration of the app as values to compare.
+func (cmd *EnvDiff) readAppFields(ref appReference) (fields map[string]string, apiResponse net.ApiResponse) {
+	spaceGuid, apiResponse := findSpaceGuid(cmd.config, cmd.orgRepo, cmd.spaceRepo, ref.orgName, ref.spaceName)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+
+	app, apiResponse := cmd.appRepo.ReadFromSpace(ref.appName, spaceGuid)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+
+	summary, apiResponse := cmd.appSummaryRepo.GetSummary(app.Guid)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+
+	routes := []string{}
+	for _, route := range app.Routes {
+		routes = append(routes, route.URL())
+	}
+	sort.Strings(routes)
+
+	services := []string{}
+	for _, service := range summary.Services {
+		services = append(services, service.Name)
+	}
+	sort.Strings(services)
+
+	fields = map[string]string{
+		"memory":    formatters.ByteSize(app.Memory * formatters.MEGABYTE),
+		"disk":      formatters.ByteSize(app.DiskQuota * formatters.MEGABYTE),
+		"instances": fmt.Sprintf("%d", app.InstanceCount),
+		"buildpack": app.BuildpackUrl,
+		"command":   app.Command,
+		"stack":     app.Stack.Name,
+		"routes":    strings.Join(routes, ", "),
+		"services":  strings.Join(services, ", "),
+	}
+
+	for name, value := range app.EnvironmentVars {
+		fields["env."+name] = value
+	}
+	return
+}
+
+// maskSecret hides the values of env variables that look like secrets, either by their name or
+// because they are URLs with a password in them. Values are compared before they are masked, so
+// that a changed secret still shows up as a difference.
+func maskSecret(fieldName, value string) string {
+	if !strings.HasPrefix(fieldName, "env.") {
+		return value
+	}
+	if secretNameRegexp.MatchString(fieldName) || secretValueRegexp.MatchString(value) {
+		return envDiffMask
+	}
+	return value
+}
+
+// envDiffFieldNames are the fields of both apps, the env variables sorted by name after the
+// others.
+func envDiffFieldNames(leftFields, rightFields map[string]string) (names []string) {
+	envNames := map[string]string{}
+	for _, fields := range []map[string]string{leftFields, rightFields} {
+		for name := range fields {
+			if strings.HasPrefix(name, "env.") {
+				envNames[name] = ""
+			}
+		}
+	}
+
+	names = append(names, envDiffFields...)
+	names = append(names, sortedEnvKeys(envNames)...)
+	return
+}
+
+func (cmd *EnvDiff) sayJSONDiff(left, right appReference, fieldNames []string, leftFields, rightFields map[string]string) {
+	diff := envDiffJSON{Left: left.String(), Right: right.String(), Differences: []envDiffFieldJSON{}}
+
+	for _, name := range fieldNames {
+		leftValue, leftSet := leftFields[name]
+		rightValue, rightSet := rightFields[name]
+		if leftSet == rightSet && leftValue == rightValue {
+			continue
+		}
+
+		field := envDiffFieldJSON{Field: name}
+		if leftSet {
+			leftValue = maskSecret(name, leftValue)
+			field.Left = &leftValue
+		}
+		if rightSet {
+			rightValue = maskSecret(name, rightValue)
+			field.Right = &rightValue
+		}
+		diff.Differences = append(diff.Differences, field)
+	}
+
+	bytes, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		cmd.ui.Failed("Error writing the differences as JSON\n%s", err.Error())
+		return
+	}
+	cmd.ui.Say("%s", bytes)
+}
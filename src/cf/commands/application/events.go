@@ -4,10 +4,14 @@ import (
 	"cf/api"
 	"cf/configuration"
 	"cf/models"
+	"cf/net"
 	"cf/requirements"
 	"cf/terminal"
 	"errors"
+	"fmt"
 	"github.com/codegangsta/cli"
+	"strings"
+	"time"
 )
 
 type Events struct {
@@ -15,6 +19,19 @@ type Events struct {
 	config     configuration.Reader
 	appReq     requirements.ApplicationRequirement
 	eventsRepo api.AppEventsRepository
+
+	// FollowInterval is how long --follow waits between polls for new events. MaxFollowPolls stops
+	// following after that many polls, it goes on until the process is stopped when it is 0.
+	FollowInterval time.Duration
+	MaxFollowPolls int
+}
+
+// followedEvents remembers the latest events that were printed, so that polling again from their
+// time does not print them twice.
+type followedEvents struct {
+	latest time.Time
+	keys   map[string]bool
+	count  int
 }
 
 func NewEvents(ui terminal.UI, config configuration.Reader, eventsRepo api.AppEventsRepository) (cmd *Events) {
@@ -22,6 +39,7 @@ func NewEvents(ui terminal.UI, config configuration.Reader, eventsRepo api.AppEv
 	cmd.ui = ui
 	cmd.config = config
 	cmd.eventsRepo = eventsRepo
+	cmd.FollowInterval = 5 * time.Second
 	return
 }
 
@@ -45,6 +63,18 @@ func (cmd *Events) GetRequirements(reqFactory requirements.Factory, c *cli.Conte
 func (cmd *Events) Run(c *cli.Context) {
 	app := cmd.appReq.GetApplication()
 
+	query, err := eventQueryFromFlags(c, time.Now())
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+		return
+	}
+
+	follow := c.Bool("follow")
+	if follow && !query.Until.IsZero() {
+		cmd.ui.Failed("--follow cannot be combined with --until")
+		return
+	}
+
 	cmd.ui.Say("Getting events for app %s in org %s / space %s as %s...\n",
 		terminal.EntityNameColor(app.Name),
 		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
@@ -53,24 +83,123 @@ func (cmd *Events) Run(c *cli.Context) {
 	)
 
 	table := cmd.ui.Table([]string{"time", "event", "description"})
-	noEvents := true
-
-	apiResponse := cmd.eventsRepo.ListEvents(app.Guid, func(event models.EventFields) bool {
-		table.Print([][]string{{
-			event.Timestamp.Local().Format(TIMESTAMP_FORMAT),
-			event.Name,
-			event.Description,
-		}})
-		noEvents = false
-		return true
-	})
+	printed := &followedEvents{keys: map[string]bool{}}
 
+	apiResponse := cmd.printEvents(app.Guid, query, table, printed)
 	if apiResponse.IsNotSuccessful() {
 		cmd.ui.Failed("Failed fetching events.\n%s", apiResponse.Message)
 		return
 	}
-	if noEvents {
+	if printed.count == 0 {
 		cmd.ui.Say("No events for app %s", terminal.EntityNameColor(app.Name))
+	}
+	if !follow {
+		return
+	}
+
+	for polls := 1; cmd.MaxFollowPolls == 0 || polls < cmd.MaxFollowPolls; polls++ {
+		cmd.ui.Wait(cmd.FollowInterval)
+
+		if !printed.latest.IsZero() {
+			query.Since = printed.latest
+		}
+		apiResponse = cmd.printEvents(app.Guid, query, table, printed)
+		if apiResponse.IsNotSuccessful() {
+			cmd.ui.Warn("Failed fetching events, trying again.\n%s", apiResponse.Message)
+		}
+	}
+}
+
+func (cmd *Events) printEvents(appGuid string, query models.EventQuery, table terminal.Table, printed *followedEvents) net.ApiResponse {
+	return cmd.eventsRepo.ListEvents(appGuid, query, func(event models.EventFields) bool {
+		if printed.add(event) {
+			table.Print([][]string{{
+				event.Timestamp.Local().Format(TIMESTAMP_FORMAT),
+				event.Name,
+				event.Description,
+			}})
+		}
+		return true
+	})
+}
+
+// add tells whether the event is new, and remembers it when it is.
+func (printed *followedEvents) add(event models.EventFields) bool {
+	key := event.Guid
+	if key == "" {
+		key = event.Name + event.Description
+	}
+
+	if printed.keys[key] && event.Timestamp.Equal(printed.latest) {
+		return false
+	}
+	if event.Timestamp.After(printed.latest) {
+		printed.latest = event.Timestamp
+		printed.keys = map[string]bool{}
+	}
+	if event.Timestamp.Equal(printed.latest) {
+		printed.keys[key] = true
+	}
+	printed.count++
+	return true
+}
+
+// eventQueryFromFlags reads --since, --until and --type. Times are relative to now when they are
+// given as a duration.
+func eventQueryFromFlags(c *cli.Context, now time.Time) (query models.EventQuery, err error) {
+	if c.String("since") != "" {
+		query.Since, err = parseEventTime(c.String("since"), now)
+		if err != nil {
+			return
+		}
+	}
+	if c.String("until") != "" {
+		query.Until, err = parseEventTime(c.String("until"), now)
+		if err != nil {
+			return
+		}
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && query.Until.Before(query.Since) {
+		err = errors.New("--until has to be after --since")
 		return
 	}
+
+	for _, eventType := range strings.Split(c.String("type"), ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType != "" {
+			query.Types = append(query.Types, fullEventType(eventType))
+		}
+	}
+	return
+}
+
+func parseEventTime(value string, now time.Time) (eventTime time.Time, err error) {
+	duration, err := time.ParseDuration(value)
+	if err == nil {
+		eventTime = now.Add(-duration)
+		return
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		eventTime, err = time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return
+		}
+	}
+
+	err = fmt.Errorf("Invalid time %s, it has to be a date, a time like 2014-01-21T15:04:05Z or a duration like 2h", value)
+	return
+}
+
+// fullEventType turns the short names of app events, like crash or update, into the types the
+// Cloud Controller knows them by.
+func fullEventType(eventType string) string {
+	switch {
+	case strings.Contains(eventType, "."):
+		return eventType
+	case eventType == "crash":
+		return api.APP_CRASH_EVENT_TYPE
+	default:
+		return "audit.app." + eventType
+	}
 }
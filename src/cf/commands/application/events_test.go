@@ -3,8 +3,10 @@ package application_test
 import (
 	. "cf/commands/application"
 	"cf/models"
+	"cf/net"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"strings"
 	testapi "testhelpers/api"
 	testassert "testhelpers/assert"
 	testcmd "testhelpers/commands"
@@ -81,6 +83,119 @@ var _ = Describe("Testing with ginkgo", func() {
 			{"No events", "my-app"},
 		})
 	})
+
+	Describe("filtering events", func() {
+		It("asks for the events in a time range and of the given types", func() {
+			reqFactory, eventsRepo := getEventsDependencies()
+
+			callEvents([]string{"--since", "2014-01-21T10:00:00Z", "--until", "2014-01-22", "--type", "crash, update,audit.app.create", "my-app"}, reqFactory, eventsRepo)
+
+			Expect(len(eventsRepo.Queries)).To(Equal(1))
+			query := eventsRepo.Queries[0]
+			Expect(query.Since).To(Equal(time.Date(2014, 1, 21, 10, 0, 0, 0, time.UTC).In(query.Since.Location())))
+			Expect(query.Until).To(Equal(time.Date(2014, 1, 22, 0, 0, 0, 0, time.Local)))
+			Expect(query.Types).To(Equal([]string{"app.crash", "audit.app.update", "audit.app.create"}))
+		})
+
+		It("takes durations as times before now", func() {
+			reqFactory, eventsRepo := getEventsDependencies()
+
+			before := time.Now()
+			callEvents([]string{"--since", "2h", "my-app"}, reqFactory, eventsRepo)
+			after := time.Now()
+
+			since := eventsRepo.Queries[0].Since
+			Expect(since.After(before.Add(-2*time.Hour - time.Second))).To(BeTrue())
+			Expect(since.Before(after.Add(-2*time.Hour + time.Second))).To(BeTrue())
+			Expect(eventsRepo.Queries[0].Until.IsZero()).To(BeTrue())
+			Expect(eventsRepo.Queries[0].Types).To(BeEmpty())
+		})
+
+		It("fails with an invalid time or time range", func() {
+			reqFactory, eventsRepo := getEventsDependencies()
+
+			ui := callEvents([]string{"--since", "yesterday", "my-app"}, reqFactory, eventsRepo)
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Invalid time yesterday"}})
+
+			ui = callEvents([]string{"--since", "1h", "--until", "2h", "my-app"}, reqFactory, eventsRepo)
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"--until has to be after --since"}})
+
+			Expect(eventsRepo.Queries).To(BeEmpty())
+		})
+	})
+
+	Describe("following events", func() {
+		newEvent := func(guid string, timestamp time.Time) (event models.EventFields) {
+			event.Guid = guid
+			event.Name = "audit.app.update"
+			event.Timestamp = timestamp
+			event.Description = "description of " + guid
+			return
+		}
+
+		It("polls for events since the latest one and prints each event once", func() {
+			reqFactory, eventsRepo := getEventsDependencies()
+
+			first := time.Date(2014, 1, 21, 10, 0, 0, 0, time.UTC)
+			second := first.Add(time.Minute)
+			eventsRepo.EventPolls = [][]models.EventFields{
+				{newEvent("event-1", first)},
+				{newEvent("event-1", first), newEvent("event-2", second), newEvent("event-3", second)},
+				{newEvent("event-2", second), newEvent("event-3", second), newEvent("event-4", second)},
+			}
+
+			ui := callEventsFollowing([]string{"--follow", "--type", "update", "my-app"}, reqFactory, eventsRepo, 3)
+
+			Expect(len(eventsRepo.Queries)).To(Equal(3))
+			Expect(eventsRepo.Queries[0].Since.IsZero()).To(BeTrue())
+			Expect(eventsRepo.Queries[1].Since).To(Equal(first))
+			Expect(eventsRepo.Queries[2].Since).To(Equal(second))
+			Expect(eventsRepo.Queries[2].Types).To(Equal([]string{"audit.app.update"}))
+
+			output := strings.Join(ui.Outputs, "\n")
+			for _, guid := range []string{"event-1", "event-2", "event-3", "event-4"} {
+				Expect(strings.Count(output, "description of "+guid)).To(Equal(1))
+			}
+			Expect(strings.Count(output, "description")).To(Equal(5))
+		})
+
+		It("warns when polling fails and keeps following", func() {
+			reqFactory, eventsRepo := getEventsDependencies()
+			reqFactory.Application.Name = "my-app"
+			eventsRepo.EventPolls = [][]models.EventFields{{}, {}, {newEvent("event-1", time.Now())}}
+			eventsRepo.PollApiResponses = []net.ApiResponse{net.ApiResponse{}, net.NewApiResponseWithMessage("Error listing events")}
+
+			ui := callEventsFollowing([]string{"--follow", "my-app"}, reqFactory, eventsRepo, 3)
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"No events", "my-app"},
+				{"Failed fetching events, trying again."},
+				{"Error listing events"},
+				{"description of event-1"},
+			})
+			for _, line := range ui.Outputs {
+				Expect(line).NotTo(Equal("FAILED"))
+			}
+		})
+
+		It("fails when the events cannot be listed before following them", func() {
+			reqFactory, eventsRepo := getEventsDependencies()
+			eventsRepo.ApiResponse = net.NewApiResponseWithMessage("Error listing events")
+
+			ui := callEventsFollowing([]string{"--follow", "my-app"}, reqFactory, eventsRepo, 3)
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Error listing events"}})
+			Expect(len(eventsRepo.Queries)).To(Equal(1))
+		})
+
+		It("cannot follow events up to a time", func() {
+			reqFactory, eventsRepo := getEventsDependencies()
+
+			ui := callEvents([]string{"--follow", "--until", "1h", "my-app"}, reqFactory, eventsRepo)
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"--follow cannot be combined with --until"}})
+		})
+	})
 })
 
 func getEventsDependencies() (reqFactory *testreq.FakeReqFactory, eventsRepo *testapi.FakeAppEventsRepo) {
@@ -98,3 +213,15 @@ func callEvents(args []string, reqFactory *testreq.FakeReqFactory, eventsRepo *t
 	testcmd.RunCommand(cmd, ctxt, reqFactory)
 	return
 }
+
+func callEventsFollowing(args []string, reqFactory *testreq.FakeReqFactory, eventsRepo *testapi.FakeAppEventsRepo, polls int) (ui *testterm.FakeUI) {
+	ui = new(testterm.FakeUI)
+	ctxt := testcmd.NewContext("events", args)
+
+	configRepo := testconfig.NewRepositoryWithDefaults()
+	cmd := NewEvents(ui, configRepo, eventsRepo)
+	cmd.FollowInterval = time.Millisecond
+	cmd.MaxFollowPolls = polls
+	testcmd.RunCommand(cmd, ctxt, reqFactory)
+	return
+}
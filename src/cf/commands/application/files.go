@@ -3,17 +3,46 @@ package application
 import (
 	"cf/api"
 	"cf/configuration"
+	"cf/net"
 	"cf/requirements"
 	"cf/terminal"
 	"errors"
+	"fileutils"
+	"fmt"
 	"github.com/codegangsta/cli"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+// FilesDownloadWorkers is how many files are downloaded at the same time when a directory is
+// downloaded with --recursive.
+var FilesDownloadWorkers = 4
+
 type Files struct {
 	ui           terminal.UI
 	config       configuration.Reader
 	appFilesRepo api.AppFilesRepository
 	appReq       requirements.ApplicationRequirement
+
+	// TailInterval is how long --tail waits between polls of the file. MaxTailPolls stops following
+	// the file after that many polls, it goes on until the process is stopped when it is 0.
+	TailInterval time.Duration
+	MaxTailPolls int
+}
+
+type fileListingEntry struct {
+	name  string
+	isDir bool
+}
+
+type fileDownload struct {
+	remotePath string
+	localPath  string
 }
 
 func NewFiles(ui terminal.UI, config configuration.Reader, appFilesRepo api.AppFilesRepository) (cmd *Files) {
@@ -21,6 +50,7 @@ func NewFiles(ui terminal.UI, config configuration.Reader, appFilesRepo api.AppF
 	cmd.ui = ui
 	cmd.config = config
 	cmd.appFilesRepo = appFilesRepo
+	cmd.TailInterval = 1 * time.Second
 	return
 }
 
@@ -44,19 +74,55 @@ func (cmd *Files) GetRequirements(reqFactory requirements.Factory, c *cli.Contex
 func (cmd *Files) Run(c *cli.Context) {
 	app := cmd.appReq.GetApplication()
 
+	instance := c.Int("i")
+	if instance < 0 {
+		cmd.ui.Failed("Instance index must not be negative")
+		return
+	}
+
+	localPath := c.String("o")
+	recursive := c.Bool("recursive")
+	if recursive && localPath == "" {
+		cmd.ui.Failed("A local directory to download to has to be given with -o for --recursive")
+		return
+	}
+
+	remotePath := "/"
+	if len(c.Args()) > 1 {
+		remotePath = c.Args()[1]
+	}
+
+	tail := c.Bool("tail")
+	if tail && (localPath != "" || recursive) {
+		cmd.ui.Failed("--tail cannot be used together with -o or --recursive")
+		return
+	}
+	if tail && len(c.Args()) < 2 {
+		cmd.ui.Failed("The path of a file to follow has to be given for --tail")
+		return
+	}
+
+	switch {
+	case tail:
+		cmd.tailFile(app.Name, app.Guid, instance, remotePath)
+	case recursive:
+		cmd.downloadDirectory(app.Name, app.Guid, instance, remotePath, localPath)
+	case localPath != "":
+		cmd.downloadFile(app.Name, app.Guid, instance, remotePath, localPath)
+	default:
+		cmd.listFiles(app.Name, app.Guid, instance, remotePath)
+	}
+}
+
+func (cmd *Files) listFiles(appName, appGuid string, instance int, remotePath string) {
 	cmd.ui.Say("Getting files for app %s in org %s / space %s as %s...",
-		terminal.EntityNameColor(app.Name),
+		terminal.EntityNameColor(appName),
 		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
 		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
 		terminal.EntityNameColor(cmd.config.Username()),
 	)
 
-	path := "/"
-	if len(c.Args()) > 1 {
-		path = c.Args()[1]
-	}
-
-	list, apiResponse := cmd.appFilesRepo.ListFiles(app.Guid, path)
+	list, apiResponse := cmd.appFilesRepo.ListFiles(appGuid, instance, remotePath)
 	if apiResponse.IsNotSuccessful() {
 		cmd.ui.Failed(apiResponse.Message)
 		return
@@ -66,3 +132,153 @@ func (cmd *Files) Run(c *cli.Context) {
 	cmd.ui.Say("")
 	cmd.ui.Say("%s", list)
 }
+
+func (cmd *Files) downloadFile(appName, appGuid string, instance int, remotePath, localPath string) {
+	cmd.sayDownloading("file", appName, instance, remotePath, localPath)
+
+	progressBar := cmd.ui.ProgressBar()
+	apiResponse := cmd.download(appGuid, instance, fileDownload{remotePath: remotePath, localPath: localPath}, progressBar.Update)
+	progressBar.Done()
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	cmd.ui.Ok()
+}
+
+// downloadDirectory mirrors the directory at remoteDir into localDir. The directory listings are
+// walked first, then the files are downloaded by a pool of workers. Files that fail to download
+// do not stop the others.
+func (cmd *Files) downloadDirectory(appName, appGuid string, instance int, remoteDir, localDir string) {
+	cmd.sayDownloading("directory", appName, instance, remoteDir, localDir)
+
+	downloads := []fileDownload{}
+	apiResponse := cmd.collectDownloads(appGuid, instance, remoteDir, localDir, &downloads)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	failures := cmd.downloadAll(appGuid, instance, downloads)
+	if len(failures) > 0 {
+		cmd.ui.Failed("Could not download %d of %d files:\n%s", len(failures), len(downloads), strings.Join(failures, "\n"))
+		return
+	}
+
+	cmd.ui.Ok()
+	cmd.ui.Say("%d files downloaded to %s", len(downloads), terminal.EntityNameColor(localDir))
+}
+
+func (cmd *Files) sayDownloading(kind, appName string, instance int, remotePath, localPath string) {
+	cmd.ui.Say("Downloading %s %s of instance %s of app %s in org %s / space %s as %s to %s...",
+		kind,
+		terminal.EntityNameColor(remotePath),
+		terminal.EntityNameColor(fmt.Sprintf("#%d", instance)),
+		terminal.EntityNameColor(appName),
+		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+		terminal.EntityNameColor(cmd.config.Username()),
+		terminal.EntityNameColor(localPath),
+	)
+}
+
+func (cmd *Files) collectDownloads(appGuid string, instance int, remoteDir, localDir string, downloads *[]fileDownload) (apiResponse net.ApiResponse) {
+	listing, apiResponse := cmd.appFilesRepo.ListFiles(appGuid, instance, remoteDir)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+
+	err := os.MkdirAll(localDir, os.ModePerm)
+	if err != nil {
+		apiResponse = net.NewApiResponseWithError("Error creating directory "+localDir, err)
+		return
+	}
+
+	for _, entry := range parseFileListing(listing) {
+		remotePath := path.Join(remoteDir, entry.name)
+		localPath := filepath.Join(localDir, entry.name)
+
+		if !entry.isDir {
+			*downloads = append(*downloads, fileDownload{remotePath: remotePath, localPath: localPath})
+			continue
+		}
+
+		apiResponse = cmd.collectDownloads(appGuid, instance, remotePath, localPath, downloads)
+		if apiResponse.IsNotSuccessful() {
+			return
+		}
+	}
+	return
+}
+
+func (cmd *Files) downloadAll(appGuid string, instance int, downloads []fileDownload) (failures []string) {
+	jobs := make(chan fileDownload)
+	results := make(chan string, len(downloads))
+	workers := &sync.WaitGroup{}
+
+	for i := 0; i < FilesDownloadWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for download := range jobs {
+				apiResponse := cmd.download(appGuid, instance, download, nil)
+				if apiResponse.IsNotSuccessful() {
+					results <- fmt.Sprintf("%s: %s", download.remotePath, apiResponse.Message)
+				}
+			}
+		}()
+	}
+
+	for _, download := range downloads {
+		jobs <- download
+	}
+	close(jobs)
+	workers.Wait()
+	close(results)
+
+	for failure := range results {
+		failures = append(failures, failure)
+	}
+	sort.Strings(failures)
+	return
+}
+
+func (cmd *Files) download(appGuid string, instance int, download fileDownload, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	file, err := fileutils.CreateFile(download.localPath)
+	if err != nil {
+		apiResponse = net.NewApiResponseWithError("Error creating "+download.localPath, err)
+		return
+	}
+
+	apiResponse = cmd.appFilesRepo.DownloadFile(appGuid, instance, download.remotePath, file, progressCb)
+	file.Close()
+	if apiResponse.IsNotSuccessful() {
+		os.Remove(download.localPath)
+	}
+	return
+}
+
+// parseFileListing reads a directory listing, where every line has the name of an entry followed
+// by its size, and the names of directories end in a slash.
+func parseFileListing(listing string) (entries []fileListingEntry) {
+	for _, line := range strings.Split(listing, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		separator := strings.LastIndexAny(line, " \t")
+		if separator < 0 {
+			continue
+		}
+
+		name := strings.TrimSpace(line[:separator])
+		isDir := strings.HasSuffix(name, "/")
+		name = strings.TrimSuffix(name, "/")
+
+		// names that would lead out of the directory being mirrored are left out
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+			continue
+		}
+
+		entries = append(entries, fileListingEntry{name: name, isDir: isDir})
+	}
+	return
+}
@@ -0,0 +1,141 @@
+package application
+
+import (
+	"cf/terminal"
+	"fmt"
+	"strings"
+)
+
+// tailBacklogBytes is how much of the end of a file is printed before following it.
+const tailBacklogBytes = 4096
+
+// fileTail is what is known about a file being followed. The byte before the offset is read again
+// on every poll, so that a file which was replaced by a different one of at least the same size is
+// noticed when that byte changes.
+type fileTail struct {
+	offset      int64
+	lastByte    byte
+	partialLine string
+	missing     bool
+}
+
+func (tail *fileTail) advance(contents []byte) {
+	tail.offset += int64(len(contents))
+	if len(contents) > 0 {
+		tail.lastByte = contents[len(contents)-1]
+	}
+}
+
+// lines returns the complete lines in what was read so far, a line without its newline yet is kept
+// until the rest of it is read.
+func (tail *fileTail) lines(contents []byte) (lines []string) {
+	lines = strings.Split(tail.partialLine+string(contents), "\n")
+	tail.partialLine = lines[len(lines)-1]
+	lines = lines[:len(lines)-1]
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return
+}
+
+func (cmd *Files) tailFile(appName, appGuid string, instance int, remotePath string) {
+	cmd.ui.Say("Following file %s of instance %s of app %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(remotePath),
+		terminal.EntityNameColor(fmt.Sprintf("#%d", instance)),
+		terminal.EntityNameColor(appName),
+		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+		terminal.EntityNameColor(cmd.config.Username()),
+	)
+
+	fileRange, apiResponse := cmd.appFilesRepo.ReadFileRange(appGuid, instance, remotePath, -tailBacklogBytes)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	cmd.ui.Say("Press Ctrl-C to stop.\n")
+
+	tail := &fileTail{offset: fileRange.Offset}
+	tail.advance(fileRange.Contents)
+
+	backlog := fileRange.Contents
+	if fileRange.Offset > 0 {
+		// the backlog most likely starts in the middle of a line
+		newline := strings.IndexByte(string(backlog), '\n')
+		backlog = backlog[newline+1:]
+	}
+	cmd.sayLines(tail.lines(backlog))
+
+	for polls := 1; cmd.MaxTailPolls == 0 || polls < cmd.MaxTailPolls; polls++ {
+		cmd.ui.Wait(cmd.TailInterval)
+		cmd.pollTail(appGuid, instance, remotePath, tail)
+	}
+
+	if tail.partialLine != "" {
+		cmd.ui.Say("%s", tail.partialLine)
+	}
+}
+
+// pollTail prints what was appended to the file since the last poll. Errors are only warned about,
+// as the file may well be readable again at the next poll.
+func (cmd *Files) pollTail(appGuid string, instance int, remotePath string, tail *fileTail) {
+	readFrom := tail.offset
+	if readFrom > 0 {
+		readFrom--
+	}
+
+	fileRange, apiResponse := cmd.appFilesRepo.ReadFileRange(appGuid, instance, remotePath, readFrom)
+	if apiResponse.IsNotFound() {
+		if !tail.missing {
+			cmd.ui.Warn("%s is gone, waiting for it to be created again...", remotePath)
+			cmd.resetTail(tail)
+			tail.missing = true
+		}
+		return
+	}
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Warn("Could not read %s: %s", remotePath, apiResponse.Message)
+		return
+	}
+
+	if tail.missing {
+		cmd.ui.Warn("%s was created again, following it from the start", remotePath)
+		tail.missing = false
+	}
+
+	if fileRange.PastEnd {
+		if tail.offset > 0 {
+			cmd.ui.Warn("%s was truncated, following it from the start", remotePath)
+			cmd.resetTail(tail)
+		}
+		return
+	}
+
+	contents := fileRange.Contents
+	if tail.offset > 0 {
+		if fileRange.Offset != readFrom || len(contents) == 0 || contents[0] != tail.lastByte {
+			cmd.ui.Warn("%s was replaced, following it from the start", remotePath)
+			cmd.resetTail(tail)
+			return
+		}
+		contents = contents[1:]
+	}
+
+	tail.advance(contents)
+	cmd.sayLines(tail.lines(contents))
+}
+
+// resetTail prints what is left of the last line and starts over at the beginning of the file.
+func (cmd *Files) resetTail(tail *fileTail) {
+	if tail.partialLine != "" {
+		cmd.ui.Say("%s", tail.partialLine)
+	}
+	*tail = fileTail{}
+}
+
+func (cmd *Files) sayLines(lines []string) {
+	for _, line := range lines {
+		cmd.ui.Say("%s", line)
+	}
+}
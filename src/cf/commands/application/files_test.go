@@ -3,8 +3,13 @@ package application_test
 import (
 	. "cf/commands/application"
 	"cf/models"
+	"fileutils"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	testapi "testhelpers/api"
 	testassert "testhelpers/assert"
 	testcmd "testhelpers/commands"
@@ -76,6 +81,248 @@ var _ = Describe("Testing with ginkgo", func() {
 			{"%s %d %i"},
 		})
 	})
+
+	Describe("instances and downloads", func() {
+		var (
+			reqFactory   *testreq.FakeReqFactory
+			appFilesRepo *testapi.FakeAppFilesRepo
+		)
+
+		BeforeEach(func() {
+			app := models.Application{}
+			app.Name = "my-found-app"
+			app.Guid = "my-app-guid"
+			reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true, Application: app}
+
+			appFilesRepo = &testapi.FakeAppFilesRepo{
+				Listings: map[string]string{
+					"/app": "core                  1.5M\n" +
+						"logs/                 -\n" +
+						"my file.txt           12B\n" +
+						"../                   -\n",
+					"/app/logs": "stdout.log            1.2K\n" +
+						"empty/                -\n",
+					"/app/logs/empty": "",
+				},
+				Files: map[string]string{
+					"/app/core":            "\x00\xff\r\ncore",
+					"/app/my file.txt":     "hello",
+					"/app/logs/stdout.log": "started\n",
+				},
+			}
+		})
+
+		It("lists the files of the instance given with -i", func() {
+			appFilesRepo.Listings = nil
+			appFilesRepo.FileList = "file 1"
+
+			callFiles([]string{"-i", "2", "my-app", "/foo"}, reqFactory, appFilesRepo)
+
+			Expect(appFilesRepo.Instance).To(Equal(2))
+			Expect(appFilesRepo.Path).To(Equal("/foo"))
+		})
+
+		It("fails with a negative instance index", func() {
+			ui := callFiles([]string{"-i", "-1", "my-app"}, reqFactory, appFilesRepo)
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Instance index must not be negative"}})
+		})
+
+		It("downloads a file as it is with -o", func() {
+			fileutils.TempDir("files", func(dir string, err error) {
+				localPath := filepath.Join(dir, "core")
+				ui := callFiles([]string{"-i", "1", "-o", localPath, "my-app", "/app/core"}, reqFactory, appFilesRepo)
+
+				testassert.SliceContains(ui.Outputs, testassert.Lines{
+					{"Downloading file", "/app/core", "#1", "my-found-app", "my-org", "my-space", "my-user", localPath},
+					{"OK"},
+				})
+				Expect(appFilesRepo.Instance).To(Equal(1))
+
+				contents, err := ioutil.ReadFile(localPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(contents).To(Equal([]byte("\x00\xff\r\ncore")))
+			})
+		})
+
+		It("does not leave a file behind when the download fails", func() {
+			fileutils.TempDir("files", func(dir string, err error) {
+				localPath := filepath.Join(dir, "missing")
+				ui := callFiles([]string{"-o", localPath, "my-app", "/app/missing"}, reqFactory, appFilesRepo)
+
+				testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"/app/missing not found"}})
+				_, err = os.Stat(localPath)
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+
+		It("mirrors a directory tree with --recursive", func() {
+			fileutils.TempDir("files", func(dir string, err error) {
+				localDir := filepath.Join(dir, "crash")
+				ui := callFiles([]string{"-i", "3", "-o", localDir, "--recursive", "my-app", "/app"}, reqFactory, appFilesRepo)
+
+				testassert.SliceContains(ui.Outputs, testassert.Lines{
+					{"Downloading directory", "/app", "#3", "my-found-app", localDir},
+					{"OK"},
+					{"3 files downloaded to", localDir},
+				})
+				Expect(appFilesRepo.Instance).To(Equal(3))
+				Expect(appFilesRepo.DownloadedPaths).To(Equal([]string{"/app/core", "/app/logs/stdout.log", "/app/my file.txt"}))
+
+				contents, _ := ioutil.ReadFile(filepath.Join(localDir, "core"))
+				Expect(contents).To(Equal([]byte("\x00\xff\r\ncore")))
+				contents, _ = ioutil.ReadFile(filepath.Join(localDir, "my file.txt"))
+				Expect(string(contents)).To(Equal("hello"))
+				contents, _ = ioutil.ReadFile(filepath.Join(localDir, "logs", "stdout.log"))
+				Expect(string(contents)).To(Equal("started\n"))
+				Expect(fileutils.IsDirEmpty(filepath.Join(localDir, "logs", "empty"))).To(BeTrue())
+
+				entries, _ := ioutil.ReadDir(dir)
+				Expect(len(entries)).To(Equal(1))
+			})
+		})
+
+		It("downloads the other files when some of them fail", func() {
+			delete(appFilesRepo.Files, "/app/core")
+
+			fileutils.TempDir("files", func(dir string, err error) {
+				ui := callFiles([]string{"-o", dir, "--recursive", "my-app", "/app"}, reqFactory, appFilesRepo)
+
+				testassert.SliceContains(ui.Outputs, testassert.Lines{
+					{"FAILED"},
+					{"Could not download 1 of 3 files"},
+					{"/app/core", "not found"},
+				})
+				_, err = os.Stat(filepath.Join(dir, "core"))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+				_, err = os.Stat(filepath.Join(dir, "logs", "stdout.log"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		It("needs a local directory for --recursive", func() {
+			ui := callFiles([]string{"--recursive", "my-app", "/app"}, reqFactory, appFilesRepo)
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"-o", "--recursive"}})
+			Expect(appFilesRepo.DownloadedPaths).To(BeEmpty())
+		})
+	})
+
+	Describe("following a file with --tail", func() {
+		var (
+			reqFactory   *testreq.FakeReqFactory
+			appFilesRepo *testapi.FakeAppFilesRepo
+		)
+
+		BeforeEach(func() {
+			app := models.Application{}
+			app.Name = "my-found-app"
+			app.Guid = "my-app-guid"
+			reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true, Application: app}
+			appFilesRepo = &testapi.FakeAppFilesRepo{}
+		})
+
+		callTail := func(polls int, args ...string) (ui *testterm.FakeUI) {
+			ui = &testterm.FakeUI{}
+			cmd := NewFiles(ui, testconfig.NewRepositoryWithDefaults(), appFilesRepo)
+			cmd.MaxTailPolls = polls
+			testcmd.RunCommand(cmd, testcmd.NewContext("files", append([]string{"--tail"}, args...)), reqFactory)
+			return
+		}
+
+		It("prints the end of the file and then the lines appended to it", func() {
+			start := strings.Repeat("x", 5000) + "\nline 1\nline 2\npart"
+			appFilesRepo.RangeFileVersions = []string{start, start + "ial\nline 3\n"}
+
+			ui := callTail(2, "-i", "1", "my-app", "/app/logs/stdout.log")
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"Following file", "/app/logs/stdout.log", "#1", "my-found-app", "my-org", "my-space", "my-user"},
+				{"line 1"},
+				{"line 2"},
+				{"partial"},
+				{"line 3"},
+			})
+			testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{{"xxx"}})
+			Expect(appFilesRepo.Instance).To(Equal(1))
+			Expect(appFilesRepo.RangeOffsets).To(Equal([]int64{-4096, int64(len(start) - 1)}))
+		})
+
+		It("prints the whole file when it is short", func() {
+			appFilesRepo.RangeFileVersions = []string{"first\r\nsecond\n"}
+
+			ui := callTail(1, "my-app", "/app/logs/stdout.log")
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"first"}, {"second"}})
+		})
+
+		It("starts over when the file is truncated", func() {
+			appFilesRepo.RangeFileVersions = []string{"a\nb\n", "c\n"}
+
+			ui := callTail(3, "my-app", "/app/logs/stdout.log")
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"a"},
+				{"b"},
+				{"/app/logs/stdout.log was truncated"},
+				{"c"},
+			})
+			Expect(appFilesRepo.RangeOffsets).To(Equal([]int64{-4096, 3, 0}))
+		})
+
+		It("starts over when the file is replaced by a different one", func() {
+			appFilesRepo.RangeFileVersions = []string{"one\n", "two!three\n"}
+
+			ui := callTail(3, "my-app", "/app/logs/stdout.log")
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"one"},
+				{"/app/logs/stdout.log was replaced"},
+				{"two!three"},
+			})
+			Expect(appFilesRepo.RangeOffsets).To(Equal([]int64{-4096, 3, 0}))
+		})
+
+		It("waits for the file when it is gone and keeps polling after errors", func() {
+			appFilesRepo.RangeFileVersions = []string{"a\n", "", "", "", "b\n"}
+			appFilesRepo.RangeErrorCodes = []string{"", "404", "404", "500", ""}
+
+			ui := callTail(5, "my-app", "/app/logs/stdout.log")
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"a"},
+				{"/app/logs/stdout.log is gone"},
+				{"Could not read /app/logs/stdout.log", "Error reading file"},
+				{"/app/logs/stdout.log was created again"},
+				{"b"},
+			})
+
+			gone := 0
+			for _, output := range ui.Outputs {
+				if strings.Contains(output, "is gone") {
+					gone++
+				}
+			}
+			Expect(gone).To(Equal(1))
+			testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{{"FAILED"}})
+		})
+
+		It("fails when the file cannot be read at first", func() {
+			appFilesRepo.RangeErrorCodes = []string{"404"}
+
+			ui := callTail(1, "my-app", "/app/missing.log")
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"/app/missing.log not found"}})
+		})
+
+		It("needs a file and cannot download at the same time", func() {
+			ui := callTail(1, "my-app")
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"path of a file", "--tail"}})
+
+			ui = callTail(1, "-o", "local.log", "my-app", "/app/logs/stdout.log")
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"--tail", "-o", "--recursive"}})
+			Expect(appFilesRepo.RangeOffsets).To(BeEmpty())
+		})
+	})
 })
 
 func callFiles(args []string, reqFactory *testreq.FakeReqFactory, appFilesRepo *testapi.FakeAppFilesRepo) (ui *testterm.FakeUI) {
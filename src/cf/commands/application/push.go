@@ -1,6 +1,7 @@
 package application
 
 import (
+	"cf"
 	"cf/api"
 	"cf/commands/service"
 	"cf/configuration"
@@ -16,30 +17,36 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type Push struct {
-	ui             terminal.UI
-	config         configuration.Reader
-	manifestRepo   manifest.ManifestRepository
-	starter        ApplicationStarter
-	stopper        ApplicationStopper
-	binder         service.ServiceBinder
-	appRepo        api.ApplicationRepository
-	domainRepo     api.DomainRepository
-	routeRepo      api.RouteRepository
-	serviceRepo    api.ServiceRepository
-	stackRepo      api.StackRepository
-	appBitsRepo    api.ApplicationBitsRepository
-	globalServices []models.ServiceInstance
+	ui                              terminal.UI
+	config                          configuration.Reader
+	manifestRepo                    manifest.ManifestRepository
+	starter                         ApplicationStarter
+	stopper                         ApplicationStopper
+	binder                          service.ServiceBinder
+	appRepo                         api.ApplicationRepository
+	domainRepo                      api.DomainRepository
+	routeRepo                       api.RouteRepository
+	serviceRepo                     api.ServiceRepository
+	userProvidedServiceInstanceRepo api.UserProvidedServiceInstanceRepository
+	stackRepo                       api.StackRepository
+	appBitsRepo                     api.ApplicationBitsRepository
+	dropletStashRepo                api.DropletStashRepository
+	appSummaryRepo                  api.AppSummaryRepository
+	globalServices                  []models.ServiceInstance
 }
 
 func NewPush(ui terminal.UI, config configuration.Reader, manifestRepo manifest.ManifestRepository,
 	starter ApplicationStarter, stopper ApplicationStopper, binder service.ServiceBinder,
 	appRepo api.ApplicationRepository, domainRepo api.DomainRepository, routeRepo api.RouteRepository,
-	stackRepo api.StackRepository, serviceRepo api.ServiceRepository, appBitsRepo api.ApplicationBitsRepository) (cmd *Push) {
+	stackRepo api.StackRepository, serviceRepo api.ServiceRepository, userProvidedServiceInstanceRepo api.UserProvidedServiceInstanceRepository,
+	appBitsRepo api.ApplicationBitsRepository, dropletStashRepo api.DropletStashRepository, appSummaryRepo api.AppSummaryRepository) (cmd *Push) {
 	cmd = &Push{}
 	cmd.ui = ui
 	cmd.config = config
@@ -51,8 +58,11 @@ func NewPush(ui terminal.UI, config configuration.Reader, manifestRepo manifest.
 	cmd.domainRepo = domainRepo
 	cmd.routeRepo = routeRepo
 	cmd.serviceRepo = serviceRepo
+	cmd.userProvidedServiceInstanceRepo = userProvidedServiceInstanceRepo
 	cmd.stackRepo = stackRepo
 	cmd.appBitsRepo = appBitsRepo
+	cmd.dropletStashRepo = dropletStashRepo
+	cmd.appSummaryRepo = appSummaryRepo
 	return
 }
 
@@ -67,28 +77,240 @@ func (cmd *Push) GetRequirements(reqFactory requirements.Factory, c *cli.Context
 func (cmd *Push) Run(c *cli.Context) {
 	appSet := cmd.findAndValidateAppsToPush(c)
 
+	if c.Bool("plan") {
+		cmd.showPlan(appSet, c)
+		return
+	}
+
+	if c.Bool("show-files") {
+		cmd.showFiles(appSet, c)
+		return
+	}
+
+	parallelism := c.Int("parallel")
+	if parallelism > len(appSet) {
+		parallelism = len(appSet)
+	}
+
+	if parallelism <= 1 {
+		pushInDependencyOrder(appSet, 1, func(appParams models.AppParams) error {
+			cmd.pushApp(appParams, c)
+			return nil
+		})
+		return
+	}
+
+	lock := &sync.Mutex{}
+	failed, skipped := pushInDependencyOrder(appSet, parallelism, func(appParams models.AppParams) error {
+		return cmd.tryWithPrefixedUI(fmt.Sprintf("[%s] ", appName(appParams)), lock, func(appCmd *Push) {
+			appCmd.pushApp(appParams, c)
+		})
+	})
+
+	if len(failed) == 0 {
+		return
+	}
+
+	message := fmt.Sprintf("Could not push %s", strings.Join(failed, ", "))
+	if len(skipped) > 0 {
+		message += fmt.Sprintf("\nDid not push %s, which depend on apps that could not be pushed", strings.Join(skipped, ", "))
+	}
+	cmd.ui.Failed(message)
+}
+
+// withUI returns a copy of the command that prints to ui, for pushing apps side by side.
+func (cmd *Push) withUI(ui terminal.UI) *Push {
+	appCmd := *cmd
+	appCmd.ui = ui
+	appCmd.starter = cmd.starter.WithUI(ui)
+	appCmd.stopper = cmd.stopper.WithUI(ui)
+	return &appCmd
+}
+
+// tryWithPrefixedUI calls push with a copy of the command whose output starts with prefix. When
+// push fails through that UI, the failure is returned instead of exiting.
+func (cmd *Push) tryWithPrefixedUI(prefix string, lock *sync.Mutex, push func(appCmd *Push)) (err error) {
+	appCmd := cmd.withUI(terminal.NewPrefixedUI(cmd.ui, prefix, lock))
+
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		failure, ok := recovered.(terminal.UIFailure)
+		if !ok {
+			panic(recovered)
+		}
+		err = failure
+	}()
+
+	push(appCmd)
+	return
+}
+
+func ignoreFiles(c *cli.Context) []string {
+	if c.Bool("gitignore") {
+		return cf.CfAndGitIgnoreFiles
+	}
+	return cf.CfIgnoreFiles
+}
+
+// showFiles lists the files of each app that the ignore files leave in, without pushing anything.
+func (cmd *Push) showFiles(appSet []models.AppParams, c *cli.Context) {
 	for _, appParams := range appSet {
-		cmd.fetchStackGuid(&appParams)
+		files, apiResponse := cmd.appBitsRepo.AppFiles(*appParams.Path, ignoreFiles(c))
+		if apiResponse.IsNotSuccessful() {
+			cmd.ui.Failed("Error finding files to upload.\n%s", apiResponse.Message)
+			return
+		}
+
+		paths := []string{}
+		var size int64
+		for _, file := range files {
+			paths = append(paths, filepath.ToSlash(file.Path))
+			size += file.Size
+		}
+		sort.Strings(paths)
+
+		cmd.ui.Say("Files to upload for %s from %s:", terminal.EntityNameColor(*appParams.Name), *appParams.Path)
+		for _, path := range paths {
+			cmd.ui.Say("  %s", path)
+		}
+		cmd.ui.Say("%d files, %s", len(paths), formatters.ByteSize(uint64(size)))
+		cmd.ui.Say("")
+	}
+}
+
+func (cmd *Push) pushApp(appParams models.AppParams, c *cli.Context) {
+	if c.Bool("stash") {
+		cmd.stashDroplet(*appParams.Name)
+	}
+
+	if c.String("strategy") == blueGreenStrategy {
+		cmd.pushBlueGreen(appParams, c)
+		return
+	}
 
-		app := cmd.createOrUpdateApp(appParams)
+	cmd.pushAndRestart(appParams, c)
+}
+
+// stashDroplet keeps the droplet the app is running and its settings, so that the push can be
+// rolled back. Apps that do not exist or have never been staged have nothing to stash.
+func (cmd *Push) stashDroplet(appName string) {
+	app, apiResponse := cmd.appRepo.Read(appName)
+	if apiResponse.IsNotFound() {
+		return
+	}
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	cmd.ui.Say("Stashing current droplet of %s...", terminal.EntityNameColor(app.Name))
+
+	progressBar := cmd.ui.ProgressBar()
+	stash, apiResponse := cmd.dropletStashRepo.Stash(app, progressBar.Update)
+	progressBar.Done()
+	if apiResponse.IsNotFound() {
+		cmd.ui.Warn("App %s has no droplet to stash yet", app.Name)
+		cmd.ui.Say("")
+		return
+	}
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed("Error stashing droplet.\n%s", apiResponse.Message)
+		return
+	}
+
+	cmd.ui.Ok()
+	cmd.ui.Say("Stashed as version %d, roll back with '%s rollback %s'", stash.Version, cf.Name(), app.Name)
+	cmd.ui.Say("")
+}
+
+func (cmd *Push) pushAndRestart(appParams models.AppParams, c *cli.Context) {
+	cmd.fetchStackGuid(&appParams)
 
-		cmd.bindAppToRoute(app, appParams, c)
+	app := cmd.createOrUpdateApp(appParams)
 
-		cmd.ui.Say("Uploading %s...", terminal.EntityNameColor(app.Name))
+	cmd.bindAppToRoute(app, appParams, c)
+
+	cmd.ui.Say("Uploading %s...", terminal.EntityNameColor(app.Name))
+
+	progressBar := cmd.ui.ProgressBar()
+	apiResponse := cmd.appBitsRepo.UploadApp(app.Guid, *appParams.Path, ignoreFiles(c), cmd.describeUploadOperation, progressBar.Update)
+	progressBar.Done()
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(fmt.Sprintf("Error uploading application.\n%s", apiResponse.Message))
+		return
+	}
+	cmd.ui.Ok()
+
+	if appParams.ServiceInstances != nil {
+		cmd.createServiceInstances(*appParams.ServiceInstances)
+	}
+
+	if appParams.Services != nil {
+		cmd.bindAppToServices(*appParams.Services, app)
+	}
+
+	cmd.restart(app, appParams, c)
+}
+
+func (cmd *Push) createServiceInstances(instances []models.ServiceInstanceParams) {
+	for _, instance := range instances {
+		_, apiResponse := cmd.serviceRepo.FindInstanceByName(instance.Name)
+		if apiResponse.IsSuccessful() {
+			cmd.ui.Say("Using service %s", terminal.EntityNameColor(instance.Name))
+			continue
+		}
+
+		if !apiResponse.IsNotFound() {
+			cmd.ui.Failed(apiResponse.Message)
+			return
+		}
+
+		cmd.ui.Say("Creating service %s in org %s / space %s as %s...",
+			terminal.EntityNameColor(instance.Name),
+			terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+			terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+			terminal.EntityNameColor(cmd.config.Username()),
+		)
+
+		if instance.IsUserProvided() {
+			apiResponse = cmd.userProvidedServiceInstanceRepo.Create(instance.Name, instance.SyslogDrainUrl, instance.Credentials)
+		} else {
+			apiResponse = cmd.createManagedServiceInstance(instance)
+		}
 
-		apiResponse := cmd.appBitsRepo.UploadApp(app.Guid, *appParams.Path, cmd.describeUploadOperation)
 		if apiResponse.IsNotSuccessful() {
-			cmd.ui.Failed(fmt.Sprintf("Error uploading application.\n%s", apiResponse.Message))
+			cmd.ui.Failed("Could not create service %s\n%s", instance.Name, apiResponse.Message)
 			return
 		}
+
 		cmd.ui.Ok()
+		cmd.ui.Say("")
+	}
+}
 
-		if appParams.Services != nil {
-			cmd.bindAppToServices(*appParams.Services, app)
-		}
+func (cmd *Push) createManagedServiceInstance(instance models.ServiceInstanceParams) (apiResponse net.ApiResponse) {
+	offerings, apiResponse := cmd.serviceRepo.GetAllServiceOfferings()
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
 
-		cmd.restart(app, appParams, c)
+	offering, err := service.FindOffering(offerings, instance.Label)
+	if err != nil {
+		apiResponse = net.NewApiResponseWithMessage("%s", err.Error())
+		return
 	}
+
+	plan, err := service.FindPlan(offering.Plans, instance.Plan)
+	if err != nil {
+		apiResponse = net.NewApiResponseWithMessage("%s", err.Error())
+		return
+	}
+
+	_, apiResponse = cmd.serviceRepo.CreateServiceInstance(instance.Name, plan.Guid)
+	return
 }
 
 func (cmd *Push) bindAppToServices(services []string, app models.Application) {
@@ -144,29 +366,35 @@ func (cmd *Push) bindAppToRoute(app models.Application, params models.AppParams,
 		return
 	}
 
-	routeFlagsPresent := c.String("n") != "" || c.String("d") != "" || c.Bool("no-hostname")
-	if len(app.Routes) > 0 && !routeFlagsPresent {
+	if keepsExistingRoutes(app, params, c) {
 		return
 	}
 
-	var defaultHostname string
-	if params.Host != nil {
-		defaultHostname = *params.Host
-	} else {
-		defaultHostname = hostNameForString(app.Name)
+	hostNames := cmd.hostnames(c, app, params)
+	domains := cmd.domains(c, params)
+
+	listedRouteGuids := map[string]bool{}
+	for _, domain := range domains {
+		for _, hostName := range hostNames {
+			route := cmd.route(hostName, domain)
+			listedRouteGuids[route.Guid] = true
+			cmd.bindRoute(app, route, hostName, domain)
+		}
 	}
 
-	var domainName string
-	if params.Domain != nil {
-		domainName = *params.Domain
-	} else {
-		domainName = c.String("d")
+	if c.Bool("unmap-unlisted-routes") {
+		cmd.unmapUnlistedRoutes(app, listedRouteGuids)
 	}
+}
 
-	hostName := cmd.hostname(c, defaultHostname)
-	domain := cmd.domain(c, domainName)
-	route := cmd.route(hostName, domain)
+// keepsExistingRoutes is true when an app that has routes is pushed without asking for any.
+func keepsExistingRoutes(app models.Application, params models.AppParams, c *cli.Context) bool {
+	routeFlagsPresent := len(c.StringSlice("n")) > 0 || len(c.StringSlice("d")) > 0 || c.Bool("no-hostname")
+	routeListsPresent := params.Hosts != nil || params.Domains != nil
+	return len(app.Routes) > 0 && !routeFlagsPresent && !routeListsPresent
+}
 
+func (cmd *Push) bindRoute(app models.Application, route models.Route, hostName string, domain models.DomainFields) {
 	for _, boundRoute := range app.Routes {
 		if boundRoute.Guid == route.Guid {
 			return
@@ -185,6 +413,25 @@ func (cmd *Push) bindAppToRoute(app models.Application, params models.AppParams,
 	cmd.ui.Say("")
 }
 
+func (cmd *Push) unmapUnlistedRoutes(app models.Application, listedRouteGuids map[string]bool) {
+	for _, boundRoute := range app.Routes {
+		if listedRouteGuids[boundRoute.Guid] {
+			continue
+		}
+
+		cmd.ui.Say("Unbinding %s from %s...", terminal.EntityNameColor(boundRoute.URL()), terminal.EntityNameColor(app.Name))
+
+		apiResponse := cmd.routeRepo.Unbind(boundRoute.Guid, app.Guid)
+		if apiResponse.IsNotSuccessful() {
+			cmd.ui.Failed(apiResponse.Message)
+			return
+		}
+
+		cmd.ui.Ok()
+		cmd.ui.Say("")
+	}
+}
+
 var forbiddenHostCharRegex = regexp.MustCompile("[^a-z0-9-]")
 var whitespaceRegex = regexp.MustCompile(`[\s_]+`)
 
@@ -234,6 +481,31 @@ func (cmd *Push) route(hostName string, domain models.DomainFields) (route model
 	return
 }
 
+func (cmd *Push) domains(c *cli.Context, params models.AppParams) (domains []models.DomainFields) {
+	// domains from the manifest win over -d, as the single domain always did, while -n wins over
+	// the hosts from the manifest
+	domainNames := []string{}
+	if params.Domain != nil {
+		domainNames = append(domainNames, *params.Domain)
+	}
+	if params.Domains != nil {
+		domainNames = append(domainNames, *params.Domains...)
+	}
+	if len(domainNames) == 0 {
+		domainNames = c.StringSlice("d")
+	}
+
+	if len(domainNames) == 0 {
+		domains = []models.DomainFields{cmd.domain(c, "")}
+		return
+	}
+
+	for _, domainName := range uniqueStrings(domainNames) {
+		domains = append(domains, cmd.domain(c, domainName))
+	}
+	return
+}
+
 func (cmd *Push) domain(c *cli.Context, domainName string) (domain models.DomainFields) {
 	var apiResponse net.ApiResponse
 
@@ -285,16 +557,39 @@ func (cmd *Push) findDefaultDomain() (domain models.DomainFields, err error) {
 	return
 }
 
-func (cmd *Push) hostname(c *cli.Context, defaultName string) (hostName string) {
+func (cmd *Push) hostnames(c *cli.Context, app models.Application, params models.AppParams) (hostNames []string) {
 	if c.Bool("no-hostname") {
+		hostNames = []string{""}
 		return
 	}
 
-	hostName = c.String("n")
-	if hostName == "" {
-		hostName = defaultName
+	hostNames = c.StringSlice("n")
+	if len(hostNames) == 0 {
+		if params.Host != nil {
+			hostNames = append(hostNames, *params.Host)
+		}
+		if params.Hosts != nil {
+			hostNames = append(hostNames, *params.Hosts...)
+		}
+	}
+
+	if len(hostNames) == 0 {
+		hostNames = []string{hostNameForString(app.Name)}
 	}
 
+	hostNames = uniqueStrings(hostNames)
+	return
+}
+
+func uniqueStrings(values []string) (unique []string) {
+	seen := map[string]bool{}
+	for _, value := range values {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
 	return
 }
 
@@ -359,11 +654,8 @@ func (cmd *Push) updateApp(app models.Application, appParams models.AppParams) (
 	)
 
 	if appParams.EnvironmentVars != nil {
-		for key, val := range app.EnvironmentVars {
-			if _, ok := (*appParams.EnvironmentVars)[key]; !ok {
-				(*appParams.EnvironmentVars)[key] = val
-			}
-		}
+		envVars := environmentVarsAfterPush(app.EnvironmentVars, appParams)
+		appParams.EnvironmentVars = &envVars
 	}
 
 	var apiResponse net.ApiResponse
@@ -379,6 +671,21 @@ func (cmd *Push) updateApp(app models.Application, appParams models.AppParams) (
 	return
 }
 
+// environmentVarsAfterPush returns the env vars an existing app has once it is updated with
+// params. Variables that params do not mention are kept.
+func environmentVarsAfterPush(existing map[string]string, params models.AppParams) (envVars map[string]string) {
+	envVars = map[string]string{}
+	for key, value := range existing {
+		envVars[key] = value
+	}
+	if params.EnvironmentVars != nil {
+		for key, value := range *params.EnvironmentVars {
+			envVars[key] = value
+		}
+	}
+	return
+}
+
 func (cmd *Push) findAndValidateAppsToPush(c *cli.Context) (appSet []models.AppParams) {
 	m := cmd.instantiateManifest(c)
 
@@ -388,6 +695,17 @@ func (cmd *Push) findAndValidateAppsToPush(c *cli.Context) (appSet []models.AppP
 		return
 	}
 
+	strategy := c.String("strategy")
+	if strategy != "" && strategy != blueGreenStrategy {
+		cmd.ui.Failed("Invalid strategy %s, the only supported strategy is %s", strategy, blueGreenStrategy)
+		return
+	}
+
+	if strategy == blueGreenStrategy && c.Bool("no-start") {
+		cmd.ui.Failed("--no-start cannot be used with --strategy %s", blueGreenStrategy)
+		return
+	}
+
 	if contextParams.Name == nil && len(m.Applications) > 1 && !contextParams.Equals(&models.AppParams{}) {
 		cmd.ui.Failed("%s", "Incorrect Usage. Command line flags (except -f) cannot be applied when pushing multiple apps from a manifest file.")
 		return
@@ -419,7 +737,13 @@ func (cmd *Push) instantiateManifest(c *cli.Context) (m *manifest.Manifest) {
 		}
 	}
 
-	m, manifestPath, errs := cmd.manifestRepo.ReadManifest(path)
+	vars, err := manifestVariablesFromContext(c)
+	if err != nil {
+		cmd.ui.Failed("Error reading manifest variables:\n%s", err)
+		return
+	}
+
+	m, manifestPath, errs := cmd.manifestRepo.ReadManifest(path, vars)
 
 	if !errs.Empty() {
 		if manifestPath == "" && c.String("f") == "" {
@@ -430,7 +754,31 @@ func (cmd *Push) instantiateManifest(c *cli.Context) (m *manifest.Manifest) {
 		return
 	}
 
-	cmd.ui.Say("Using manifest file %s\n", terminal.EntityNameColor(manifestPath))
+	if !printsPlanAsJSON(c) {
+		cmd.ui.Say("Using manifest file %s\n", terminal.EntityNameColor(manifestPath))
+	}
+	return
+}
+
+func manifestVariablesFromContext(c *cli.Context) (vars manifest.Variables, err error) {
+	vars = manifest.NewVariables()
+
+	for _, varsFile := range c.StringSlice("vars-file") {
+		var fileVars manifest.Variables
+		fileVars, err = manifest.ReadVariablesFile(varsFile)
+		if err != nil {
+			return
+		}
+		vars.Merge(fileVars)
+	}
+
+	for _, assignment := range c.StringSlice("var") {
+		err = vars.ParseVariable(assignment)
+		if err != nil {
+			return
+		}
+	}
+
 	return
 }
 
@@ -546,7 +894,10 @@ func newAppParamsFromContext(c *cli.Context) (appParams models.AppParams, err er
 		appParams.HealthCheckTimeout = &timeout
 	}
 
-	if c.String("p") != "" {
+	if cf.IsRemoteAppSource(c.String("p")) {
+		path := c.String("p")
+		appParams.Path = &path
+	} else if c.String("p") != "" {
 		var path string
 		path, err = filepath.Abs(c.String("p"))
 		if err != nil {
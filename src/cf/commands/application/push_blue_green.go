@@ -0,0 +1,251 @@
+package application
+
+import (
+	"cf"
+	"cf/commands/service"
+	"cf/models"
+	"cf/terminal"
+	"github.com/codegangsta/cli"
+	"sync"
+)
+
+const (
+	blueGreenStrategy     = "blue-green"
+	blueGreenNewAppSuffix = "-green"
+)
+
+type blueGreenDeployment struct {
+	liveApp       models.Application
+	liveServices  []models.ServiceInstanceFields
+	newApp        models.Application
+	unboundRoutes []models.RouteSummary
+	liveAppGone   bool
+}
+
+// pushBlueGreen pushes and starts the app under a temporary name next to the live app, moves the
+// live app's routes over once the new app is running, deletes the live app and renames the new
+// one. The live app is left as it was if anything fails before it is deleted.
+func (cmd *Push) pushBlueGreen(appParams models.AppParams, c *cli.Context) {
+	liveApp, apiResponse := cmd.appRepo.Read(*appParams.Name)
+	if apiResponse.IsNotFound() {
+		cmd.ui.Say("App %s does not exist yet, pushing it without %s", terminal.EntityNameColor(*appParams.Name), blueGreenStrategy)
+		cmd.pushAndRestart(appParams, c)
+		return
+	}
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	liveSummary, apiResponse := cmd.appSummaryRepo.GetSummary(liveApp.Guid)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	deployment := &blueGreenDeployment{liveApp: liveApp, liveServices: liveSummary.Services}
+	newAppParams := blueGreenAppParams(liveApp, appParams)
+
+	// the new app must not take over an app that happens to have its temporary name, since that
+	// app would be overwritten and then renamed or deleted
+	_, apiResponse = cmd.appRepo.Read(*newAppParams.Name)
+	if apiResponse.IsSuccessful() {
+		cmd.ui.Failed("App %s already exists, rename or delete it before pushing %s with %s",
+			*newAppParams.Name, liveApp.Name, blueGreenStrategy)
+		return
+	}
+	if apiResponse.IsError() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	err := cmd.tryWithPrefixedUI("", &sync.Mutex{}, func(appCmd *Push) {
+		appCmd.deployNewApp(deployment, newAppParams, c)
+		appCmd.retireLiveApp(deployment)
+	})
+
+	if err != nil {
+		if deployment.liveAppGone {
+			cmd.ui.Failed("The new version of %s is running as %s, rename it with '%s rename'\n%s",
+				liveApp.Name, deployment.newApp.Name, cf.Name(), err.Error())
+			return
+		}
+
+		cmd.rollBackBlueGreen(deployment)
+		cmd.ui.Failed("Could not push %s, the live app was left unchanged\n%s", liveApp.Name, err.Error())
+		return
+	}
+
+	cmd.ui.Ok()
+	cmd.ui.Say("")
+}
+
+func blueGreenAppParams(liveApp models.Application, appParams models.AppParams) (params models.AppParams) {
+	params.Memory = &liveApp.Memory
+	params.InstanceCount = &liveApp.InstanceCount
+	if liveApp.DiskQuota != 0 {
+		params.DiskQuota = &liveApp.DiskQuota
+	}
+	if liveApp.BuildpackUrl != "" {
+		params.BuildpackUrl = &liveApp.BuildpackUrl
+	}
+	if liveApp.Command != "" {
+		params.Command = &liveApp.Command
+	}
+	if liveApp.Stack.Guid != "" {
+		params.StackGuid = &liveApp.Stack.Guid
+	}
+
+	envVars := environmentVarsAfterPush(liveApp.EnvironmentVars, appParams)
+	params.Merge(&appParams)
+	params.EnvironmentVars = &envVars
+
+	newName := liveApp.Name + blueGreenNewAppSuffix
+	params.Name = &newName
+	return
+}
+
+func (cmd *Push) deployNewApp(deployment *blueGreenDeployment, params models.AppParams, c *cli.Context) {
+	cmd.ui.Say("Pushing new version of %s as %s...",
+		terminal.EntityNameColor(deployment.liveApp.Name),
+		terminal.EntityNameColor(*params.Name),
+	)
+	cmd.ui.Say("")
+
+	cmd.fetchStackGuid(&params)
+	deployment.newApp, _ = cmd.createApp(params)
+
+	cmd.ui.Say("Uploading %s...", terminal.EntityNameColor(deployment.newApp.Name))
+
+	progressBar := cmd.ui.ProgressBar()
+	apiResponse := cmd.appBitsRepo.UploadApp(deployment.newApp.Guid, *params.Path, ignoreFiles(c), cmd.describeUploadOperation, progressBar.Update)
+	progressBar.Done()
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed("Error uploading application.\n%s", apiResponse.Message)
+		return
+	}
+	cmd.ui.Ok()
+
+	if params.ServiceInstances != nil {
+		cmd.createServiceInstances(*params.ServiceInstances)
+	}
+
+	if params.Services != nil {
+		cmd.bindAppToServices(*params.Services, deployment.newApp)
+	}
+	cmd.bindLiveServices(deployment, params)
+
+	cmd.restart(deployment.newApp, params, c)
+
+	for _, route := range deployment.liveApp.Routes {
+		cmd.ui.Say("Binding %s to %s...", terminal.EntityNameColor(route.URL()), terminal.EntityNameColor(deployment.newApp.Name))
+
+		apiResponse = cmd.routeRepo.Bind(route.Guid, deployment.newApp.Guid)
+		if apiResponse.IsNotSuccessful() {
+			cmd.ui.Failed(apiResponse.Message)
+			return
+		}
+
+		cmd.ui.Ok()
+		cmd.ui.Say("")
+	}
+
+	// routes from flags and the manifest are worked out as if the app still had its live name
+	newAppAsLive := deployment.newApp
+	newAppAsLive.Name = deployment.liveApp.Name
+	newAppAsLive.Routes = deployment.liveApp.Routes
+	cmd.bindAppToRoute(newAppAsLive, params, c)
+}
+
+// bindLiveServices binds the new app to the services of the live app that the manifest and flags
+// did not bind it to already, so that it runs with the same VCAP_SERVICES.
+func (cmd *Push) bindLiveServices(deployment *blueGreenDeployment, params models.AppParams) {
+	alreadyBound := map[string]bool{}
+	if params.Services != nil {
+		for _, name := range *params.Services {
+			alreadyBound[name] = true
+		}
+	}
+
+	for _, fields := range deployment.liveServices {
+		if alreadyBound[fields.Name] {
+			continue
+		}
+
+		cmd.ui.Say("Binding service %s of %s to %s...",
+			terminal.EntityNameColor(fields.Name),
+			terminal.EntityNameColor(deployment.liveApp.Name),
+			terminal.EntityNameColor(deployment.newApp.Name),
+		)
+
+		instance := models.ServiceInstance{}
+		instance.ServiceInstanceFields = fields
+		apiResponse := cmd.binder.BindApplication(deployment.newApp, instance)
+		if apiResponse.IsNotSuccessful() && apiResponse.ErrorCode != service.AppAlreadyBoundErrorCode {
+			cmd.ui.Failed("Could not bind to service %s\n%s", fields.Name, apiResponse.Message)
+			return
+		}
+
+		cmd.ui.Ok()
+	}
+}
+
+func (cmd *Push) retireLiveApp(deployment *blueGreenDeployment) {
+	liveApp := deployment.liveApp
+
+	for _, route := range liveApp.Routes {
+		cmd.ui.Say("Unbinding %s from %s...", terminal.EntityNameColor(route.URL()), terminal.EntityNameColor(liveApp.Name))
+
+		apiResponse := cmd.routeRepo.Unbind(route.Guid, liveApp.Guid)
+		if apiResponse.IsNotSuccessful() {
+			cmd.ui.Failed(apiResponse.Message)
+			return
+		}
+		deployment.unboundRoutes = append(deployment.unboundRoutes, route)
+
+		cmd.ui.Ok()
+		cmd.ui.Say("")
+	}
+
+	cmd.ui.Say("Deleting old version of %s...", terminal.EntityNameColor(liveApp.Name))
+
+	apiResponse := cmd.appRepo.Delete(liveApp.Guid)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+	deployment.liveAppGone = true
+
+	cmd.ui.Ok()
+	cmd.ui.Say("")
+
+	cmd.ui.Say("Renaming %s to %s...", terminal.EntityNameColor(deployment.newApp.Name), terminal.EntityNameColor(liveApp.Name))
+
+	name := liveApp.Name
+	_, apiResponse = cmd.appRepo.Update(deployment.newApp.Guid, models.AppParams{Name: &name})
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+}
+
+func (cmd *Push) rollBackBlueGreen(deployment *blueGreenDeployment) {
+	cmd.ui.Say("")
+	cmd.ui.Say("Rolling back push of %s...", terminal.EntityNameColor(deployment.liveApp.Name))
+
+	for _, route := range deployment.unboundRoutes {
+		apiResponse := cmd.routeRepo.Bind(route.Guid, deployment.liveApp.Guid)
+		if apiResponse.IsNotSuccessful() {
+			cmd.ui.Warn("Could not bind %s back to %s: %s", route.URL(), deployment.liveApp.Name, apiResponse.Message)
+		}
+	}
+
+	if deployment.newApp.Guid != "" {
+		apiResponse := cmd.appRepo.Delete(deployment.newApp.Guid)
+		if apiResponse.IsNotSuccessful() {
+			cmd.ui.Warn("Could not delete %s: %s", deployment.newApp.Name, apiResponse.Message)
+		}
+	}
+
+	cmd.ui.Say("Rolled back, %s is still serving its routes", terminal.EntityNameColor(deployment.liveApp.Name))
+}
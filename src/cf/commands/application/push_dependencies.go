@@ -0,0 +1,108 @@
+package application
+
+import (
+	"cf/models"
+)
+
+type appPushResult struct {
+	index int
+	err   error
+}
+
+// pushInDependencyOrder calls pushApp for each app once every app it depends on has been pushed,
+// running at most parallelism calls at the same time. With a parallelism of one, pushApp is called
+// on the calling goroutine. Apps that depend on an app that failed, directly or through other
+// apps, are not pushed. Dependencies on apps outside of appSet are taken to be pushed already.
+func pushInDependencyOrder(appSet []models.AppParams, parallelism int, pushApp func(models.AppParams) error) (failed, skipped []string) {
+	indexesByName := map[string]int{}
+	for index, app := range appSet {
+		if app.Name != nil {
+			indexesByName[*app.Name] = index
+		}
+	}
+
+	const (
+		pending = iota
+		running
+		pushed
+		notPushed
+	)
+	states := make([]int, len(appSet))
+	results := make(chan appPushResult, len(appSet))
+	runningCount := 0
+
+	readiness := func(app models.AppParams) (ready, blocked bool) {
+		ready = true
+		if app.DependsOn == nil {
+			return
+		}
+		for _, dependency := range *app.DependsOn {
+			index, found := indexesByName[dependency]
+			if !found {
+				continue
+			}
+			switch states[index] {
+			case notPushed:
+				blocked = true
+				return
+			case pending, running:
+				ready = false
+			}
+		}
+		return
+	}
+
+	for {
+		for changed := true; changed; {
+			changed = false
+			for index, app := range appSet {
+				if states[index] != pending {
+					continue
+				}
+
+				ready, blocked := readiness(app)
+				if blocked {
+					states[index] = notPushed
+					skipped = append(skipped, appName(app))
+					changed = true
+					continue
+				}
+				if !ready || runningCount >= parallelism {
+					continue
+				}
+
+				states[index] = running
+				runningCount++
+				changed = true
+
+				if parallelism == 1 {
+					results <- appPushResult{index: index, err: pushApp(app)}
+				} else {
+					go func(index int, app models.AppParams) {
+						results <- appPushResult{index: index, err: pushApp(app)}
+					}(index, app)
+				}
+			}
+		}
+
+		if runningCount == 0 {
+			return
+		}
+
+		result := <-results
+		runningCount--
+		if result.err != nil {
+			states[result.index] = notPushed
+			failed = append(failed, appName(appSet[result.index]))
+		} else {
+			states[result.index] = pushed
+		}
+	}
+}
+
+func appName(app models.AppParams) string {
+	if app.Name == nil {
+		return ""
+	}
+	return *app.Name
+}
@@ -0,0 +1,258 @@
+package application
+
+import (
+	"cf/formatters"
+	"cf/models"
+	"cf/terminal"
+	"encoding/json"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"sort"
+)
+
+type appPlan struct {
+	Name             string            `json:"name"`
+	Action           string            `json:"action"`
+	Changes          []attributeChange `json:"changes"`
+	EnvAdded         []string          `json:"env_added"`
+	EnvChanged       []string          `json:"env_changed"`
+	EnvRemoved       []string          `json:"env_removed"`
+	RoutesToCreate   []string          `json:"routes_to_create"`
+	RoutesToBind     []string          `json:"routes_to_bind"`
+	RoutesToUnbind   []string          `json:"routes_to_unbind"`
+	ServicesToCreate []string          `json:"services_to_create"`
+	ServicesToBind   []string          `json:"services_to_bind"`
+	Upload           uploadPlan        `json:"upload"`
+}
+
+type attributeChange struct {
+	Attribute string `json:"attribute"`
+	Current   string `json:"current"`
+	Planned   string `json:"planned"`
+}
+
+type uploadPlan struct {
+	FileCount int   `json:"file_count"`
+	Bytes     int64 `json:"bytes"`
+}
+
+func printsPlanAsJSON(c *cli.Context) bool {
+	return c.Bool("plan") && c.Bool("json")
+}
+
+// showPlan prints what pushing appSet would change, making only requests that read state.
+func (cmd *Push) showPlan(appSet []models.AppParams, c *cli.Context) {
+	plans := []appPlan{}
+	for _, appParams := range appSet {
+		plans = append(plans, cmd.planApp(appParams, c))
+	}
+
+	if printsPlanAsJSON(c) {
+		output, err := json.MarshalIndent(plans, "", "  ")
+		if err != nil {
+			cmd.ui.Failed(err.Error())
+			return
+		}
+		cmd.ui.Say("%s", output)
+		return
+	}
+
+	for _, plan := range plans {
+		cmd.printPlan(plan)
+	}
+}
+
+func (cmd *Push) planApp(appParams models.AppParams, c *cli.Context) (plan appPlan) {
+	plan.Name = *appParams.Name
+	plan.Action = "update"
+
+	app, apiResponse := cmd.appRepo.Read(*appParams.Name)
+	if apiResponse.IsNotFound() {
+		plan.Action = "create"
+		app = models.Application{}
+		app.Name = *appParams.Name
+	} else if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	plan.Changes = appAttributeChanges(app, appParams)
+	plan.EnvAdded, plan.EnvChanged, plan.EnvRemoved = envVarChanges(app.EnvironmentVars, environmentVarsAfterPush(app.EnvironmentVars, appParams))
+	plan.RoutesToCreate, plan.RoutesToBind, plan.RoutesToUnbind = cmd.planRoutes(app, appParams, c)
+	plan.ServicesToCreate, plan.ServicesToBind = cmd.planServices(appParams)
+
+	files, apiResponse := cmd.appBitsRepo.FilesToUpload(*appParams.Path, ignoreFiles(c))
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed("Error finding files to upload.\n%s", apiResponse.Message)
+		return
+	}
+	plan.Upload.FileCount = len(files)
+	for _, file := range files {
+		plan.Upload.Bytes += file.Size
+	}
+
+	return
+}
+
+func appAttributeChanges(app models.Application, params models.AppParams) (changes []attributeChange) {
+	changes = []attributeChange{}
+	addChange := func(attribute, current, planned string) {
+		if current != planned {
+			changes = append(changes, attributeChange{Attribute: attribute, Current: current, Planned: planned})
+		}
+	}
+	megabytes := func(value uint64) string {
+		if value == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%dM", value)
+	}
+
+	if params.Memory != nil {
+		addChange("memory", megabytes(app.Memory), megabytes(*params.Memory))
+	}
+	if params.DiskQuota != nil {
+		addChange("disk_quota", megabytes(app.DiskQuota), megabytes(*params.DiskQuota))
+	}
+	if params.InstanceCount != nil {
+		current := ""
+		if app.Guid != "" {
+			current = fmt.Sprintf("%d", app.InstanceCount)
+		}
+		addChange("instances", current, fmt.Sprintf("%d", *params.InstanceCount))
+	}
+	if params.Command != nil {
+		addChange("command", app.Command, *params.Command)
+	}
+	if params.BuildpackUrl != nil {
+		addChange("buildpack", app.BuildpackUrl, *params.BuildpackUrl)
+	}
+	return
+}
+
+func envVarChanges(current, planned map[string]string) (added, changed, removed []string) {
+	added, changed, removed = []string{}, []string{}, []string{}
+	for _, key := range sortedMapKeys(planned) {
+		currentValue, found := current[key]
+		if !found {
+			added = append(added, key)
+		} else if currentValue != planned[key] {
+			changed = append(changed, key)
+		}
+	}
+	for _, key := range sortedMapKeys(current) {
+		if _, found := planned[key]; !found {
+			removed = append(removed, key)
+		}
+	}
+	return
+}
+
+func sortedMapKeys(values map[string]string) (keys []string) {
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
+
+func (cmd *Push) planRoutes(app models.Application, params models.AppParams, c *cli.Context) (toCreate, toBind, toUnbind []string) {
+	toCreate, toBind, toUnbind = []string{}, []string{}, []string{}
+
+	if c.Bool("no-route") || (params.NoRoute != nil && *params.NoRoute) || keepsExistingRoutes(app, params, c) {
+		return
+	}
+
+	listedRouteGuids := map[string]bool{}
+	for _, domain := range cmd.domains(c, params) {
+		for _, hostName := range cmd.hostnames(c, app, params) {
+			url := domain.UrlForHost(hostName)
+
+			route, apiResponse := cmd.routeRepo.FindByHostAndDomain(hostName, domain.Name)
+			if apiResponse.IsNotSuccessful() {
+				toCreate = append(toCreate, url)
+				toBind = append(toBind, url)
+				continue
+			}
+
+			listedRouteGuids[route.Guid] = true
+			if !appHasRoute(app, route.Guid) {
+				toBind = append(toBind, url)
+			}
+		}
+	}
+
+	if c.Bool("unmap-unlisted-routes") {
+		for _, route := range app.Routes {
+			if !listedRouteGuids[route.Guid] {
+				toUnbind = append(toUnbind, route.URL())
+			}
+		}
+	}
+	return
+}
+
+func appHasRoute(app models.Application, routeGuid string) bool {
+	for _, route := range app.Routes {
+		if route.Guid == routeGuid {
+			return true
+		}
+	}
+	return false
+}
+
+func (cmd *Push) planServices(params models.AppParams) (toCreate, toBind []string) {
+	toCreate, toBind = []string{}, []string{}
+
+	if params.ServiceInstances != nil {
+		for _, instance := range *params.ServiceInstances {
+			_, apiResponse := cmd.serviceRepo.FindInstanceByName(instance.Name)
+			if apiResponse.IsNotFound() {
+				toCreate = append(toCreate, instance.Name)
+			}
+		}
+	}
+
+	if params.Services != nil {
+		toBind = append(toBind, *params.Services...)
+	}
+	return
+}
+
+func (cmd *Push) printPlan(plan appPlan) {
+	cmd.ui.Say("Plan for app %s (%s):", terminal.EntityNameColor(plan.Name), plan.Action)
+
+	for _, change := range plan.Changes {
+		current := change.Current
+		if current == "" {
+			current = "(none)"
+		}
+		cmd.ui.Say("  %s: %s -> %s", change.Attribute, current, change.Planned)
+	}
+	for _, key := range plan.EnvAdded {
+		cmd.ui.Say("  env: + %s", key)
+	}
+	for _, key := range plan.EnvChanged {
+		cmd.ui.Say("  env: ~ %s", key)
+	}
+	for _, key := range plan.EnvRemoved {
+		cmd.ui.Say("  env: - %s", key)
+	}
+	for _, url := range plan.RoutesToCreate {
+		cmd.ui.Say("  route: create %s", url)
+	}
+	for _, url := range plan.RoutesToBind {
+		cmd.ui.Say("  route: bind %s", url)
+	}
+	for _, url := range plan.RoutesToUnbind {
+		cmd.ui.Say("  route: unbind %s", url)
+	}
+	for _, name := range plan.ServicesToCreate {
+		cmd.ui.Say("  service: create %s", name)
+	}
+	for _, name := range plan.ServicesToBind {
+		cmd.ui.Say("  service: bind %s", name)
+	}
+	cmd.ui.Say("  upload: %d files, %s", plan.Upload.FileCount, formatters.ByteSize(uint64(plan.Upload.Bytes)))
+	cmd.ui.Say("")
+}
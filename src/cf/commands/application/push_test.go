@@ -1,16 +1,21 @@
 package application_test
 
 import (
+	"cf"
 	. "cf/commands/application"
 	"cf/manifest"
 	"cf/models"
 	"cf/net"
+	"encoding/json"
 	"errors"
 	"generic"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"syscall"
 	testapi "testhelpers/api"
 	testassert "testhelpers/assert"
@@ -20,6 +25,7 @@ import (
 	testmanifest "testhelpers/manifest"
 	testreq "testhelpers/requirements"
 	testterm "testhelpers/terminal"
+	"time"
 )
 
 var _ = Describe("Push Command", func() {
@@ -37,8 +43,9 @@ var _ = Describe("Push Command", func() {
 		stackRepo := deps.stackRepo
 		appBitsRepo := deps.appBitsRepo
 		serviceRepo := deps.serviceRepo
+		userProvidedServiceInstanceRepo := deps.userProvidedServiceInstanceRepo
 
-		cmd := NewPush(ui, configRepo, manifestRepo, starter, stopper, binder, appRepo, domainRepo, routeRepo, stackRepo, serviceRepo, appBitsRepo)
+		cmd := NewPush(ui, configRepo, manifestRepo, starter, stopper, binder, appRepo, domainRepo, routeRepo, stackRepo, serviceRepo, userProvidedServiceInstanceRepo, appBitsRepo, &testapi.FakeDropletStashRepository{}, deps.appSummaryRepo)
 		ctxt := testcmd.NewContext("push", []string{})
 
 		reqFactory := &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true}
@@ -452,6 +459,80 @@ var _ = Describe("Push Command", func() {
 		})
 	})
 
+	It("TestPushingCreatesMissingServiceInstancesFromTheManifest", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+
+		plan := models.ServicePlanFields{Name: "spark", Guid: "spark-plan-guid"}
+		offering := models.ServiceOffering{}
+		offering.Label = "cleardb"
+		offering.Plans = []models.ServicePlanFields{plan}
+		deps.serviceRepo.GetAllServiceOfferingsReturns.ServiceOfferings = []models.ServiceOffering{offering}
+
+		deps.serviceRepo.FindInstanceByNameResponses = []net.ApiResponse{
+			net.NewNotFoundApiResponse("Service instance review-db not found"),
+			net.NewNotFoundApiResponse("Service instance review-creds not found"),
+		}
+
+		deps.manifestRepo.ReadManifestReturns.Manifest = manifestWithServiceInstances()
+
+		ui := callPush([]string{}, deps)
+
+		Expect(deps.serviceRepo.CreateServiceInstanceName).To(Equal("review-db"))
+		Expect(deps.serviceRepo.CreateServiceInstancePlanGuid).To(Equal("spark-plan-guid"))
+
+		Expect(deps.userProvidedServiceInstanceRepo.CreateName).To(Equal("review-creds"))
+		Expect(deps.userProvidedServiceInstanceRepo.CreateDrainUrl).To(Equal("syslog://example.com"))
+		Expect(deps.userProvidedServiceInstanceRepo.CreateParams).To(Equal(map[string]string{"password": "secret"}))
+
+		Expect(len(deps.binder.InstancesToBindTo)).To(Equal(2))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Creating service", "review-db", "my-org", "my-space", "my-user"},
+			{"OK"},
+			{"Creating service", "review-creds"},
+			{"OK"},
+			{"Binding service", "review-db", "review-app"},
+			{"Binding service", "review-creds", "review-app"},
+		})
+	})
+
+	It("TestPushingReusesExistingServiceInstancesFromTheManifest", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+		deps.manifestRepo.ReadManifestReturns.Manifest = manifestWithServiceInstances()
+
+		ui := callPush([]string{}, deps)
+
+		Expect(deps.serviceRepo.CreateServiceInstanceName).To(Equal(""))
+		Expect(deps.userProvidedServiceInstanceRepo.CreateName).To(Equal(""))
+		Expect(len(deps.binder.InstancesToBindTo)).To(Equal(2))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Using service", "review-db"},
+			{"Using service", "review-creds"},
+		})
+		testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{
+			{"Creating service"},
+		})
+	})
+
+	It("TestPushingFailsWhenTheServiceOfferingForAServiceInstanceDoesNotExist", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+		deps.serviceRepo.FindInstanceByNameNotFound = true
+		deps.manifestRepo.ReadManifestReturns.Manifest = manifestWithServiceInstances()
+
+		ui := callPush([]string{}, deps)
+
+		Expect(deps.serviceRepo.CreateServiceInstanceName).To(Equal(""))
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"Could not create service review-db"},
+			{"Could not find offering with name cleardb"},
+		})
+	})
+
 	It("TestPushingAppWithPath", func() {
 		deps := getPushDependencies()
 		deps.appRepo.ReadNotFound = true
@@ -482,6 +563,17 @@ var _ = Describe("Push Command", func() {
 		Expect(deps.appBitsRepo.UploadedDir).To(Equal(absPath))
 	})
 
+	It("TestPushingAppWithPathToRemoteSource", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+
+		callPush([]string{"-p", "git+https://example.com/app.git#v1", "app-with-path"}, deps)
+		Expect(deps.appBitsRepo.UploadedDir).To(Equal("git+https://example.com/app.git#v1"))
+
+		callPush([]string{"-p", "https://example.com/app.zip", "app-with-path"}, deps)
+		Expect(deps.appBitsRepo.UploadedDir).To(Equal("https://example.com/app.zip"))
+	})
+
 	It("TestPushingWithDefaultAppPath", func() {
 		deps := getPushDependencies()
 		deps.appRepo.ReadNotFound = true
@@ -558,6 +650,35 @@ var _ = Describe("Push Command", func() {
 		Expect(deps.manifestRepo.ReadManifestArgs.Path).To(Equal(cwd))
 	})
 
+	It("TestPushingWithManifestVariables", func() {
+		deps := getPushDependencies()
+		deps.manifestRepo.ReadManifestReturns.Manifest = singleAppManifest()
+
+		callPush([]string{
+			"--vars-file", "../../../fixtures/manifests/vars.yml",
+			"--var", "host=from-flag",
+			"--var", "stack=my-stack",
+		}, deps)
+
+		Expect(deps.manifestRepo.ReadManifestArgs.Variables).To(Equal(manifest.Variables{
+			"instances": "2",
+			"host":      "from-flag",
+			"stack":     "my-stack",
+		}))
+	})
+
+	It("TestPushingWithInvalidManifestVariables", func() {
+		deps := getPushDependencies()
+
+		ui := callPush([]string{"--var", "not-an-assignment", "my-app"}, deps)
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"Error reading manifest variables"},
+			{"not-an-assignment"},
+		})
+	})
+
 	It("TestPushingWithNoManifestFlag", func() {
 		deps := getPushDependencies()
 		deps.appRepo.ReadNotFound = true
@@ -963,6 +1084,163 @@ var _ = Describe("Push Command", func() {
 		Expect(deps.routeRepo.BoundRouteGuid).To(Equal("existing-app-route-guid"))
 	})
 
+	It("TestPushingAppWithMultipleHostsAndDomains", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+		deps.routeRepo.FindByHostAndDomainNotFound = true
+		deps.domainRepo.FindByNameInOrgDomainsByName = map[string]models.DomainFields{
+			"example.com": models.DomainFields{Name: "example.com", Guid: "example-domain-guid"},
+			"example.org": models.DomainFields{Name: "example.org", Guid: "other-domain-guid"},
+		}
+
+		ui := callPush([]string{
+			"-n", "www",
+			"-n", "shop",
+			"-d", "example.com",
+			"-d", "example.org",
+			"my-new-app",
+		}, deps)
+
+		Expect(deps.routeRepo.CreatedHosts).To(Equal([]string{"www", "shop", "www", "shop"}))
+		Expect(deps.routeRepo.CreatedDomainGuids).To(Equal([]string{
+			"example-domain-guid", "example-domain-guid", "other-domain-guid", "other-domain-guid",
+		}))
+		Expect(len(deps.routeRepo.BoundRouteGuids)).To(Equal(4))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Binding", "www.example.com"},
+			{"Binding", "shop.example.com"},
+			{"Binding", "www.example.org"},
+			{"Binding", "shop.example.org"},
+		})
+	})
+
+	It("TestPushingAppWithHostsAndDomainsFromManifest", func() {
+		deps := getPushDependencies()
+
+		existingRoute := models.RouteSummary{}
+		existingRoute.Guid = "existing-route-guid"
+		existingRoute.Host = "existing-app"
+		existingRoute.Domain = models.DomainFields{Name: "example.com"}
+
+		existingApp := models.Application{}
+		existingApp.Name = "existing-app"
+		existingApp.Guid = "existing-app-guid"
+		existingApp.Routes = []models.RouteSummary{existingRoute}
+
+		deps.appRepo.ReadApp = existingApp
+		deps.appRepo.UpdateAppResult = existingApp
+		deps.routeRepo.FindByHostAndDomainNotFound = true
+		deps.domainRepo.FindByNameInOrgDomain = models.DomainFields{Name: "example.com", Guid: "example-domain-guid"}
+
+		name := "existing-app"
+		deps.manifestRepo.ReadManifestReturns.Manifest = &manifest.Manifest{
+			Applications: []models.AppParams{{
+				Name:    &name,
+				Hosts:   &[]string{"www", "shop"},
+				Domains: &[]string{"example.com"},
+			}},
+		}
+
+		callPush([]string{}, deps)
+
+		Expect(deps.domainRepo.FindByNameInOrgName).To(Equal("example.com"))
+		Expect(deps.routeRepo.CreatedHosts).To(Equal([]string{"www", "shop"}))
+		Expect(deps.routeRepo.CreatedDomainGuids).To(Equal([]string{"example-domain-guid", "example-domain-guid"}))
+		Expect(deps.routeRepo.BoundRouteGuids).To(Equal([]string{"www-route-guid", "shop-route-guid"}))
+		Expect(deps.routeRepo.UnboundRouteGuids).To(BeEmpty())
+	})
+
+	It("TestPushingAppPrefersTheManifestDomainsAndTheFlagHosts", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+		deps.routeRepo.FindByHostAndDomainNotFound = true
+		deps.domainRepo.FindByNameInOrgDomainsByName = map[string]models.DomainFields{
+			"manifest.example.com": models.DomainFields{Name: "manifest.example.com", Guid: "manifest-domain-guid"},
+			"flag.example.com":     models.DomainFields{Name: "flag.example.com", Guid: "flag-domain-guid"},
+		}
+
+		name := "my-new-app"
+		host := "manifest-host"
+		domain := "manifest.example.com"
+		deps.manifestRepo.ReadManifestReturns.Manifest = &manifest.Manifest{
+			Applications: []models.AppParams{{
+				Name:   &name,
+				Host:   &host,
+				Domain: &domain,
+			}},
+		}
+
+		callPush([]string{"-n", "flag-host", "-d", "flag.example.com"}, deps)
+
+		Expect(deps.routeRepo.CreatedHosts).To(Equal([]string{"flag-host"}))
+		Expect(deps.routeRepo.CreatedDomainGuids).To(Equal([]string{"manifest-domain-guid"}))
+	})
+
+	It("TestPushingAppUnmapsRoutesThatAreNoLongerListed", func() {
+		deps := getPushDependencies()
+
+		domain := models.DomainFields{Name: "example.com", Guid: "example-domain-guid"}
+
+		keptRoute := models.RouteSummary{}
+		keptRoute.Guid = "kept-route-guid"
+		keptRoute.Host = "www"
+		keptRoute.Domain = domain
+
+		oldRoute := models.RouteSummary{}
+		oldRoute.Guid = "old-route-guid"
+		oldRoute.Host = "old"
+		oldRoute.Domain = domain
+
+		existingApp := models.Application{}
+		existingApp.Name = "existing-app"
+		existingApp.Guid = "existing-app-guid"
+		existingApp.Routes = []models.RouteSummary{keptRoute, oldRoute}
+
+		foundRoute := models.Route{}
+		foundRoute.RouteFields = keptRoute.RouteFields
+		foundRoute.Domain = domain
+
+		deps.appRepo.ReadApp = existingApp
+		deps.appRepo.UpdateAppResult = existingApp
+		deps.routeRepo.FindByHostAndDomainRoute = foundRoute
+		deps.domainRepo.FindByNameInOrgDomain = domain
+
+		ui := callPush([]string{"-n", "www", "-d", "example.com", "--unmap-unlisted-routes", "existing-app"}, deps)
+
+		Expect(deps.routeRepo.BoundRouteGuids).To(BeEmpty())
+		Expect(deps.routeRepo.UnboundRouteGuids).To(Equal([]string{"old-route-guid"}))
+		Expect(deps.routeRepo.UnboundAppGuid).To(Equal("existing-app-guid"))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Unbinding", "old.example.com", "existing-app"},
+			{"OK"},
+		})
+	})
+
+	It("TestPushingAppKeepsUnlistedRoutesByDefault", func() {
+		deps := getPushDependencies()
+
+		oldRoute := models.RouteSummary{}
+		oldRoute.Guid = "old-route-guid"
+		oldRoute.Host = "old"
+		oldRoute.Domain = models.DomainFields{Name: "example.com"}
+
+		existingApp := models.Application{}
+		existingApp.Name = "existing-app"
+		existingApp.Guid = "existing-app-guid"
+		existingApp.Routes = []models.RouteSummary{oldRoute}
+
+		deps.appRepo.ReadApp = existingApp
+		deps.appRepo.UpdateAppResult = existingApp
+		deps.routeRepo.FindByHostAndDomainNotFound = true
+
+		callPush([]string{"-n", "www", "existing-app"}, deps)
+
+		Expect(deps.routeRepo.CreatedHosts).To(Equal([]string{"www"}))
+		Expect(deps.routeRepo.UnboundRouteGuids).To(BeEmpty())
+	})
+
 	It("TestPushingAppWithInvalidPath", func() {
 		deps := getPushDependencies()
 		deps.appBitsRepo.UploadAppErr = true
@@ -990,6 +1268,382 @@ var _ = Describe("Push Command", func() {
 		})
 	})
 
+	It("TestPushingAppReportsUploadProgress", func() {
+		deps := getPushDependencies()
+
+		deps.appRepo.ReadNotFound = true
+		deps.appBitsRepo.ProgressBytes = []int64{1024, 2048, 4096}
+
+		ui := callPush([]string{"appName"}, deps)
+		Expect(ui.ProgressBarUpdates).To(Equal([]int64{1024, 2048, 4096}))
+		Expect(ui.ProgressBarDone).To(BeTrue())
+	})
+
+	It("TestPushingAppsAfterTheAppsTheyDependOn", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+		deps.manifestRepo.ReadManifestReturns.Manifest = manifestWithDependencies(map[string][]string{
+			"web": {"api"},
+			"api": {"db-migrator"},
+		}, "web", "api", "db-migrator")
+
+		callPush([]string{"--no-route"}, deps)
+
+		Expect(len(deps.appRepo.CreateAppParams)).To(Equal(3))
+		Expect(*deps.appRepo.CreateAppParams[0].Name).To(Equal("db-migrator"))
+		Expect(*deps.appRepo.CreateAppParams[1].Name).To(Equal("api"))
+		Expect(*deps.appRepo.CreateAppParams[2].Name).To(Equal("web"))
+	})
+
+	It("TestPushingIndependentAppsInParallel", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+		deps.manifestRepo.ReadManifestReturns.Manifest = manifestWithDependencies(map[string][]string{
+			"web": {"api", "worker"},
+		}, "api", "worker", "web")
+
+		bothUploading := make(chan bool)
+		uploadsStarted := 0
+		lock := &sync.Mutex{}
+		deps.appBitsRepo.UploadAppHook = func(appGuid string) {
+			if appGuid == "web-guid" {
+				return
+			}
+
+			lock.Lock()
+			uploadsStarted++
+			if uploadsStarted == 2 {
+				close(bothUploading)
+			}
+			lock.Unlock()
+
+			select {
+			case <-bothUploading:
+			case <-time.After(5 * time.Second):
+			}
+		}
+
+		ui := callPush([]string{"--no-route", "--parallel", "2"}, deps)
+
+		Expect(uploadsStarted).To(Equal(2))
+		Expect(len(deps.appBitsRepo.UploadedAppGuids)).To(Equal(3))
+		Expect(deps.appBitsRepo.UploadedAppGuids[2]).To(Equal("web-guid"))
+		Expect(len(deps.starter.AppsStarted)).To(Equal(3))
+		Expect(len(deps.starter.UIs)).To(Equal(3))
+
+		for _, name := range []string{"api", "worker", "web"} {
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"[" + name + "] ", "Creating app", name},
+			})
+		}
+		testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+		})
+	})
+
+	It("TestPushingInParallelDoesNotPushAppsThatDependOnAFailedApp", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+		deps.appBitsRepo.UploadAppErrGuid = "api-guid"
+		deps.manifestRepo.ReadManifestReturns.Manifest = manifestWithDependencies(map[string][]string{
+			"web":   {"api"},
+			"admin": {"web"},
+		}, "api", "web", "admin", "worker")
+
+		ui := callPush([]string{"--no-route", "--parallel", "2"}, deps)
+
+		createdNames := []string{}
+		for _, params := range deps.appRepo.CreateAppParams {
+			createdNames = append(createdNames, *params.Name)
+		}
+		sort.Strings(createdNames)
+		Expect(createdNames).To(Equal([]string{"api", "worker"}))
+
+		Expect(len(deps.starter.AppsStarted)).To(Equal(1))
+		Expect(deps.starter.AppsStarted[0].Name).To(Equal("worker"))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"[api] ", "Error uploading application"},
+			{"FAILED"},
+			{"Could not push api"},
+			{"Did not push web, admin"},
+		})
+	})
+
+	It("TestPushingWithBlueGreenStrategy", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadAppsByName = map[string]models.Application{"my-app": liveAppWithRoute()}
+
+		ui := callPush([]string{"--strategy", "blue-green", "-p", "/foo/bar", "my-app"}, deps)
+
+		Expect(len(deps.appRepo.CreateAppParams)).To(Equal(1))
+		newAppParams := deps.appRepo.CreateAppParams[0]
+		Expect(*newAppParams.Name).To(Equal("my-app-green"))
+		Expect(*newAppParams.Memory).To(Equal(uint64(512)))
+		Expect(*newAppParams.InstanceCount).To(Equal(3))
+		Expect(*newAppParams.EnvironmentVars).To(Equal(map[string]string{"LIVE": "true"}))
+
+		Expect(deps.appBitsRepo.UploadedAppGuid).To(Equal("my-app-green-guid"))
+		Expect(deps.starter.AppToStart.Guid).To(Equal("my-app-green-guid"))
+
+		Expect(deps.routeRepo.BoundRouteGuids).To(Equal([]string{"live-route-guid"}))
+		Expect(deps.routeRepo.BoundAppGuid).To(Equal("my-app-green-guid"))
+		Expect(deps.routeRepo.UnboundRouteGuids).To(Equal([]string{"live-route-guid"}))
+		Expect(deps.routeRepo.UnboundAppGuid).To(Equal("my-app-guid"))
+
+		Expect(deps.appRepo.DeletedAppGuids).To(Equal([]string{"my-app-guid"}))
+		Expect(deps.appRepo.UpdateAppGuid).To(Equal("my-app-green-guid"))
+		Expect(*deps.appRepo.UpdateParams.Name).To(Equal("my-app"))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Pushing new version of", "my-app", "my-app-green"},
+			{"Binding", "live-host.example.com", "my-app-green"},
+			{"Unbinding", "live-host.example.com", "my-app"},
+			{"Deleting old version of", "my-app"},
+			{"Renaming", "my-app-green", "my-app"},
+		})
+		testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+		})
+	})
+
+	It("TestPushingWithBlueGreenStrategyCarriesOverTheServicesAndStackOfTheLiveApp", func() {
+		deps := getPushDependencies()
+		liveApp := liveAppWithRoute()
+		liveApp.Stack = models.Stack{Guid: "live-stack-guid", Name: "lucid64"}
+		deps.appRepo.ReadAppsByName = map[string]models.Application{"my-app": liveApp}
+
+		db := models.ServiceInstanceFields{Guid: "my-db-guid", Name: "my-db"}
+		queue := models.ServiceInstanceFields{Guid: "my-queue-guid", Name: "my-queue"}
+		deps.appSummaryRepo.GetSummarySummary = models.AppSummary{Services: []models.ServiceInstanceFields{db, queue}}
+
+		ui := callPush([]string{"--strategy", "blue-green", "-p", "/foo/bar", "my-app"}, deps)
+
+		Expect(deps.appSummaryRepo.GetSummaryAppGuid).To(Equal("my-app-guid"))
+		Expect(*deps.appRepo.CreateAppParams[0].StackGuid).To(Equal("live-stack-guid"))
+
+		Expect(len(deps.binder.InstancesToBindTo)).To(Equal(2))
+		Expect(deps.binder.InstancesToBindTo[0].Guid).To(Equal("my-db-guid"))
+		Expect(deps.binder.InstancesToBindTo[1].Guid).To(Equal("my-queue-guid"))
+		Expect(deps.binder.AppsToBind[0].Guid).To(Equal("my-app-green-guid"))
+		Expect(deps.binder.AppsToBind[1].Guid).To(Equal("my-app-green-guid"))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Binding service", "my-db", "my-app", "my-app-green"},
+			{"Binding service", "my-queue", "my-app", "my-app-green"},
+			{"Binding", "live-host.example.com", "my-app-green"},
+			{"Deleting old version of", "my-app"},
+		})
+		testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+		})
+	})
+
+	It("TestPushingWithBlueGreenStrategyRollsBackWhenTheNewAppFails", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadAppsByName = map[string]models.Application{"my-app": liveAppWithRoute()}
+		deps.appBitsRepo.UploadAppErr = true
+
+		ui := callPush([]string{"--strategy", "blue-green", "-p", "/foo/bar", "my-app"}, deps)
+
+		Expect(deps.appRepo.DeletedAppGuids).To(Equal([]string{"my-app-green-guid"}))
+		Expect(deps.routeRepo.BoundRouteGuids).To(BeEmpty())
+		Expect(deps.routeRepo.UnboundRouteGuids).To(BeEmpty())
+		Expect(deps.appRepo.UpdateAppGuid).To(Equal(""))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Error uploading application"},
+			{"Rolling back push of", "my-app"},
+			{"FAILED"},
+			{"Could not push my-app, the live app was left unchanged"},
+		})
+	})
+
+	It("TestPushingWithBlueGreenStrategyWhenAnAppHasTheTemporaryName", func() {
+		deps := getPushDependencies()
+		greenApp := maker.NewApp(maker.Overrides{"name": "my-app-green", "guid": "someone-elses-app-guid"})
+		deps.appRepo.ReadAppsByName = map[string]models.Application{
+			"my-app":       liveAppWithRoute(),
+			"my-app-green": greenApp,
+		}
+
+		ui := callPush([]string{"--strategy", "blue-green", "-p", "/foo/bar", "my-app"}, deps)
+
+		Expect(deps.appRepo.CreateAppParams).To(BeEmpty())
+		Expect(deps.appRepo.UpdateAppGuid).To(Equal(""))
+		Expect(deps.appRepo.DeletedAppGuids).To(BeEmpty())
+		Expect(deps.appBitsRepo.UploadedAppGuid).To(Equal(""))
+		Expect(deps.routeRepo.BoundRouteGuids).To(BeEmpty())
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"my-app-green already exists"},
+		})
+		testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{
+			{"Rolling back"},
+		})
+	})
+
+	It("TestPushingWithBlueGreenStrategyWhenTheAppDoesNotExist", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadAppsByName = map[string]models.Application{}
+
+		ui := callPush([]string{"--strategy", "blue-green", "-p", "/foo/bar", "my-app"}, deps)
+
+		Expect(len(deps.appRepo.CreateAppParams)).To(Equal(1))
+		Expect(*deps.appRepo.CreateAppParams[0].Name).To(Equal("my-app"))
+		Expect(deps.appRepo.DeletedAppGuids).To(BeEmpty())
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"my-app", "does not exist yet"},
+		})
+	})
+
+	It("TestPushingWithAnInvalidStrategy", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+
+		ui := callPush([]string{"--strategy", "red-black", "my-app"}, deps)
+
+		Expect(deps.appRepo.CreateAppParams).To(BeEmpty())
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"Invalid strategy red-black"},
+		})
+	})
+
+	It("TestPushingWithPlanShowsChangesWithoutMakingThem", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadApp = appToPlan()
+		deps.manifestRepo.ReadManifestReturns.Manifest = manifestToPlan()
+		deps.routeRepo.FindByHostAndDomainNotFound = true
+		deps.serviceRepo.FindInstanceByNameNotFound = true
+		deps.appBitsRepo.FilesToUploadFiles = []models.AppFileFields{{Path: "app.rb", Size: 1024}, {Path: "Gemfile", Size: 1024}}
+
+		ui := callPush([]string{"--plan"}, deps)
+
+		Expect(deps.appRepo.CreateAppParams).To(BeNil())
+		Expect(deps.appRepo.UpdateAppGuid).To(Equal(""))
+		Expect(deps.routeRepo.CreatedHosts).To(BeNil())
+		Expect(deps.routeRepo.BoundRouteGuids).To(BeNil())
+		Expect(deps.appBitsRepo.UploadedAppGuid).To(Equal(""))
+		Expect(deps.appBitsRepo.FilesToUploadDir).To(Equal("/some/path"))
+		Expect(deps.starter.AppToStart.Name).To(Equal(""))
+		Expect(deps.userProvidedServiceInstanceRepo.CreateName).To(Equal(""))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Plan for app", "my-app", "update"},
+			{"memory: 256M -> 512M"},
+			{"instances: 1 -> 3"},
+			{"env: + NEW_VAR"},
+			{"env: ~ CHANGED_VAR"},
+			{"route: create new-host.foo.cf-app.com"},
+			{"route: bind new-host.foo.cf-app.com"},
+			{"service: create my-creds"},
+			{"service: bind my-creds"},
+			{"upload: 2 files, 2K"},
+		})
+		testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{
+			{"env: - KEPT_VAR"},
+			{"FAILED"},
+		})
+	})
+
+	It("TestPushingWithPlanPrintsJSON", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+		deps.manifestRepo.ReadManifestReturns.Manifest = manifestToPlan()
+		deps.routeRepo.FindByHostAndDomainNotFound = true
+		deps.appBitsRepo.FilesToUploadFiles = []models.AppFileFields{{Path: "app.rb", Size: 10}}
+
+		ui := callPush([]string{"--plan", "--json"}, deps)
+
+		plans := []map[string]interface{}{}
+		err := json.Unmarshal([]byte(strings.Join(ui.Outputs, "\n")), &plans)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(plans)).To(Equal(1))
+		Expect(plans[0]["name"]).To(Equal("my-app"))
+		Expect(plans[0]["action"]).To(Equal("create"))
+		Expect(plans[0]["env_added"]).To(Equal([]interface{}{"CHANGED_VAR", "NEW_VAR"}))
+		Expect(plans[0]["routes_to_create"]).To(Equal([]interface{}{"new-host.foo.cf-app.com"}))
+		Expect(plans[0]["upload"]).To(Equal(map[string]interface{}{"file_count": float64(1), "bytes": float64(10)}))
+		Expect(deps.appRepo.CreateAppParams).To(BeNil())
+	})
+
+	It("TestPushingWithShowFilesListsFilesWithoutPushing", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+		deps.appBitsRepo.AppFilesFiles = []models.AppFileFields{
+			{Path: filepath.Join("lib", "util.rb"), Size: 1024},
+			{Path: "app.rb", Size: 1024},
+		}
+
+		ui := callPush([]string{"--show-files", "-p", "/some/path", "my-new-app"}, deps)
+
+		Expect(deps.appRepo.CreateAppParams).To(BeNil())
+		Expect(deps.appBitsRepo.UploadedAppGuid).To(Equal(""))
+		Expect(deps.appBitsRepo.AppFilesIgnores).To(Equal(cf.CfIgnoreFiles))
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Files to upload for", "my-new-app"},
+			{"  app.rb"},
+			{"  lib/util.rb"},
+			{"2 files, 2K"},
+		})
+	})
+
+	It("TestPushingWithGitignoreAlsoLeavesOutGitIgnoredFiles", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+
+		callPush([]string{"--gitignore", "-p", "/some/path", "my-new-app"}, deps)
+
+		Expect(deps.appBitsRepo.UploadedIgnores).To(Equal(cf.CfAndGitIgnoreFiles))
+	})
+
+	It("TestPushingWithStashKeepsTheCurrentDropletFirst", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadApp = liveAppWithRoute()
+		deps.appRepo.UpdateAppResult = liveAppWithRoute()
+		deps.appBitsRepo.UploadAppHook = func(appGuid string) {
+			Expect(len(deps.stashRepo.StashedApps)).To(Equal(1))
+		}
+
+		ui := callPush([]string{"--stash", "-p", "/some/path", "my-app"}, deps)
+
+		Expect(len(deps.stashRepo.StashedApps)).To(Equal(1))
+		Expect(deps.stashRepo.StashedApps[0].Memory).To(Equal(uint64(512)))
+		Expect(deps.appBitsRepo.UploadedAppGuid).To(Equal("my-app-guid"))
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Stashing current droplet of", "my-app"},
+			{"OK"},
+			{"Stashed as version 1", "rollback my-app"},
+		})
+	})
+
+	It("TestPushingWithStashContinuesWhenTheAppHasNoDroplet", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadApp = liveAppWithRoute()
+		deps.appRepo.UpdateAppResult = liveAppWithRoute()
+		deps.stashRepo.StashNotFound = true
+
+		ui := callPush([]string{"--stash", "-p", "/some/path", "my-app"}, deps)
+
+		Expect(deps.appBitsRepo.UploadedAppGuid).To(Equal("my-app-guid"))
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"my-app", "has no droplet to stash yet"},
+		})
+		testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{{"FAILED"}})
+	})
+
+	It("TestPushingWithStashSkipsNewApps", func() {
+		deps := getPushDependencies()
+		deps.appRepo.ReadNotFound = true
+
+		callPush([]string{"--stash", "-p", "/some/path", "my-new-app"}, deps)
+
+		Expect(deps.stashRepo.StashedApps).To(BeNil())
+		Expect(deps.appRepo.CreatedAppParams().Name).NotTo(BeNil())
+	})
+
 	It("TestPushingWithNoManifestAndNoName", func() {
 		deps := getPushDependencies()
 
@@ -1057,17 +1711,97 @@ func manifestWithServicesAndEnv() *manifest.Manifest {
 	}
 }
 
+func appToPlan() (app models.Application) {
+	app.Name = "my-app"
+	app.Guid = "my-app-guid"
+	app.Memory = 256
+	app.InstanceCount = 1
+	app.EnvironmentVars = map[string]string{"KEPT_VAR": "kept", "CHANGED_VAR": "old"}
+	return
+}
+
+func manifestToPlan() *manifest.Manifest {
+	name := "my-app"
+	memory := uint64(512)
+	instances := 3
+	host := "new-host"
+	path := "/some/path"
+	return &manifest.Manifest{
+		Applications: []models.AppParams{{
+			Name:            &name,
+			Memory:          &memory,
+			InstanceCount:   &instances,
+			Host:            &host,
+			Path:            &path,
+			Services:        &[]string{"my-creds"},
+			EnvironmentVars: &map[string]string{"CHANGED_VAR": "new", "NEW_VAR": "new"},
+			ServiceInstances: &[]models.ServiceInstanceParams{
+				{Name: "my-creds", Credentials: map[string]string{"password": "secret"}},
+			},
+		}},
+	}
+}
+
+func liveAppWithRoute() (app models.Application) {
+	app.Name = "my-app"
+	app.Guid = "my-app-guid"
+	app.State = "started"
+	app.Memory = 512
+	app.InstanceCount = 3
+	app.EnvironmentVars = map[string]string{"LIVE": "true"}
+
+	route := models.RouteSummary{}
+	route.Guid = "live-route-guid"
+	route.Host = "live-host"
+	route.Domain = models.DomainFields{Name: "example.com"}
+	app.Routes = []models.RouteSummary{route}
+	return
+}
+
+func manifestWithDependencies(dependencies map[string][]string, names ...string) *manifest.Manifest {
+	m := &manifest.Manifest{}
+	for _, name := range names {
+		app := models.AppParams{Name: new(string)}
+		*app.Name = name
+		if dependsOn, ok := dependencies[name]; ok {
+			app.DependsOn = &dependsOn
+		}
+		m.Applications = append(m.Applications, app)
+	}
+	return m
+}
+
+func manifestWithServiceInstances() *manifest.Manifest {
+	name := "review-app"
+	return &manifest.Manifest{
+		Applications: []models.AppParams{
+			models.AppParams{
+				Name:     &name,
+				Services: &[]string{"review-db", "review-creds"},
+				ServiceInstances: &[]models.ServiceInstanceParams{
+					{Name: "review-db", Label: "cleardb", Plan: "spark"},
+					{Name: "review-creds", Credentials: map[string]string{"password": "secret"}, SyslogDrainUrl: "syslog://example.com"},
+				},
+			},
+		},
+	}
+}
+
 type pushDependencies struct {
-	manifestRepo *testmanifest.FakeManifestRepository
-	starter      *testcmd.FakeAppStarter
-	stopper      *testcmd.FakeAppStopper
-	binder       *testcmd.FakeAppBinder
-	appRepo      *testapi.FakeApplicationRepository
-	domainRepo   *testapi.FakeDomainRepository
-	routeRepo    *testapi.FakeRouteRepository
-	stackRepo    *testapi.FakeStackRepository
-	appBitsRepo  *testapi.FakeApplicationBitsRepository
-	serviceRepo  *testapi.FakeServiceRepo
+	manifestRepo   *testmanifest.FakeManifestRepository
+	starter        *testcmd.FakeAppStarter
+	stopper        *testcmd.FakeAppStopper
+	binder         *testcmd.FakeAppBinder
+	appRepo        *testapi.FakeApplicationRepository
+	domainRepo     *testapi.FakeDomainRepository
+	routeRepo      *testapi.FakeRouteRepository
+	stackRepo      *testapi.FakeStackRepository
+	appBitsRepo    *testapi.FakeApplicationBitsRepository
+	serviceRepo    *testapi.FakeServiceRepo
+	stashRepo      *testapi.FakeDropletStashRepository
+	appSummaryRepo *testapi.FakeAppSummaryRepo
+
+	userProvidedServiceInstanceRepo *testapi.FakeUserProvidedServiceInstanceRepo
 }
 
 func getPushDependencies() (deps pushDependencies) {
@@ -1085,6 +1819,9 @@ func getPushDependencies() (deps pushDependencies) {
 	deps.stackRepo = &testapi.FakeStackRepository{}
 	deps.appBitsRepo = &testapi.FakeApplicationBitsRepository{}
 	deps.serviceRepo = &testapi.FakeServiceRepo{}
+	deps.userProvidedServiceInstanceRepo = &testapi.FakeUserProvidedServiceInstanceRepo{}
+	deps.stashRepo = &testapi.FakeDropletStashRepository{}
+	deps.appSummaryRepo = &testapi.FakeAppSummaryRepo{}
 
 	return
 }
@@ -1097,7 +1834,7 @@ func callPush(args []string, deps pushDependencies) (ui *testterm.FakeUI) {
 
 	cmd := NewPush(ui, configRepo, deps.manifestRepo, deps.starter,
 		deps.stopper, deps.binder, deps.appRepo, deps.domainRepo,
-		deps.routeRepo, deps.stackRepo, deps.serviceRepo, deps.appBitsRepo)
+		deps.routeRepo, deps.stackRepo, deps.serviceRepo, deps.userProvidedServiceInstanceRepo, deps.appBitsRepo, deps.stashRepo, deps.appSummaryRepo)
 
 	reqFactory := &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true}
 	testcmd.RunCommand(cmd, ctxt, reqFactory)
@@ -12,18 +12,21 @@ type Restart struct {
 	ui      terminal.UI
 	starter ApplicationStarter
 	stopper ApplicationStopper
-	appReq  requirements.ApplicationRequirement
+
+	instanceRestarter ApplicationInstanceRestarter
+	appReq            requirements.ApplicationRequirement
 }
 
 type ApplicationRestarter interface {
 	ApplicationRestart(app models.Application)
 }
 
-func NewRestart(ui terminal.UI, starter ApplicationStarter, stopper ApplicationStopper) (cmd *Restart) {
+func NewRestart(ui terminal.UI, starter ApplicationStarter, stopper ApplicationStopper, instanceRestarter ApplicationInstanceRestarter) (cmd *Restart) {
 	cmd = new(Restart)
 	cmd.ui = ui
 	cmd.starter = starter
 	cmd.stopper = stopper
+	cmd.instanceRestarter = instanceRestarter
 	return
 }
 
@@ -46,6 +49,10 @@ func (cmd *Restart) GetRequirements(reqFactory requirements.Factory, c *cli.Cont
 
 func (cmd *Restart) Run(c *cli.Context) {
 	app := cmd.appReq.GetApplication()
+	if c.Bool("rolling") {
+		cmd.instanceRestarter.ApplicationRollingRestart(app)
+		return
+	}
 	cmd.ApplicationRestart(app)
 }
 
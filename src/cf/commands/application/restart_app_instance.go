@@ -0,0 +1,183 @@
+package application
+
+import (
+	"cf"
+	"cf/api"
+	"cf/configuration"
+	"cf/models"
+	"cf/requirements"
+	"cf/terminal"
+	"errors"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"strconv"
+	"time"
+)
+
+type ApplicationInstanceRestarter interface {
+	ApplicationRestartInstance(app models.Application, index int)
+	ApplicationRollingRestart(app models.Application)
+}
+
+type RestartAppInstance struct {
+	ui               terminal.UI
+	config           configuration.Reader
+	appInstancesRepo api.AppInstancesRepository
+	appReq           requirements.ApplicationRequirement
+	index            int
+
+	StartupTimeout time.Duration
+	PingerThrottle time.Duration
+}
+
+func NewRestartAppInstance(ui terminal.UI, config configuration.Reader, appInstancesRepo api.AppInstancesRepository) (cmd *RestartAppInstance) {
+	cmd = new(RestartAppInstance)
+	cmd.ui = ui
+	cmd.config = config
+	cmd.appInstancesRepo = appInstancesRepo
+
+	cmd.StartupTimeout = DefaultStartupTimeout
+	cmd.PingerThrottle = DefaultPingerThrottle
+	return
+}
+
+func (cmd *RestartAppInstance) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
+	if len(c.Args()) != 2 {
+		err = errors.New("Incorrect Usage")
+		cmd.ui.FailWithUsage(c, "restart-app-instance")
+		return
+	}
+
+	cmd.index, err = strconv.Atoi(c.Args()[1])
+	if err != nil || cmd.index < 0 {
+		err = errors.New("Incorrect Usage")
+		cmd.ui.FailWithUsage(c, "restart-app-instance")
+		return
+	}
+
+	cmd.appReq = reqFactory.NewApplicationRequirement(c.Args()[0])
+
+	reqs = []requirements.Requirement{
+		reqFactory.NewLoginRequirement(),
+		reqFactory.NewTargetedSpaceRequirement(),
+		cmd.appReq,
+	}
+	return
+}
+
+func (cmd *RestartAppInstance) Run(c *cli.Context) {
+	cmd.ApplicationRestartInstance(cmd.appReq.GetApplication(), cmd.index)
+}
+
+func (cmd *RestartAppInstance) ApplicationRestartInstance(app models.Application, index int) {
+	instances, ok := cmd.getInstances(app)
+	if !ok {
+		return
+	}
+
+	if index >= len(instances) {
+		cmd.ui.Failed("App %s has no instance #%d, it has %d instances", app.Name, index, len(instances))
+		return
+	}
+
+	if cmd.restartInstance(app, index, instances[index]) {
+		cmd.ui.Say(terminal.HeaderColor(fmt.Sprintf("\nInstance #%d restarted", index)))
+	}
+}
+
+// ApplicationRollingRestart restarts the instances of an app one after the other, each once the
+// one before it is running again, so that the app keeps serving requests while it restarts.
+func (cmd *RestartAppInstance) ApplicationRollingRestart(app models.Application) {
+	if app.State == "stopped" {
+		cmd.ui.Failed("App %s is stopped, there are no instances to restart", app.Name)
+		return
+	}
+
+	instances, ok := cmd.getInstances(app)
+	if !ok {
+		return
+	}
+
+	if len(instances) == 1 {
+		cmd.ui.Warn("App %s has only one instance, it is unavailable while the instance restarts.\n", app.Name)
+	}
+
+	for index, instance := range instances {
+		if !cmd.restartInstance(app, index, instance) {
+			return
+		}
+		cmd.ui.Say("")
+	}
+
+	cmd.ui.Say(terminal.HeaderColor(fmt.Sprintf("All %d instances restarted", len(instances))))
+}
+
+func (cmd *RestartAppInstance) getInstances(app models.Application) (instances []models.AppInstanceFields, ok bool) {
+	instances, apiResponse := cmd.appInstancesRepo.GetInstances(app.Guid)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	ok = true
+	return
+}
+
+func (cmd *RestartAppInstance) restartInstance(app models.Application, index int, instance models.AppInstanceFields) (ok bool) {
+	cmd.ui.Say("Restarting instance %s of app %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(fmt.Sprintf("#%d", index)),
+		terminal.EntityNameColor(app.Name),
+		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+		terminal.EntityNameColor(cmd.config.Username()),
+	)
+
+	apiResponse := cmd.appInstancesRepo.DeleteInstance(app.Guid, index)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	cmd.ui.Ok()
+	cmd.ui.Say("")
+
+	return cmd.waitForInstanceToRun(app, index, instance.Since)
+}
+
+// waitForInstanceToRun waits for the instance at index to be replaced and running. Until the old
+// instance has gone away it is still reported as running, with the time it was started at.
+func (cmd *RestartAppInstance) waitForInstanceToRun(app models.Application, index int, oldSince time.Time) (ok bool) {
+	startupStartTime := time.Now()
+	replaced := false
+	var lastState models.InstanceState
+
+	for {
+		if time.Since(startupStartTime) > cmd.StartupTimeout {
+			cmd.ui.Failed(fmt.Sprintf("Timed out waiting for instance #%d to start\n\nTIP: use '%s' for more information", index, terminal.CommandColor(fmt.Sprintf("%s logs %s --recent", cf.Name(), app.Name))))
+			return
+		}
+
+		instances, apiResponse := cmd.appInstancesRepo.GetInstances(app.Guid)
+		if apiResponse.IsSuccessful() && index < len(instances) {
+			instance := instances[index]
+			replaced = replaced || instance.State != models.InstanceRunning || !instance.Since.Equal(oldSince)
+
+			if replaced && instance.State != lastState {
+				cmd.ui.Say("instance #%d is %s", index, coloredInstanceState(instance))
+				lastState = instance.State
+			}
+
+			if instance.State == models.InstanceFlapping {
+				cmd.ui.Failed(fmt.Sprintf("Instance #%d failed to start\n\nTIP: use '%s' for more information", index, terminal.CommandColor(fmt.Sprintf("%s logs %s --recent", cf.Name(), app.Name))))
+				return
+			}
+
+			if replaced && instance.State == models.InstanceRunning {
+				ok = true
+				return
+			}
+		}
+
+		cmd.ui.Wait(cmd.PingerThrottle)
+	}
+}
@@ -0,0 +1,221 @@
+package application_test
+
+import (
+	. "cf/commands/application"
+	"cf/models"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	testapi "testhelpers/api"
+	testassert "testhelpers/assert"
+	testcmd "testhelpers/commands"
+	testconfig "testhelpers/configuration"
+	testreq "testhelpers/requirements"
+	testterm "testhelpers/terminal"
+	"time"
+)
+
+var _ = Describe("restart-app-instance command", func() {
+	var (
+		app              models.Application
+		appInstancesRepo *testapi.FakeAppInstancesRepo
+		reqFactory       *testreq.FakeReqFactory
+		startupTimeout   time.Duration
+	)
+
+	oldSince := time.Date(2014, 5, 1, 10, 0, 0, 0, time.UTC)
+	newSince := oldSince.Add(time.Hour)
+
+	instance := func(state models.InstanceState, since time.Time) models.AppInstanceFields {
+		return models.AppInstanceFields{State: state, Since: since}
+	}
+
+	BeforeEach(func() {
+		app = models.Application{}
+		app.Name = "my-app"
+		app.Guid = "my-app-guid"
+		app.State = "started"
+
+		appInstancesRepo = &testapi.FakeAppInstancesRepo{}
+		reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true, Application: app}
+		startupTimeout = time.Minute
+	})
+
+	newCommand := func(ui *testterm.FakeUI) (cmd *RestartAppInstance) {
+		cmd = NewRestartAppInstance(ui, testconfig.NewRepositoryWithDefaults(), appInstancesRepo)
+		cmd.StartupTimeout = startupTimeout
+		cmd.PingerThrottle = 0
+		return
+	}
+
+	callRestartAppInstance := func(args []string) (ui *testterm.FakeUI) {
+		ui = &testterm.FakeUI{}
+		testcmd.RunCommand(newCommand(ui), testcmd.NewContext("restart-app-instance", args), reqFactory)
+		return
+	}
+
+	It("fails with usage without an app and a numeric index", func() {
+		ui := callRestartAppInstance([]string{"my-app"})
+		Expect(ui.FailedWithUsage).To(BeTrue())
+
+		ui = callRestartAppInstance([]string{"my-app", "first"})
+		Expect(ui.FailedWithUsage).To(BeTrue())
+
+		ui = callRestartAppInstance([]string{"my-app", "-1"})
+		Expect(ui.FailedWithUsage).To(BeTrue())
+
+		ui = callRestartAppInstance([]string{"my-app", "1"})
+		Expect(ui.FailedWithUsage).To(BeFalse())
+	})
+
+	It("requires a user to be logged in, a space to be targeted and the app to exist", func() {
+		reqFactory.LoginSuccess = false
+		callRestartAppInstance([]string{"my-app", "0"})
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+
+		reqFactory.LoginSuccess = true
+		reqFactory.TargetedSpaceSuccess = false
+		callRestartAppInstance([]string{"my-app", "0"})
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+
+		reqFactory.TargetedSpaceSuccess = true
+		callRestartAppInstance([]string{"my-app", "0"})
+		Expect(testcmd.CommandDidPassRequirements).To(BeTrue())
+		Expect(reqFactory.ApplicationName).To(Equal("my-app"))
+	})
+
+	It("deletes the instance and waits until its replacement is running", func() {
+		appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{
+			{instance(models.InstanceRunning, oldSince), instance(models.InstanceRunning, oldSince)},
+			{instance(models.InstanceRunning, oldSince), instance(models.InstanceRunning, oldSince)},
+			{instance(models.InstanceRunning, oldSince), instance(models.InstanceStarting, newSince)},
+			{instance(models.InstanceRunning, oldSince), instance(models.InstanceRunning, newSince)},
+		}
+
+		ui := callRestartAppInstance([]string{"my-app", "1"})
+
+		Expect(appInstancesRepo.DeleteInstanceAppGuid).To(Equal("my-app-guid"))
+		Expect(appInstancesRepo.DeleteInstanceIndexes).To(Equal([]int{1}))
+		Expect(appInstancesRepo.GetInstancesResponses).To(BeEmpty())
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Restarting instance", "#1", "my-app", "my-org", "my-space", "my-user"},
+			{"OK"},
+			{"instance #1 is", "starting"},
+			{"instance #1 is", "running"},
+			{"Instance #1 restarted"},
+		})
+		testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{{"FAILED"}})
+	})
+
+	It("fails when the app has no instance with the index", func() {
+		appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{
+			{instance(models.InstanceRunning, oldSince), instance(models.InstanceRunning, oldSince)},
+		}
+
+		ui := callRestartAppInstance([]string{"my-app", "2"})
+
+		Expect(appInstancesRepo.DeleteInstanceIndexes).To(BeEmpty())
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"my-app", "has no instance #2", "2 instances"},
+		})
+	})
+
+	It("fails when the new instance is crashing", func() {
+		appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{
+			{instance(models.InstanceRunning, oldSince)},
+			{instance(models.InstanceDown, oldSince)},
+			{instance(models.InstanceFlapping, newSince)},
+		}
+
+		ui := callRestartAppInstance([]string{"my-app", "0"})
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"instance #0 is", "down"},
+			{"instance #0 is", "crashing"},
+			{"FAILED"},
+			{"Instance #0 failed to start"},
+			{"logs my-app --recent"},
+		})
+	})
+
+	It("fails when the new instance does not start in time", func() {
+		startupTimeout = 0
+		appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{
+			{instance(models.InstanceRunning, oldSince)},
+		}
+
+		ui := callRestartAppInstance([]string{"my-app", "0"})
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"Timed out waiting for instance #0 to start"},
+		})
+	})
+
+	Describe("rolling restarts", func() {
+		It("restarts each instance once the one before it is running again", func() {
+			appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{
+				{instance(models.InstanceRunning, oldSince), instance(models.InstanceRunning, oldSince)},
+				{instance(models.InstanceStarting, newSince), instance(models.InstanceRunning, oldSince)},
+				{instance(models.InstanceRunning, newSince), instance(models.InstanceRunning, oldSince)},
+				{instance(models.InstanceRunning, newSince), instance(models.InstanceDown, oldSince)},
+				{instance(models.InstanceRunning, newSince), instance(models.InstanceRunning, newSince)},
+			}
+
+			ui := &testterm.FakeUI{}
+			newCommand(ui).ApplicationRollingRestart(app)
+
+			Expect(appInstancesRepo.DeleteInstanceIndexes).To(Equal([]int{0, 1}))
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"Restarting instance", "#0", "my-app"},
+				{"instance #0 is", "running"},
+				{"Restarting instance", "#1", "my-app"},
+				{"instance #1 is", "down"},
+				{"instance #1 is", "running"},
+				{"All 2 instances restarted"},
+			})
+		})
+
+		It("stops at the first instance that fails to start", func() {
+			appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{
+				{instance(models.InstanceRunning, oldSince), instance(models.InstanceRunning, oldSince)},
+				{instance(models.InstanceFlapping, newSince), instance(models.InstanceRunning, oldSince)},
+			}
+
+			ui := &testterm.FakeUI{}
+			testassert.AssertPanic(testterm.FailedWasCalled, func() {
+				newCommand(ui).ApplicationRollingRestart(app)
+			})
+
+			Expect(appInstancesRepo.DeleteInstanceIndexes).To(Equal([]int{0}))
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Instance #0 failed to start"}})
+		})
+
+		It("warns that an app with one instance is unavailable while it restarts", func() {
+			appInstancesRepo.GetInstancesResponses = [][]models.AppInstanceFields{
+				{instance(models.InstanceRunning, oldSince)},
+				{instance(models.InstanceRunning, newSince)},
+			}
+
+			ui := &testterm.FakeUI{}
+			newCommand(ui).ApplicationRollingRestart(app)
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"only one instance", "unavailable"},
+				{"All 1 instances restarted"},
+			})
+		})
+
+		It("fails when the app is stopped", func() {
+			app.State = "stopped"
+
+			ui := &testterm.FakeUI{}
+			testassert.AssertPanic(testterm.FailedWasCalled, func() {
+				newCommand(ui).ApplicationRollingRestart(app)
+			})
+
+			Expect(appInstancesRepo.DeleteInstanceIndexes).To(BeEmpty())
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"my-app is stopped"}})
+		})
+	})
+})
@@ -11,10 +11,14 @@ import (
 )
 
 func callRestart(args []string, reqFactory *testreq.FakeReqFactory, starter ApplicationStarter, stopper ApplicationStopper) (ui *testterm.FakeUI) {
+	return callRestartWithInstanceRestarter(args, reqFactory, starter, stopper, &testcmd.FakeAppInstanceRestarter{})
+}
+
+func callRestartWithInstanceRestarter(args []string, reqFactory *testreq.FakeReqFactory, starter ApplicationStarter, stopper ApplicationStopper, instanceRestarter ApplicationInstanceRestarter) (ui *testterm.FakeUI) {
 	ui = new(testterm.FakeUI)
 	ctxt := testcmd.NewContext("restart", args)
 
-	cmd := NewRestart(ui, starter, stopper)
+	cmd := NewRestart(ui, starter, stopper, instanceRestarter)
 	testcmd.RunCommand(cmd, ctxt, reqFactory)
 	return
 }
@@ -63,4 +67,18 @@ var _ = Describe("Testing with ginkgo", func() {
 		Expect(stopper.AppToStop).To(Equal(app))
 		Expect(starter.AppToStart).To(Equal(app))
 	})
+	It("restarts the instances one at a time with --rolling", func() {
+		app := models.Application{}
+		app.Name = "my-app"
+		app.Guid = "my-app-guid"
+		reqFactory := &testreq.FakeReqFactory{Application: app, LoginSuccess: true, TargetedSpaceSuccess: true}
+		starter := &testcmd.FakeAppStarter{}
+		stopper := &testcmd.FakeAppStopper{}
+		instanceRestarter := &testcmd.FakeAppInstanceRestarter{}
+		callRestartWithInstanceRestarter([]string{"--rolling", "my-app"}, reqFactory, starter, stopper, instanceRestarter)
+
+		Expect(instanceRestarter.AppToRollOut).To(Equal(app))
+		Expect(stopper.AppToStop).To(Equal(models.Application{}))
+		Expect(starter.AppToStart).To(Equal(models.Application{}))
+	})
 })
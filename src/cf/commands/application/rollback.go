@@ -0,0 +1,152 @@
+package application
+
+import (
+	"cf"
+	"cf/api"
+	"cf/configuration"
+	"cf/formatters"
+	"cf/models"
+	"cf/requirements"
+	"cf/terminal"
+	"errors"
+	"fmt"
+	"github.com/codegangsta/cli"
+)
+
+type Rollback struct {
+	ui               terminal.UI
+	config           configuration.Reader
+	appRepo          api.ApplicationRepository
+	dropletStashRepo api.DropletStashRepository
+	starter          ApplicationStarter
+	stopper          ApplicationStopper
+	appReq           requirements.ApplicationRequirement
+}
+
+func NewRollback(ui terminal.UI, config configuration.Reader, appRepo api.ApplicationRepository, dropletStashRepo api.DropletStashRepository, starter ApplicationStarter, stopper ApplicationStopper) (cmd *Rollback) {
+	cmd = new(Rollback)
+	cmd.ui = ui
+	cmd.config = config
+	cmd.appRepo = appRepo
+	cmd.dropletStashRepo = dropletStashRepo
+	cmd.starter = starter
+	cmd.stopper = stopper
+	return
+}
+
+func (cmd *Rollback) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
+	if len(c.Args()) != 1 {
+		err = errors.New("Incorrect Usage")
+		cmd.ui.FailWithUsage(c, "rollback")
+		return
+	}
+
+	cmd.appReq = reqFactory.NewApplicationRequirement(c.Args()[0])
+
+	reqs = []requirements.Requirement{
+		reqFactory.NewLoginRequirement(),
+		reqFactory.NewTargetedSpaceRequirement(),
+		cmd.appReq,
+	}
+	return
+}
+
+func (cmd *Rollback) Run(c *cli.Context) {
+	app := cmd.appReq.GetApplication()
+
+	stashes, apiResponse := cmd.dropletStashRepo.ListStashes(app.Name)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	if c.Bool("list") {
+		cmd.listStashes(app, stashes)
+		return
+	}
+
+	if len(stashes) == 0 {
+		cmd.ui.Failed("There is no stashed droplet to roll %s back to, push with --stash to keep one", app.Name)
+		return
+	}
+
+	cmd.rollBack(app, stashes[0])
+}
+
+func (cmd *Rollback) listStashes(app models.Application, stashes []models.DropletStash) {
+	cmd.ui.Say("Getting stashed droplets for app %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(app.Name),
+		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+		terminal.EntityNameColor(cmd.config.Username()),
+	)
+	cmd.ui.Ok()
+	cmd.ui.Say("")
+
+	if len(stashes) == 0 {
+		cmd.ui.Say("No stashed droplets for app %s", terminal.EntityNameColor(app.Name))
+		return
+	}
+
+	table := [][]string{
+		[]string{"version", "stashed", "memory", "instances", "command"},
+	}
+	for _, stash := range stashes {
+		table = append(table, []string{
+			fmt.Sprintf("%d", stash.Version),
+			stash.StashedAt.Local().Format(TIMESTAMP_FORMAT),
+			formatters.ByteSize(stash.Memory * formatters.MEGABYTE),
+			fmt.Sprintf("%d", stash.InstanceCount),
+			stash.Command,
+		})
+	}
+	cmd.ui.DisplayTable(table)
+}
+
+func (cmd *Rollback) rollBack(app models.Application, stash models.DropletStash) {
+	cmd.ui.Say("Rolling back app %s in org %s / space %s as %s to version %d stashed at %s...",
+		terminal.EntityNameColor(app.Name),
+		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+		terminal.EntityNameColor(cmd.config.Username()),
+		stash.Version,
+		stash.StashedAt.Local().Format(TIMESTAMP_FORMAT),
+	)
+
+	updatedApp, apiResponse := cmd.appRepo.Update(app.Guid, stash.ToParams())
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed("Error restoring the settings of %s.\n%s", app.Name, apiResponse.Message)
+		return
+	}
+
+	progressBar := cmd.ui.ProgressBar()
+	apiResponse = cmd.dropletStashRepo.Restore(app.Guid, stash, progressBar.Update)
+	progressBar.Done()
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed("Error uploading the stashed droplet.\n%s", apiResponse.Message)
+		return
+	}
+
+	cmd.ui.Ok()
+	cmd.ui.Say("")
+
+	// the stash has been used, so a later rollback goes back one version further
+	apiResponse = cmd.dropletStashRepo.Delete(stash)
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Warn("Could not delete version %d from the stash: %s", stash.Version, apiResponse.Message)
+	}
+
+	stoppedApp, err := cmd.stopper.ApplicationStop(updatedApp)
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+		return
+	}
+
+	cmd.ui.Say("")
+
+	_, err = cmd.starter.ApplicationStart(stoppedApp)
+	if err != nil {
+		cmd.ui.Failed("%s\nThe droplet and settings were restored, start the app with '%s start %s'", err.Error(), cf.Name(), app.Name)
+		return
+	}
+}
@@ -0,0 +1,166 @@
+package application_test
+
+import (
+	"cf/api"
+	. "cf/commands/application"
+	"cf/models"
+	"cf/net"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	testapi "testhelpers/api"
+	testassert "testhelpers/assert"
+	testcmd "testhelpers/commands"
+	testconfig "testhelpers/configuration"
+	testnet "testhelpers/net"
+	testreq "testhelpers/requirements"
+	testterm "testhelpers/terminal"
+	"time"
+)
+
+var _ = Describe("rollback command", func() {
+	var (
+		appRepo    *testapi.FakeApplicationRepository
+		stashRepo  *testapi.FakeDropletStashRepository
+		starter    *testcmd.FakeAppStarter
+		stopper    *testcmd.FakeAppStopper
+		reqFactory *testreq.FakeReqFactory
+	)
+
+	BeforeEach(func() {
+		app := models.Application{}
+		app.Name = "my-app"
+		app.Guid = "my-app-guid"
+
+		appRepo = &testapi.FakeApplicationRepository{}
+		appRepo.UpdateAppResult = app
+		starter = &testcmd.FakeAppStarter{}
+		stopper = &testcmd.FakeAppStopper{}
+		reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true, Application: app}
+
+		stashRepo = &testapi.FakeDropletStashRepository{Stashes: []models.DropletStash{
+			{
+				Version:         2,
+				AppName:         "my-app",
+				StashedAt:       time.Date(2014, 5, 1, 10, 0, 0, 0, time.UTC),
+				Command:         "bundle exec rackup",
+				EnvironmentVars: map[string]string{"FOO": "bar"},
+				InstanceCount:   3,
+				Memory:          256,
+			},
+			{Version: 1, AppName: "my-app", InstanceCount: 1, Memory: 128},
+		}}
+	})
+
+	callRollback := func(args []string) (ui *testterm.FakeUI) {
+		ui = &testterm.FakeUI{}
+		configRepo := testconfig.NewRepositoryWithDefaults()
+		cmd := NewRollback(ui, configRepo, appRepo, stashRepo, starter, stopper)
+		testcmd.RunCommand(cmd, testcmd.NewContext("rollback", args), reqFactory)
+		return
+	}
+
+	It("requires a user to be logged in, a space to be targeted and the app to exist", func() {
+		callRollback([]string{"my-app"})
+		Expect(testcmd.CommandDidPassRequirements).To(BeTrue())
+		Expect(reqFactory.ApplicationName).To(Equal("my-app"))
+
+		reqFactory.TargetedSpaceSuccess = false
+		callRollback([]string{"my-app"})
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+	})
+
+	It("fails with usage when no app is given", func() {
+		ui := callRollback([]string{})
+		Expect(ui.FailedWithUsage).To(BeTrue())
+	})
+
+	It("restores the newest stashed droplet and settings and restarts the app", func() {
+		ui := callRollback([]string{"my-app"})
+
+		Expect(stashRepo.ListedAppName).To(Equal("my-app"))
+		Expect(appRepo.UpdateAppGuid).To(Equal("my-app-guid"))
+		Expect(*appRepo.UpdateParams.Memory).To(Equal(uint64(256)))
+		Expect(*appRepo.UpdateParams.InstanceCount).To(Equal(3))
+		Expect(*appRepo.UpdateParams.Command).To(Equal("bundle exec rackup"))
+		Expect(*appRepo.UpdateParams.EnvironmentVars).To(Equal(map[string]string{"FOO": "bar"}))
+
+		Expect(stashRepo.RestoredAppGuid).To(Equal("my-app-guid"))
+		Expect(stashRepo.RestoredStash.Version).To(Equal(2))
+		Expect(len(stashRepo.DeletedStashes)).To(Equal(1))
+		Expect(stashRepo.DeletedStashes[0].Version).To(Equal(2))
+
+		Expect(stopper.AppToStop.Guid).To(Equal("my-app-guid"))
+		Expect(starter.AppToStart.Guid).To(Equal("my-app-guid"))
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Rolling back app", "my-app", "my-org", "my-space", "my-user", "version 2"},
+			{"OK"},
+		})
+	})
+
+	It("sends every stashed setting to the Cloud Controller, clearing env variables the bad push added", func() {
+		stashRepo.Stashes = []models.DropletStash{
+			{Version: 1, AppName: "my-app", Command: "bundle exec rackup", DiskQuota: 2048, InstanceCount: 2, Memory: 256},
+		}
+
+		ts, handler := testnet.NewTLSServer([]testnet.TestRequest{
+			testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "PUT",
+				Path:     "/v2/apps/my-app-guid",
+				Matcher:  testnet.RequestBodyMatcher(`{"buildpack":"","command":"bundle exec rackup","disk_quota":2048,"environment_json":{},"instances":2,"memory":256}`),
+				Response: testnet.TestResponse{Status: http.StatusCreated, Body: `{"metadata": {"guid": "my-app-guid"}, "entity": {"name": "my-app"}}`},
+			}),
+		})
+		defer ts.Close()
+
+		configRepo := testconfig.NewRepositoryWithDefaults()
+		configRepo.SetApiEndpoint(ts.URL)
+		ccAppRepo := api.NewCloudControllerApplicationRepository(configRepo, net.NewCloudControllerGateway())
+
+		ui := &testterm.FakeUI{}
+		cmd := NewRollback(ui, configRepo, ccAppRepo, stashRepo, starter, stopper)
+		testcmd.RunCommand(cmd, testcmd.NewContext("rollback", []string{"my-app"}), reqFactory)
+
+		Expect(handler.AllRequestsCalled()).To(BeTrue())
+		Expect(stashRepo.RestoredAppGuid).To(Equal("my-app-guid"))
+	})
+
+	It("does not restart the app when the droplet cannot be restored", func() {
+		stashRepo.RestoreErr = true
+
+		ui := callRollback([]string{"my-app"})
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"Error uploading the stashed droplet"},
+		})
+		Expect(stashRepo.DeletedStashes).To(BeNil())
+		Expect(starter.AppToStart.Guid).To(Equal(""))
+	})
+
+	It("fails when nothing has been stashed", func() {
+		stashRepo.Stashes = []models.DropletStash{}
+
+		ui := callRollback([]string{"my-app"})
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"no stashed droplet", "--stash"},
+		})
+		Expect(appRepo.UpdateAppGuid).To(Equal(""))
+	})
+
+	It("lists the stashed droplets with --list", func() {
+		ui := callRollback([]string{"--list", "my-app"})
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Getting stashed droplets for app", "my-app"},
+			{"version", "stashed", "memory", "instances", "command"},
+			{"2", "256M", "3", "bundle exec rackup"},
+			{"1", "128M", "1"},
+		})
+		Expect(stashRepo.RestoredAppGuid).To(Equal(""))
+		Expect(appRepo.UpdateAppGuid).To(Equal(""))
+	})
+})
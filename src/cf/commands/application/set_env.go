@@ -8,26 +8,30 @@ import (
 	"cf/requirements"
 	"cf/terminal"
 	"errors"
+	"fmt"
 	"github.com/codegangsta/cli"
 )
 
 type SetEnv struct {
-	ui      terminal.UI
-	config  configuration.Reader
-	appRepo api.ApplicationRepository
-	appReq  requirements.ApplicationRequirement
+	ui        terminal.UI
+	config    configuration.Reader
+	appRepo   api.ApplicationRepository
+	restarter ApplicationRestarter
+	appReq    requirements.ApplicationRequirement
 }
 
-func NewSetEnv(ui terminal.UI, config configuration.Reader, appRepo api.ApplicationRepository) (cmd *SetEnv) {
+func NewSetEnv(ui terminal.UI, config configuration.Reader, appRepo api.ApplicationRepository, restarter ApplicationRestarter) (cmd *SetEnv) {
 	cmd = new(SetEnv)
 	cmd.ui = ui
 	cmd.config = config
 	cmd.appRepo = appRepo
+	cmd.restarter = restarter
 	return
 }
 
 func (cmd *SetEnv) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
-	if len(c.Args()) < 3 {
+	fromFile := c.String("from-file") != ""
+	if (fromFile && len(c.Args()) != 1) || (!fromFile && (len(c.Args()) < 3 || c.Bool("replace"))) {
 		err = errors.New("Incorrect Usage")
 		cmd.ui.FailWithUsage(c, "set-env")
 		return
@@ -43,6 +47,11 @@ func (cmd *SetEnv) GetRequirements(reqFactory requirements.Factory, c *cli.Conte
 }
 
 func (cmd *SetEnv) Run(c *cli.Context) {
+	if c.String("from-file") != "" {
+		cmd.setEnvFromFile(c.String("from-file"), c.Bool("replace"), c.Bool("restart"))
+		return
+	}
+
 	varName := c.Args()[1]
 	varValue := c.Args()[2]
 	app := cmd.appReq.GetApplication()
@@ -62,7 +71,7 @@ func (cmd *SetEnv) Run(c *cli.Context) {
 	envParams := app.EnvironmentVars
 	envParams[varName] = varValue
 
-	_, apiResponse := cmd.appRepo.Update(app.Guid, models.AppParams{EnvironmentVars: &envParams})
+	updatedApp, apiResponse := cmd.appRepo.Update(app.Guid, models.AppParams{EnvironmentVars: &envParams})
 
 	if apiResponse.IsNotSuccessful() {
 		cmd.ui.Failed(apiResponse.Message)
@@ -70,5 +79,100 @@ func (cmd *SetEnv) Run(c *cli.Context) {
 	}
 
 	cmd.ui.Ok()
+	cmd.finish(updatedApp, c.Bool("restart"))
+}
+
+// setEnvFromFile sets all the env variables in the file with a single update, so that the app
+// needs to be restarted only once. With replace, variables that are not in the file are removed.
+func (cmd *SetEnv) setEnvFromFile(path string, replace bool, restart bool) {
+	vars, err := readEnvFile(path)
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+		return
+	}
+	if len(vars) == 0 {
+		cmd.ui.Failed("There are no env variables in %s", path)
+		return
+	}
+
+	app := cmd.appReq.GetApplication()
+
+	cmd.ui.Say("Setting env variables from %s for app %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(path),
+		terminal.EntityNameColor(app.Name),
+		terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+		terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+		terminal.EntityNameColor(cmd.config.Username()),
+	)
+
+	envParams := map[string]string{}
+	if !replace {
+		for name, value := range app.EnvironmentVars {
+			envParams[name] = value
+		}
+	}
+	for name, value := range vars {
+		envParams[name] = value
+	}
+
+	if !cmd.sayEnvDiff(app.EnvironmentVars, envParams) {
+		cmd.ui.Ok()
+		cmd.ui.Say("No env variables changed")
+		return
+	}
+
+	updatedApp, apiResponse := cmd.appRepo.Update(app.Guid, models.AppParams{EnvironmentVars: &envParams})
+	if apiResponse.IsNotSuccessful() {
+		cmd.ui.Failed(apiResponse.Message)
+		return
+	}
+
+	cmd.ui.Ok()
+	cmd.finish(updatedApp, restart)
+}
+
+// sayEnvDiff prints the variables that are added, changed and removed, and says whether there
+// were any.
+func (cmd *SetEnv) sayEnvDiff(oldVars, newVars map[string]string) (changed bool) {
+	allVars := map[string]string{}
+	for name := range oldVars {
+		allVars[name] = ""
+	}
+	for name := range newVars {
+		allVars[name] = ""
+	}
+
+	for _, name := range sortedEnvKeys(allVars) {
+		oldValue, wasSet := oldVars[name]
+		newValue, isSet := newVars[name]
+
+		if wasSet == isSet && oldValue == newValue {
+			continue
+		}
+		cmd.ui.Say("%s", diffLine(name, oldValue, wasSet, newValue, isSet))
+		changed = true
+	}
+	return
+}
+
+// diffLine describes how a value differs between two sides, in green when it is only set on the
+// new side, in red when it is only set on the old side, and in yellow when it changed.
+func diffLine(name, oldValue string, wasSet bool, newValue string, isSet bool) string {
+	switch {
+	case !wasSet:
+		return terminal.SuccessColor(fmt.Sprintf("+ %s: %s", name, newValue))
+	case !isSet:
+		return terminal.FailureColor(fmt.Sprintf("- %s: %s", name, oldValue))
+	default:
+		return terminal.WarningColor(fmt.Sprintf("~ %s: %s -> %s", name, oldValue, newValue))
+	}
+}
+
+func (cmd *SetEnv) finish(updatedApp models.Application, restart bool) {
+	if restart {
+		cmd.ui.Say("")
+		cmd.restarter.ApplicationRestart(updatedApp)
+		return
+	}
 	cmd.ui.Say("TIP: Use '%s' to ensure your env variable changes take effect", terminal.CommandColor(cf.Name()+" push"))
 }
@@ -6,6 +6,9 @@ import (
 	"cf/models"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	testapi "testhelpers/api"
 	testassert "testhelpers/assert"
 	testcmd "testhelpers/commands"
@@ -150,6 +153,146 @@ var _ = Describe("set-env command", func() {
 		args = []string{}
 		ui = callSetEnv(args, reqFactory, appRepo)
 		Expect(ui.FailedWithUsage).To(BeTrue())
+
+		args = []string{"--replace", "my-app", "DATABASE_URL", "..."}
+		ui = callSetEnv(args, reqFactory, appRepo)
+		Expect(ui.FailedWithUsage).To(BeTrue())
+
+		args = []string{"--from-file", "vars.env", "my-app", "DATABASE_URL", "..."}
+		ui = callSetEnv(args, reqFactory, appRepo)
+		Expect(ui.FailedWithUsage).To(BeTrue())
+
+		args = []string{"--from-file", "vars.env", "my-app"}
+		ui = callSetEnv(args, reqFactory, appRepo)
+		Expect(ui.FailedWithUsage).To(BeFalse())
+	})
+
+	Describe("setting env variables from a file", func() {
+		var (
+			reqFactory *testreq.FakeReqFactory
+			appRepo    *testapi.FakeApplicationRepository
+			restarter  *testcmd.FakeAppRestarter
+			dir        string
+		)
+
+		BeforeEach(func() {
+			app := models.Application{}
+			app.Name = "my-app"
+			app.Guid = "my-app-guid"
+			app.EnvironmentVars = map[string]string{"KEPT": "same", "CHANGED": "old", "REMOVED": "gone"}
+			reqFactory = &testreq.FakeReqFactory{Application: app, LoginSuccess: true, TargetedSpaceSuccess: true}
+
+			updatedApp := models.Application{}
+			updatedApp.Guid = "my-app-guid"
+			appRepo = &testapi.FakeApplicationRepository{UpdateAppResult: updatedApp}
+			restarter = &testcmd.FakeAppRestarter{}
+
+			var err error
+			dir, err = ioutil.TempDir("", "set-env")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		writeFile := func(name, contents string) (path string) {
+			path = filepath.Join(dir, name)
+			Expect(ioutil.WriteFile(path, []byte(contents), os.ModePerm)).NotTo(HaveOccurred())
+			return
+		}
+
+		callSetEnvFromFile := func(args ...string) (ui *testterm.FakeUI) {
+			ui = new(testterm.FakeUI)
+			cmd := NewSetEnv(ui, testconfig.NewRepositoryWithDefaults(), appRepo, restarter)
+			testcmd.RunCommand(cmd, testcmd.NewContext("set-env", args), reqFactory)
+			return
+		}
+
+		It("sets all the variables in a dotenv file with a single update and shows what changed", func() {
+			path := writeFile("vars.env", "# database\n"+
+				"CHANGED=new # not part of the value\n"+
+				"export ADDED=\"quoted \\\"value\\\"\\nwith a newline\"\n"+
+				"\n"+
+				"KEPT='same'\n")
+
+			ui := callSetEnvFromFile("--from-file", path, "my-app")
+
+			Expect(appRepo.UpdateAppGuid).To(Equal("my-app-guid"))
+			Expect(*appRepo.UpdateParams.EnvironmentVars).To(Equal(map[string]string{
+				"ADDED":   "quoted \"value\"\nwith a newline",
+				"CHANGED": "new",
+				"KEPT":    "same",
+				"REMOVED": "gone",
+			}))
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"Setting env variables from", path, "my-app", "my-org", "my-space", "my-user"},
+				{"+ ADDED: quoted \"value\""},
+				{"~ CHANGED: old -> new"},
+				{"OK"},
+				{"TIP"},
+			})
+			testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{{"KEPT"}, {"REMOVED"}})
+			Expect(restarter.AppToRestart.Guid).To(Equal(""))
+		})
+
+		It("makes the env exactly match a YAML file with --replace and restarts once with --restart", func() {
+			path := writeFile("vars.yml", "KEPT: same\nCHANGED: new\nPORT: 8080\n")
+
+			ui := callSetEnvFromFile("--from-file", path, "--replace", "--restart", "my-app")
+
+			Expect(*appRepo.UpdateParams.EnvironmentVars).To(Equal(map[string]string{
+				"CHANGED": "new",
+				"KEPT":    "same",
+				"PORT":    "8080",
+			}))
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"~ CHANGED: old -> new"},
+				{"+ PORT: 8080"},
+				{"- REMOVED: gone"},
+				{"OK"},
+			})
+			testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{{"TIP"}})
+			Expect(restarter.AppToRestart.Guid).To(Equal("my-app-guid"))
+		})
+
+		It("does not update the app when nothing changes", func() {
+			path := writeFile("vars.env", "KEPT=same\n")
+
+			ui := callSetEnvFromFile("--from-file", path, "--restart", "my-app")
+
+			Expect(appRepo.UpdateAppGuid).To(Equal(""))
+			Expect(restarter.AppToRestart.Guid).To(Equal(""))
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"OK"}, {"No env variables changed"}})
+		})
+
+		It("fails when the file cannot be read", func() {
+			ui := callSetEnvFromFile("--from-file", writeFile("vars.env", "KEPT=same\nnot a variable\n"), "my-app")
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Error reading"}, {"Line 2 is not NAME=VALUE"}})
+
+			ui = callSetEnvFromFile("--from-file", writeFile("vars.env", "KEPT=\"same\n"), "my-app")
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"line 1 has no closing quote"}})
+
+			ui = callSetEnvFromFile("--from-file", writeFile("vars.yml", "KEPT:\n  - same\n"), "my-app")
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"KEPT has to be a string"}})
+
+			ui = callSetEnvFromFile("--from-file", writeFile("vars.env", "# nothing\n"), "my-app")
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"There are no env variables in"}})
+
+			ui = callSetEnvFromFile("--from-file", filepath.Join(dir, "missing.env"), "my-app")
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Error reading", "missing.env"}})
+
+			Expect(appRepo.UpdateAppGuid).To(Equal(""))
+		})
+
+		It("fails when the update fails", func() {
+			appRepo.UpdateErr = true
+
+			ui := callSetEnvFromFile("--from-file", writeFile("vars.env", "CHANGED=new\n"), "--restart", "my-app")
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Error updating app."}})
+			Expect(restarter.AppToRestart.Guid).To(Equal(""))
+		})
 	})
 })
 
@@ -157,7 +300,7 @@ func callSetEnv(args []string, reqFactory *testreq.FakeReqFactory, appRepo api.A
 	ui = new(testterm.FakeUI)
 	ctxt := testcmd.NewContext("set-env", args)
 	configRepo := testconfig.NewRepositoryWithDefaults()
-	cmd := NewSetEnv(ui, configRepo, appRepo)
+	cmd := NewSetEnv(ui, configRepo, appRepo, &testcmd.FakeAppRestarter{})
 	testcmd.RunCommand(cmd, ctxt, reqFactory)
 	return
 }
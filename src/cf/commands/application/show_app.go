@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"github.com/codegangsta/cli"
 	"strings"
+	"time"
 )
 
 type ShowApp struct {
@@ -20,10 +21,12 @@ type ShowApp struct {
 	appSummaryRepo   api.AppSummaryRepository
 	appInstancesRepo api.AppInstancesRepository
 	appReq           requirements.ApplicationRequirement
+	now              func() time.Time
 }
 
 type ApplicationDisplayer interface {
 	ShowApp(app models.Application)
+	WithUI(ui terminal.UI) ApplicationDisplayer
 }
 
 func NewShowApp(ui terminal.UI, config configuration.Reader, appSummaryRepo api.AppSummaryRepository, appInstancesRepo api.AppInstancesRepository) (cmd *ShowApp) {
@@ -32,9 +35,17 @@ func NewShowApp(ui terminal.UI, config configuration.Reader, appSummaryRepo api.
 	cmd.config = config
 	cmd.appSummaryRepo = appSummaryRepo
 	cmd.appInstancesRepo = appInstancesRepo
+	cmd.now = time.Now
 	return
 }
 
+// WithUI returns a copy of the displayer that prints to ui.
+func (cmd *ShowApp) WithUI(ui terminal.UI) ApplicationDisplayer {
+	displayer := *cmd
+	displayer.ui = ui
+	return &displayer
+}
+
 func (cmd *ShowApp) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
 	if len(c.Args()) < 1 {
 		err = errors.New("Incorrect Usage")
@@ -54,6 +65,18 @@ func (cmd *ShowApp) GetRequirements(reqFactory requirements.Factory, c *cli.Cont
 
 func (cmd *ShowApp) Run(c *cli.Context) {
 	app := cmd.appReq.GetApplication()
+
+	if c.Bool("watch") {
+		interval := c.Int("interval")
+		if interval <= 0 {
+			cmd.ui.Failed("Interval must be a positive number of seconds")
+			return
+		}
+
+		cmd.watchApp(app, time.Duration(interval)*time.Second)
+		return
+	}
+
 	cmd.ShowApp(app)
 }
 
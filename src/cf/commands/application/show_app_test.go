@@ -118,6 +118,84 @@ var _ = Describe("Testing with ginkgo", func() {
 
 		testDisplayingAppSummaryWithErrorCode(cf.APP_NOT_STAGED)
 	})
+	Describe("watching an app", func() {
+		var (
+			reqFactory       *testreq.FakeReqFactory
+			appSummaryRepo   *testapi.FakeAppSummaryRepo
+			appInstancesRepo *testapi.FakeAppInstancesRepo
+		)
+
+		BeforeEach(func() {
+			app := models.Application{}
+			app.Name = "my-app"
+			app.Guid = "my-app-guid"
+			reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true, Application: app}
+
+			appSummary := models.AppSummary{}
+			appSummary.State = "started"
+			appSummary.InstanceCount = 2
+			appSummary.RunningInstances = 1
+			appSummary.Memory = 256
+			appSummaryRepo = &testapi.FakeAppSummaryRepo{GetSummarySummary: appSummary}
+
+			instance := func(state models.InstanceState, cpu float64, memUsage uint64) models.AppInstanceFields {
+				return models.AppInstanceFields{State: state, CpuUsage: cpu, MemUsage: memUsage, MemQuota: 100}
+			}
+			appInstancesRepo = &testapi.FakeAppInstancesRepo{
+				GetInstancesResponses: [][]models.AppInstanceFields{
+					{instance(models.InstanceRunning, 0, 0), instance(models.InstanceStarting, 0, 0)},
+					{instance(models.InstanceRunning, 0.5, 50), instance(models.InstanceRunning, 0, 0)},
+					{instance(models.InstanceRunning, 1, 100), instance(models.InstanceFlapping, 0, 0)},
+				},
+			}
+		})
+
+		It("redraws the stats of the instances until the user quits", func() {
+			ui := &testterm.FakeUI{DashboardQuitAfterFrames: 3}
+			cmd := NewShowApp(ui, testconfig.NewRepositoryWithDefaults(), appSummaryRepo, appInstancesRepo)
+			testcmd.RunCommand(cmd, testcmd.NewContext("app", []string{"--watch", "--interval", "5", "my-app"}), reqFactory)
+
+			Expect(len(ui.DashboardFrames)).To(Equal(3))
+			Expect(ui.DashboardWaits).To(Equal([]time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second}))
+			Expect(ui.DashboardClosed).To(BeTrue())
+			Expect(ui.Outputs).To(BeEmpty())
+
+			lastFrame := ui.DashboardFrames[2]
+			testassert.SliceContains(lastFrame, testassert.Lines{
+				{"Watching app", "my-app", "my-org", "my-space", "my-user", "5s", "q to quit"},
+				{"requested state", "started"},
+				{"instances", "1/2"},
+				{"state", "since", "cpu", "memory", "disk", "cpu history", "memory history"},
+				{"#0", "running", "100.0%", "▁▅█", "▁▅█"},
+				{"#1", "flapping (was running)", "▁▁▁"},
+				{"recent events"},
+				{"#1 starting -> running"},
+				{"#1 running -> flapping"},
+			})
+		})
+
+		It("shows errors on the screen and keeps polling", func() {
+			appInstancesRepo.GetInstancesErrorCodes = []string{"500"}
+
+			ui := &testterm.FakeUI{DashboardQuitAfterFrames: 2}
+			cmd := NewShowApp(ui, testconfig.NewRepositoryWithDefaults(), appSummaryRepo, appInstancesRepo)
+			testcmd.RunCommand(cmd, testcmd.NewContext("app", []string{"--watch", "my-app"}), reqFactory)
+
+			Expect(len(ui.DashboardFrames)).To(Equal(2))
+			Expect(ui.DashboardWaits[0]).To(Equal(3 * time.Second))
+			testassert.SliceContains(ui.DashboardFrames[0], testassert.Lines{{"Error staging app"}})
+			testassert.SliceContains(ui.DashboardFrames[1], testassert.Lines{{"#0", "running"}})
+		})
+
+		It("fails when the interval is not positive", func() {
+			ui := &testterm.FakeUI{}
+			cmd := NewShowApp(ui, testconfig.NewRepositoryWithDefaults(), appSummaryRepo, appInstancesRepo)
+			testcmd.RunCommand(cmd, testcmd.NewContext("app", []string{"--watch", "--interval", "0", "my-app"}), reqFactory)
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{{"FAILED"}, {"Interval must be a positive"}})
+			Expect(ui.DashboardFrames).To(BeNil())
+		})
+	})
 })
 
 func testDisplayingAppSummaryWithErrorCode(errorCode string) {
@@ -0,0 +1,172 @@
+package application
+
+import (
+	"cf/api"
+	"cf/configuration"
+	"cf/models"
+	"cf/requirements"
+	"cf/terminal"
+	"encoding/json"
+	"errors"
+	"github.com/codegangsta/cli"
+	"sort"
+	"time"
+)
+
+type SpaceEvents struct {
+	ui             terminal.UI
+	config         configuration.Reader
+	appSummaryRepo api.AppSummaryRepository
+	eventsRepo     api.AppEventsRepository
+}
+
+type spaceEvent struct {
+	appName string
+	event   models.EventFields
+}
+
+type spaceEventJSON struct {
+	Time        string `json:"time"`
+	App         string `json:"app"`
+	Event       string `json:"event"`
+	Description string `json:"description"`
+}
+
+type spaceEventsByTime []spaceEvent
+
+func (events spaceEventsByTime) Len() int      { return len(events) }
+func (events spaceEventsByTime) Swap(i, j int) { events[i], events[j] = events[j], events[i] }
+func (events spaceEventsByTime) Less(i, j int) bool {
+	return events[i].event.Timestamp.Before(events[j].event.Timestamp)
+}
+
+func NewSpaceEvents(ui terminal.UI, config configuration.Reader, appSummaryRepo api.AppSummaryRepository, eventsRepo api.AppEventsRepository) (cmd *SpaceEvents) {
+	cmd = new(SpaceEvents)
+	cmd.ui = ui
+	cmd.config = config
+	cmd.appSummaryRepo = appSummaryRepo
+	cmd.eventsRepo = eventsRepo
+	return
+}
+
+func (cmd *SpaceEvents) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
+	if len(c.Args()) != 0 {
+		err = errors.New("Incorrect Usage")
+		cmd.ui.FailWithUsage(c, "space-events")
+		return
+	}
+
+	reqs = []requirements.Requirement{
+		reqFactory.NewLoginRequirement(),
+		reqFactory.NewTargetedSpaceRequirement(),
+	}
+	return
+}
+
+func (cmd *SpaceEvents) Run(c *cli.Context) {
+	query, err := eventQueryFromFlags(c, time.Now())
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+		return
+	}
+
+	asJSON := c.Bool("json")
+	if !asJSON {
+		cmd.ui.Say("Getting events for all apps in org %s / space %s as %s...\n",
+			terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+			terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+			terminal.EntityNameColor(cmd.config.Username()),
+		)
+	}
+
+	events, err := cmd.listSpaceEvents(query)
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+		return
+	}
+
+	if asJSON {
+		cmd.sayJSON(events)
+		return
+	}
+
+	if len(events) == 0 {
+		cmd.ui.Say("No events for apps in space %s", terminal.EntityNameColor(cmd.config.SpaceFields().Name))
+		return
+	}
+
+	table := cmd.ui.Table([]string{"time", "app", "event", "description"})
+	rows := [][]string{}
+	for _, spaceEvent := range events {
+		rows = append(rows, []string{
+			spaceEvent.event.Timestamp.Local().Format(TIMESTAMP_FORMAT),
+			spaceEvent.appName,
+			spaceEvent.event.Name,
+			spaceEvent.event.Description,
+		})
+	}
+	table.Print(rows)
+}
+
+// listSpaceEvents lists the events of every app in the targeted space, merged in order of time.
+func (cmd *SpaceEvents) listSpaceEvents(query models.EventQuery) (events []spaceEvent, err error) {
+	apiResponse := cmd.eventsRepo.ListSpaceEvents(cmd.config.SpaceFields().Guid, query, func(appName string, event models.EventFields) bool {
+		events = append(events, spaceEvent{appName: appName, event: event})
+		return true
+	})
+
+	// FIXME: needs semantic versioning
+	if apiResponse.IsNotFound() {
+		events, err = cmd.listEventsOfEachApp(query)
+	} else if apiResponse.IsNotSuccessful() {
+		err = errors.New(apiResponse.Message)
+	}
+	if err != nil {
+		return
+	}
+
+	sort.Stable(spaceEventsByTime(events))
+	return
+}
+
+// listEventsOfEachApp lists the events app by app, for Cloud Controllers that cannot list the
+// events of a space. It misses the events of deleted apps.
+func (cmd *SpaceEvents) listEventsOfEachApp(query models.EventQuery) (events []spaceEvent, err error) {
+	apps, apiResponse := cmd.appSummaryRepo.GetSummariesInCurrentSpace()
+	if apiResponse.IsNotSuccessful() {
+		err = errors.New(apiResponse.Message)
+		return
+	}
+
+	for _, app := range apps {
+		appName := app.Name
+		apiResponse = cmd.eventsRepo.ListEvents(app.Guid, query, func(event models.EventFields) bool {
+			events = append(events, spaceEvent{appName: appName, event: event})
+			return true
+		})
+		if apiResponse.IsNotSuccessful() {
+			err = errors.New("Failed fetching events of app " + appName + ".\n" + apiResponse.Message)
+			return
+		}
+	}
+	return
+}
+
+func (cmd *SpaceEvents) sayJSON(events []spaceEvent) {
+	eventsJSON := []spaceEventJSON{}
+	for _, spaceEvent := range events {
+		eventsJSON = append(eventsJSON, spaceEventJSON{
+			Time:        spaceEvent.event.Timestamp.UTC().Format(time.RFC3339),
+			App:         spaceEvent.appName,
+			Event:       spaceEvent.event.Name,
+			Description: spaceEvent.event.Description,
+		})
+	}
+
+	bytes, err := json.MarshalIndent(eventsJSON, "", "  ")
+	if err != nil {
+		cmd.ui.Failed("Error writing events as JSON\n%s", err.Error())
+		return
+	}
+	cmd.ui.Say("%s", bytes)
+}
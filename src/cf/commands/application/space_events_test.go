@@ -0,0 +1,191 @@
+package application_test
+
+import (
+	. "cf/commands/application"
+	"cf/models"
+	"cf/net"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+	testapi "testhelpers/api"
+	testassert "testhelpers/assert"
+	testcmd "testhelpers/commands"
+	testconfig "testhelpers/configuration"
+	testreq "testhelpers/requirements"
+	testterm "testhelpers/terminal"
+	"time"
+)
+
+var _ = Describe("space-events command", func() {
+	var (
+		reqFactory     *testreq.FakeReqFactory
+		appSummaryRepo *testapi.FakeAppSummaryRepo
+		eventsRepo     *testapi.FakeAppEventsRepo
+		start          time.Time
+	)
+
+	newEvent := func(name string, minutes int, description string) (event models.EventFields) {
+		event.Guid = description
+		event.Name = name
+		event.Timestamp = start.Add(time.Duration(minutes) * time.Minute)
+		event.Description = description
+		return
+	}
+
+	BeforeEach(func() {
+		reqFactory = &testreq.FakeReqFactory{LoginSuccess: true, TargetedSpaceSuccess: true}
+		start = time.Date(2014, 1, 21, 10, 0, 0, 0, time.UTC)
+
+		app1 := models.AppSummary{}
+		app1.Name = "app1"
+		app1.Guid = "app1-guid"
+		app2 := models.AppSummary{}
+		app2.Name = "app2"
+		app2.Guid = "app2-guid"
+		appSummaryRepo = &testapi.FakeAppSummaryRepo{GetSummariesInCurrentSpaceApps: []models.AppSummary{app1, app2}}
+
+		eventsRepo = &testapi.FakeAppEventsRepo{
+			SpaceEvents: []testapi.FakeSpaceEvent{
+				{AppName: "app1", Event: newEvent("app.crash", 0, "first crash")},
+				{AppName: "app2", Event: newEvent("app.crash", 10, "second crash")},
+				{AppName: "app1", Event: newEvent("audit.app.update", 20, "scaled up")},
+			},
+		}
+	})
+
+	callSpaceEvents := func(args ...string) (ui *testterm.FakeUI) {
+		ui = &testterm.FakeUI{}
+		cmd := NewSpaceEvents(ui, testconfig.NewRepositoryWithDefaults(), appSummaryRepo, eventsRepo)
+		testcmd.RunCommand(cmd, testcmd.NewContext("space-events", args), reqFactory)
+		return
+	}
+
+	It("requires a login and a targeted space", func() {
+		callSpaceEvents()
+		Expect(testcmd.CommandDidPassRequirements).To(BeTrue())
+
+		reqFactory.TargetedSpaceSuccess = false
+		callSpaceEvents()
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+
+		reqFactory.TargetedSpaceSuccess = true
+		reqFactory.LoginSuccess = false
+		callSpaceEvents()
+		Expect(testcmd.CommandDidPassRequirements).To(BeFalse())
+	})
+
+	It("fails with usage when given arguments", func() {
+		ui := callSpaceEvents("my-app")
+		Expect(ui.FailedWithUsage).To(BeTrue())
+	})
+
+	It("lists the events of all apps in the space with one query", func() {
+		ui := callSpaceEvents("--type", "crash,update")
+
+		Expect(eventsRepo.SpaceGuid).To(Equal("my-space-guid"))
+		Expect(eventsRepo.SpaceQuery.Types).To(Equal([]string{"app.crash", "audit.app.update"}))
+		Expect(eventsRepo.AppGuids).To(BeEmpty())
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"Getting events for all apps in org", "my-org", "my-space", "my-user"},
+			{"time", "app", "event", "description"},
+			{"app1", "app.crash", "first crash"},
+			{"app2", "app.crash", "second crash"},
+			{"app1", "audit.app.update", "scaled up"},
+		})
+	})
+
+	It("lists the events of deleted apps", func() {
+		eventsRepo.SpaceEvents = append(eventsRepo.SpaceEvents,
+			testapi.FakeSpaceEvent{AppName: "deleted-app", Event: newEvent("audit.app.delete-request", 5, "recursive: true")})
+
+		ui := callSpaceEvents()
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"app1", "app.crash", "first crash"},
+			{"deleted-app", "audit.app.delete-request", "recursive: true"},
+			{"app2", "app.crash", "second crash"},
+		})
+	})
+
+	It("prints the events as JSON", func() {
+		ui := callSpaceEvents("--json")
+
+		Expect(strings.Join(ui.Outputs, "\n")).To(Equal(`[
+  {
+    "time": "2014-01-21T10:00:00Z",
+    "app": "app1",
+    "event": "app.crash",
+    "description": "first crash"
+  },
+  {
+    "time": "2014-01-21T10:10:00Z",
+    "app": "app2",
+    "event": "app.crash",
+    "description": "second crash"
+  },
+  {
+    "time": "2014-01-21T10:20:00Z",
+    "app": "app1",
+    "event": "audit.app.update",
+    "description": "scaled up"
+  }
+]`))
+	})
+
+	It("says when there are no events", func() {
+		eventsRepo.SpaceEvents = nil
+
+		ui := callSpaceEvents()
+		testassert.SliceContains(ui.Outputs, testassert.Lines{{"No events for apps in space", "my-space"}})
+
+		ui = callSpaceEvents("--json")
+		Expect(ui.Outputs).To(Equal([]string{"[]"}))
+	})
+
+	It("fails when the events of the space cannot be listed", func() {
+		eventsRepo.SpaceApiResponse = net.NewApiResponseWithMessage("Error listing events")
+
+		ui := callSpaceEvents()
+
+		testassert.SliceContains(ui.Outputs, testassert.Lines{
+			{"FAILED"},
+			{"Error listing events"},
+		})
+	})
+
+	Context("when the Cloud Controller cannot list the events of a space", func() {
+		BeforeEach(func() {
+			eventsRepo.SpaceApiResponse = net.NewNotFoundApiResponse("Unknown request")
+			eventsRepo.EventsByApp = map[string][]models.EventFields{
+				"app1-guid": {newEvent("app.crash", 0, "first crash"), newEvent("audit.app.update", 20, "scaled up")},
+				"app2-guid": {newEvent("app.crash", 10, "second crash")},
+			}
+		})
+
+		It("lists the events of each app in the space in order of time", func() {
+			ui := callSpaceEvents("--type", "crash,update")
+
+			Expect(eventsRepo.AppGuids).To(Equal([]string{"app1-guid", "app2-guid"}))
+			Expect(eventsRepo.Queries[1].Types).To(Equal([]string{"app.crash", "audit.app.update"}))
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"time", "app", "event", "description"},
+				{"app1", "app.crash", "first crash"},
+				{"app2", "app.crash", "second crash"},
+				{"app1", "audit.app.update", "scaled up"},
+			})
+			testassert.SliceDoesNotContain(ui.Outputs, testassert.Lines{{"Unknown request"}})
+		})
+
+		It("fails when the events of an app cannot be listed", func() {
+			eventsRepo.ApiResponse = net.NewApiResponseWithMessage("Error listing events")
+
+			ui := callSpaceEvents()
+
+			testassert.SliceContains(ui.Outputs, testassert.Lines{
+				{"FAILED"},
+				{"Failed fetching events of app app1."},
+				{"Error listing events"},
+			})
+		})
+	})
+})
@@ -42,6 +42,7 @@ type Start struct {
 type ApplicationStarter interface {
 	SetStartTimeoutSeconds(timeout int)
 	ApplicationStart(app models.Application) (updatedApp models.Application, err error)
+	WithUI(ui terminal.UI) ApplicationStarter
 }
 
 func NewStart(ui terminal.UI, config configuration.Reader, appDisplayer ApplicationDisplayer, appRepo api.ApplicationRepository, appInstancesRepo api.AppInstancesRepository, logRepo api.LogsRepository) (cmd *Start) {
@@ -78,6 +79,14 @@ func NewStart(ui terminal.UI, config configuration.Reader, appDisplayer Applicat
 	return
 }
 
+// WithUI returns a copy of the starter that prints to ui, for starting apps side by side.
+func (cmd *Start) WithUI(ui terminal.UI) ApplicationStarter {
+	starter := *cmd
+	starter.ui = ui
+	starter.appDisplayer = cmd.appDisplayer.WithUI(ui)
+	return &starter
+}
+
 func (cmd *Start) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
 	if len(c.Args()) == 0 {
 		err = errors.New("Incorrect Usage")
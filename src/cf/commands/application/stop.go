@@ -12,6 +12,7 @@ import (
 
 type ApplicationStopper interface {
 	ApplicationStop(app models.Application) (updatedApp models.Application, err error)
+	WithUI(ui terminal.UI) ApplicationStopper
 }
 
 type Stop struct {
@@ -30,6 +31,13 @@ func NewStop(ui terminal.UI, config configuration.Reader, appRepo api.Applicatio
 	return
 }
 
+// WithUI returns a copy of the stopper that prints to ui, for stopping apps side by side.
+func (cmd *Stop) WithUI(ui terminal.UI) ApplicationStopper {
+	stopper := *cmd
+	stopper.ui = ui
+	return &stopper
+}
+
 func (cmd *Stop) GetRequirements(reqFactory requirements.Factory, c *cli.Context) (reqs []requirements.Requirement, err error) {
 	if len(c.Args()) == 0 {
 		err = errors.New("Incorrect Usage")
@@ -59,7 +59,9 @@ func (cmd CreateBuildpack) Run(c *cli.Context) {
 
 	dir := c.Args()[1]
 
-	apiResponse = cmd.buildpackBitsRepo.UploadBuildpack(buildpack, dir)
+	progressBar := cmd.ui.ProgressBar()
+	apiResponse = cmd.buildpackBitsRepo.UploadBuildpack(buildpack, dir, progressBar.Update)
+	progressBar.Done()
 	if apiResponse.IsNotSuccessful() {
 		cmd.ui.Failed(apiResponse.Message)
 		return
@@ -103,7 +103,9 @@ func (cmd *UpdateBuildpack) Run(c *cli.Context) {
 	}
 
 	if dir != "" {
-		apiResponse := cmd.buildpackBitsRepo.UploadBuildpack(buildpack, dir)
+		progressBar := cmd.ui.ProgressBar()
+		apiResponse := cmd.buildpackBitsRepo.UploadBuildpack(buildpack, dir, progressBar.Update)
+		progressBar.Done()
 		if apiResponse.IsNotSuccessful() {
 			cmd.ui.Failed("Error uploading buildpack %s\n%s", terminal.EntityNameColor(buildpack.Name), apiResponse.Message)
 			return
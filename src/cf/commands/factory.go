@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"cf"
 	"cf/api"
 	"cf/commands/application"
 	"cf/commands/buildpack"
@@ -34,6 +35,7 @@ func NewFactory(ui terminal.UI, config configuration.ReadWriter, manifestRepo ma
 	factory.cmdsByName["ssh"] = application.NewSsh(ui, config, repoLocator.GetAppSshRepository())
 	factory.cmdsByName["auth"] = NewAuthenticate(ui, config, repoLocator.GetAuthenticationRepository())
 	factory.cmdsByName["buildpacks"] = buildpack.NewListBuildpacks(ui, repoLocator.GetBuildpackRepository())
+	factory.cmdsByName["create-app-manifest"] = application.NewCreateAppManifest(ui, config, repoLocator.GetAppSummaryRepository(), manifestRepo)
 	factory.cmdsByName["create-buildpack"] = buildpack.NewCreateBuildpack(ui, repoLocator.GetBuildpackRepository(), repoLocator.GetBuildpackBitsRepository())
 	factory.cmdsByName["create-domain"] = domain.NewCreateDomain(ui, config, repoLocator.GetDomainRepository())
 	factory.cmdsByName["create-org"] = organization.NewCreateOrg(ui, config, repoLocator.GetOrganizationRepository())
@@ -55,7 +57,9 @@ func NewFactory(ui terminal.UI, config configuration.ReadWriter, manifestRepo ma
 	factory.cmdsByName["delete-space"] = space.NewDeleteSpace(ui, config, repoLocator.GetSpaceRepository())
 	factory.cmdsByName["delete-user"] = user.NewDeleteUser(ui, config, repoLocator.GetUserRepository())
 	factory.cmdsByName["domains"] = domain.NewListDomains(ui, config, repoLocator.GetDomainRepository())
-	factory.cmdsByName["env"] = application.NewEnv(ui, config)
+	factory.cmdsByName["download"] = application.NewDownloadApp(ui, config, repoLocator.GetApplicationBitsRepository(), cf.ApplicationZipper{})
+	factory.cmdsByName["env"] = application.NewEnv(ui, config, repoLocator.GetApplicationRepository())
+	factory.cmdsByName["env-diff"] = application.NewEnvDiff(ui, config, repoLocator.GetApplicationRepository(), repoLocator.GetAppSummaryRepository(), repoLocator.GetOrganizationRepository(), repoLocator.GetSpaceRepository())
 	factory.cmdsByName["events"] = application.NewEvents(ui, config, repoLocator.GetAppEventsRepository())
 	factory.cmdsByName["files"] = application.NewFiles(ui, config, repoLocator.GetAppFilesRepository())
 	factory.cmdsByName["login"] = NewLogin(ui, config, repoLocator.GetAuthenticationRepository(), repoLocator.GetEndpointRepository(), repoLocator.GetOrganizationRepository(), repoLocator.GetSpaceRepository())
@@ -79,11 +83,11 @@ func NewFactory(ui terminal.UI, config configuration.ReadWriter, manifestRepo ma
 	factory.cmdsByName["service-brokers"] = servicebroker.NewListServiceBrokers(ui, config, repoLocator.GetServiceBrokerRepository())
 	factory.cmdsByName["services"] = service.NewListServices(ui, config, repoLocator.GetServiceSummaryRepository())
 	factory.cmdsByName["migrate-service-instances"] = service.NewMigrateServiceInstances(ui, config, repoLocator.GetServiceRepository())
-	factory.cmdsByName["set-env"] = application.NewSetEnv(ui, config, repoLocator.GetApplicationRepository())
 	factory.cmdsByName["set-org-role"] = user.NewSetOrgRole(ui, config, repoLocator.GetUserRepository())
 	factory.cmdsByName["set-quota"] = organization.NewSetQuota(ui, config, repoLocator.GetQuotaRepository())
 	factory.cmdsByName["create-shared-domain"] = domain.NewCreateSharedDomain(ui, config, repoLocator.GetDomainRepository())
 	factory.cmdsByName["space"] = space.NewShowSpace(ui, config)
+	factory.cmdsByName["space-events"] = application.NewSpaceEvents(ui, config, repoLocator.GetAppSummaryRepository(), repoLocator.GetAppEventsRepository())
 	factory.cmdsByName["space-users"] = user.NewSpaceUsers(ui, config, repoLocator.GetSpaceRepository(), repoLocator.GetUserRepository())
 	factory.cmdsByName["spaces"] = space.NewListSpaces(ui, config, repoLocator.GetSpaceRepository())
 	factory.cmdsByName["stacks"] = NewListStacks(ui, config, repoLocator.GetStackRepository())
@@ -105,7 +109,8 @@ func NewFactory(ui terminal.UI, config configuration.ReadWriter, manifestRepo ma
 	displayApp := application.NewShowApp(ui, config, repoLocator.GetAppSummaryRepository(), repoLocator.GetAppInstancesRepository())
 	start := application.NewStart(ui, config, displayApp, repoLocator.GetApplicationRepository(), repoLocator.GetAppInstancesRepository(), repoLocator.GetLogsRepository())
 	stop := application.NewStop(ui, config, repoLocator.GetApplicationRepository())
-	restart := application.NewRestart(ui, start, stop)
+	restartAppInstance := application.NewRestartAppInstance(ui, config, repoLocator.GetAppInstancesRepository())
+	restart := application.NewRestart(ui, start, stop, restartAppInstance)
 	bind := service.NewBindService(ui, config, repoLocator.GetServiceBindingRepository())
 
 	factory.cmdsByName["app"] = displayApp
@@ -113,8 +118,13 @@ func NewFactory(ui terminal.UI, config configuration.ReadWriter, manifestRepo ma
 	factory.cmdsByName["start"] = start
 	factory.cmdsByName["stop"] = stop
 	factory.cmdsByName["restart"] = restart
-	factory.cmdsByName["push"] = application.NewPush(ui, config, manifestRepo, start, stop, bind, repoLocator.GetApplicationRepository(), repoLocator.GetDomainRepository(), repoLocator.GetRouteRepository(), repoLocator.GetStackRepository(), repoLocator.GetServiceRepository(), repoLocator.GetApplicationBitsRepository())
+	factory.cmdsByName["restart-app-instance"] = restartAppInstance
+	factory.cmdsByName["copy-source"] = application.NewCopySource(ui, config, repoLocator.GetApplicationRepository(), repoLocator.GetApplicationBitsRepository(), repoLocator.GetOrganizationRepository(), repoLocator.GetSpaceRepository(), start, stop)
+	factory.cmdsByName["rollback"] = application.NewRollback(ui, config, repoLocator.GetApplicationRepository(), repoLocator.GetDropletStashRepository(), start, stop)
+	factory.cmdsByName["push"] = application.NewPush(ui, config, manifestRepo, start, stop, bind, repoLocator.GetApplicationRepository(), repoLocator.GetDomainRepository(), repoLocator.GetRouteRepository(), repoLocator.GetStackRepository(), repoLocator.GetServiceRepository(), repoLocator.GetUserProvidedServiceInstanceRepository(), repoLocator.GetApplicationBitsRepository(), repoLocator.GetDropletStashRepository(), repoLocator.GetAppSummaryRepository())
 	factory.cmdsByName["scale"] = application.NewScale(ui, config, restart, repoLocator.GetApplicationRepository())
+	factory.cmdsByName["set-env"] = application.NewSetEnv(ui, config, repoLocator.GetApplicationRepository(), restart)
+	factory.cmdsByName["autoscale"] = application.NewAutoscale(ui, config, repoLocator.GetApplicationRepository(), repoLocator.GetAppInstancesRepository(), cf.NewClock())
 
 	spaceRoleSetter := user.NewSetSpaceRole(ui, config, repoLocator.GetSpaceRepository(), repoLocator.GetUserRepository())
 	factory.cmdsByName["set-space-role"] = spaceRoleSetter
@@ -52,13 +52,13 @@ func (cmd CreateService) Run(c *cli.Context) {
 		return
 	}
 
-	offering, err := findOffering(offerings, offeringName)
+	offering, err := FindOffering(offerings, offeringName)
 	if err != nil {
 		cmd.ui.Failed(err.Error())
 		return
 	}
 
-	plan, err := findPlan(offering.Plans, planName)
+	plan, err := FindPlan(offering.Plans, planName)
 	if err != nil {
 		cmd.ui.Failed(err.Error())
 		return
@@ -78,7 +78,7 @@ func (cmd CreateService) Run(c *cli.Context) {
 	}
 }
 
-func findOffering(offerings []models.ServiceOffering, name string) (offering models.ServiceOffering, err error) {
+func FindOffering(offerings []models.ServiceOffering, name string) (offering models.ServiceOffering, err error) {
 	for _, offering := range offerings {
 		if name == offering.Label {
 			return offering, nil
@@ -89,7 +89,7 @@ func findOffering(offerings []models.ServiceOffering, name string) (offering mod
 	return
 }
 
-func findPlan(plans []models.ServicePlanFields, name string) (plan models.ServicePlanFields, err error) {
+func FindPlan(plans []models.ServicePlanFields, name string) (plan models.ServicePlanFields, err error) {
 	for _, plan := range plans {
 		if name == plan.Name {
 			return plan, nil
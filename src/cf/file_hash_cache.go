@@ -0,0 +1,140 @@
+package cf
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fileutils"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileHashCache remembers the SHA1 of files by their path, size and modification time, so that
+// files that did not change since the last push are not read again. A nil cache hashes every file.
+type FileHashCache struct {
+	path    string
+	mutex   sync.Mutex
+	loaded  bool
+	entries map[string]fileHashEntry
+}
+
+type fileHashEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Sha1    string `json:"sha1"`
+}
+
+// NewFileHashCache returns a cache that is read from and saved to path. The file is only read
+// once the first hash is asked for. With an empty path nothing is saved.
+func NewFileHashCache(path string) *FileHashCache {
+	return &FileHashCache{path: path}
+}
+
+// Sha1 returns the hex encoded SHA1 of the file at fullPath, reading it only when the cache has
+// no entry for the same size and modification time.
+func (cache *FileHashCache) Sha1(fullPath string, info os.FileInfo) (sha1 string, err error) {
+	if cache == nil {
+		return fileSha1(fullPath)
+	}
+
+	cache.mutex.Lock()
+	cache.load()
+	entry, found := cache.entries[fullPath]
+	cache.mutex.Unlock()
+
+	if found && entry.matches(info) {
+		sha1 = entry.Sha1
+		return
+	}
+
+	sha1, err = fileSha1(fullPath)
+	if err != nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	cache.entries[fullPath] = fileHashEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Sha1: sha1}
+	cache.mutex.Unlock()
+	return
+}
+
+// Save writes the cache to its file, leaving out the files that were changed or removed since
+// they were hashed.
+func (cache *FileHashCache) Save() (err error) {
+	if cache == nil || cache.path == "" {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if !cache.loaded {
+		return
+	}
+
+	for path, entry := range cache.entries {
+		info, statErr := os.Stat(path)
+		if statErr != nil || !entry.matches(info) {
+			delete(cache.entries, path)
+		}
+	}
+
+	data, err := json.Marshal(cache.entries)
+	if err != nil {
+		return
+	}
+
+	// write to a new file first, so that an interrupted save does not leave half a cache behind
+	file, err := fileutils.CreateFile(cache.path + ".new")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(data)
+	file.Close()
+	if err != nil {
+		os.Remove(file.Name())
+		return
+	}
+
+	return os.Rename(file.Name(), cache.path)
+}
+
+func (cache *FileHashCache) load() {
+	if cache.loaded {
+		return
+	}
+	cache.loaded = true
+	cache.entries = map[string]fileHashEntry{}
+
+	if cache.path == "" {
+		return
+	}
+
+	data, err := ioutil.ReadFile(filepath.Clean(cache.path))
+	if err != nil {
+		return
+	}
+
+	// a cache that cannot be read is started over
+	if json.Unmarshal(data, &cache.entries) != nil {
+		cache.entries = map[string]fileHashEntry{}
+	}
+}
+
+func (entry fileHashEntry) matches(info os.FileInfo) bool {
+	return entry.Size == info.Size() && entry.ModTime == info.ModTime().UnixNano()
+}
+
+func fileSha1(fullPath string) (sha1Hash string, err error) {
+	h := sha1.New()
+
+	err = fileutils.CopyPathToWriter(fullPath, h)
+	if err != nil {
+		return
+	}
+
+	sha1Hash = fmt.Sprintf("%x", h.Sum(nil))
+	return
+}
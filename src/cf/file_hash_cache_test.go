@@ -0,0 +1,111 @@
+package cf_test
+
+import (
+	. "cf"
+	"fileutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var _ = Describe("FileHashCache", func() {
+	var (
+		dir       string
+		appFile   string
+		cachePath string
+	)
+
+	const helloSha1 = "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"
+
+	writeFile := func(contents string, modTime time.Time) os.FileInfo {
+		err := ioutil.WriteFile(appFile, []byte(contents), 0644)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.Chtimes(appFile, modTime, modTime)
+		Expect(err).NotTo(HaveOccurred())
+		info, err := os.Stat(appFile)
+		Expect(err).NotTo(HaveOccurred())
+		return info
+	}
+
+	withDir := func(test func()) {
+		fileutils.TempDir("file_hash_cache_test", func(tmpDir string, err error) {
+			Expect(err).NotTo(HaveOccurred())
+			dir = tmpDir
+			appFile = filepath.Join(dir, "app.rb")
+			cachePath = filepath.Join(dir, "cache", "file_hashes.json")
+			test()
+		})
+	}
+
+	It("only reads files again when their size or modification time changed", func() {
+		withDir(func() {
+			modTime := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+			cache := NewFileHashCache(cachePath)
+
+			sha1, err := cache.Sha1(appFile, writeFile("hello", modTime))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sha1).To(Equal(helloSha1))
+
+			sha1, _ = cache.Sha1(appFile, writeFile("HELLO", modTime))
+			Expect(sha1).To(Equal(helloSha1))
+
+			sha1, _ = cache.Sha1(appFile, writeFile("HELLO", modTime.Add(time.Second)))
+			Expect(sha1).NotTo(Equal(helloSha1))
+		})
+	})
+
+	It("keeps the hashes between runs", func() {
+		withDir(func() {
+			modTime := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+			cache := NewFileHashCache(cachePath)
+			cache.Sha1(appFile, writeFile("hello", modTime))
+			err := cache.Save()
+			Expect(err).NotTo(HaveOccurred())
+
+			cache = NewFileHashCache(cachePath)
+			sha1, _ := cache.Sha1(appFile, writeFile("HELLO", modTime))
+			Expect(sha1).To(Equal(helloSha1))
+		})
+	})
+
+	It("leaves out files that were removed when saving", func() {
+		withDir(func() {
+			cache := NewFileHashCache(cachePath)
+			cache.Sha1(appFile, writeFile("hello", time.Now()))
+			os.Remove(appFile)
+			err := cache.Save()
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(cachePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("{}"))
+		})
+	})
+
+	It("starts over when the cache file is corrupt", func() {
+		withDir(func() {
+			err := fileutils.CopyReaderToPath(strings.NewReader("not json"), cachePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			cache := NewFileHashCache(cachePath)
+			sha1, err := cache.Sha1(appFile, writeFile("hello", time.Now()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sha1).To(Equal(helloSha1))
+		})
+	})
+
+	It("hashes every file without a cache", func() {
+		withDir(func() {
+			var cache *FileHashCache
+			sha1, err := cache.Sha1(appFile, writeFile("hello", time.Now()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sha1).To(Equal(helloSha1))
+			err = cache.Save()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,132 @@
+package cf
+
+import (
+	"glob"
+	"path"
+	"strings"
+)
+
+type ignoreRule struct {
+	glob     glob.Glob
+	base     string
+	negated  bool
+	dirOnly  bool
+	anchored bool
+}
+
+type ignoreRules []ignoreRule
+
+func defaultIgnoreRules() (rules ignoreRules) {
+	for _, pattern := range DefaultIgnoreFiles {
+		rules = append(rules, parseIgnoreRules("", pattern)...)
+	}
+	return
+}
+
+// parseIgnoreRules reads the patterns of an ignore file found in base, a directory relative to the
+// app directory. Patterns follow the rules of .gitignore files: ! re-includes what an earlier
+// pattern excluded, a trailing / only matches directories, and a pattern containing a / is matched
+// against the path below base, while any other pattern matches names at any depth.
+func parseIgnoreRules(base string, contents string) (rules ignoreRules) {
+	for _, line := range strings.Split(contents, "\n") {
+		line = trimIgnoreLine(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negated = true
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimLeft(line, "/")
+		}
+
+		if line == "" {
+			continue
+		}
+
+		var err error
+		rule.glob, err = glob.CompileGlob(unescapeIgnorePattern(line))
+		if err != nil {
+			continue
+		}
+
+		rules = append(rules, rule)
+	}
+	return
+}
+
+// trimIgnoreLine removes the line ending and trailing spaces that are not escaped.
+func trimIgnoreLine(line string) string {
+	line = strings.TrimRight(line, "\r")
+	trimmed := strings.TrimRight(line, " \t")
+	if strings.HasSuffix(trimmed, `\`) && len(trimmed) < len(line) {
+		trimmed += " "
+	}
+	return trimmed
+}
+
+// unescapeIgnorePattern turns escaped characters into ones that match themselves. Glob patterns
+// have no escapes of their own, so characters with a meaning become a class of just themselves.
+func unescapeIgnorePattern(pattern string) string {
+	if !strings.Contains(pattern, `\`) {
+		return pattern
+	}
+
+	unescaped := []string{}
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if c != '\\' || i+1 == len(runes) {
+			unescaped = append(unescaped, string(c))
+			continue
+		}
+
+		i++
+		switch runes[i] {
+		case '*', '?', '[':
+			unescaped = append(unescaped, "["+string(runes[i])+"]")
+		default:
+			unescaped = append(unescaped, string(runes[i]))
+		}
+	}
+	return strings.Join(unescaped, "")
+}
+
+func (rule ignoreRule) matches(relPath string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+
+	if rule.base != "" {
+		if !strings.HasPrefix(relPath, rule.base+"/") {
+			return false
+		}
+		relPath = relPath[len(rule.base)+1:]
+	}
+
+	if rule.anchored {
+		return rule.glob.Match(relPath)
+	}
+	return rule.glob.Match(path.Base(relPath))
+}
+
+// ignores is true when the last rule matching relPath excludes it. Files in an excluded
+// directory are never walked, so they cannot be included again.
+func (rules ignoreRules) ignores(relPath string, isDir bool) (ignored bool) {
+	for _, rule := range rules {
+		if rule.matches(relPath, isDir) {
+			ignored = !rule.negated
+		}
+	}
+	return
+}
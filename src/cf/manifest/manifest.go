@@ -1,14 +1,15 @@
 package manifest
 
 import (
+	"cf"
 	"cf/formatters"
 	"cf/models"
 	"errors"
 	"fmt"
 	"generic"
 	"path/filepath"
-	"regexp"
 	"strconv"
+	"strings"
 )
 
 type Manifest struct {
@@ -21,7 +22,11 @@ func NewEmptyManifest() (m *Manifest) {
 }
 
 func NewManifest(basePath string, data generic.Map) (m *Manifest, errs ManifestErrors) {
-	errs = walkManifestLookingForProperties(data)
+	return NewManifestWithVariables(basePath, data, NewVariables())
+}
+
+func NewManifestWithVariables(basePath string, data generic.Map, vars Variables) (m *Manifest, errs ManifestErrors) {
+	errs = interpolateManifest(data, vars)
 	if !errs.Empty() {
 		return
 	}
@@ -31,36 +36,6 @@ func NewManifest(basePath string, data generic.Map) (m *Manifest, errs ManifestE
 	return
 }
 
-func walkManifestLookingForProperties(data generic.Map) (errs ManifestErrors) {
-	generic.Each(data, func(key, value interface{}) {
-		errs = append(errs, walkMapLookingForProperties(value)...)
-	})
-
-	return
-}
-
-func walkMapLookingForProperties(value interface{}) (errs ManifestErrors) {
-	propertyRegex := regexp.MustCompile(`\${\w+}`)
-	switch value := value.(type) {
-	case string:
-		match := propertyRegex.FindString(value)
-		if match != "" {
-			err := errors.New(fmt.Sprintf("Properties are not supported. Found property '%s' in manifest", match))
-			errs = append(errs, err)
-		}
-	case []interface{}:
-		for _, item := range value {
-			errs = append(errs, walkMapLookingForProperties(item)...)
-		}
-	case map[string]interface{}:
-		for _, item := range value {
-			errs = append(errs, walkMapLookingForProperties(item)...)
-		}
-	}
-
-	return
-}
-
 func mapToAppSet(basePath string, data generic.Map) (appSet []models.AppParams, errs ManifestErrors) {
 	if data.Has("applications") {
 		appMaps, ok := data.Get("applications").([]interface{})
@@ -88,11 +63,81 @@ func mapToAppSet(basePath string, data generic.Map) (appSet []models.AppParams,
 
 			appSet = append(appSet, appParams)
 		}
+
+		errs = append(errs, validateDependencies(appSet)...)
 	}
 
 	return
 }
 
+// validateDependencies checks that every app named in depends_on is in the manifest and that
+// no app depends on itself, directly or through other apps.
+func validateDependencies(appSet []models.AppParams) (errs ManifestErrors) {
+	dependencies := map[string][]string{}
+	for _, app := range appSet {
+		if app.Name != nil && app.DependsOn != nil {
+			dependencies[*app.Name] = *app.DependsOn
+		}
+	}
+
+	for _, app := range appSet {
+		if app.Name == nil {
+			continue
+		}
+		for _, dependency := range dependencies[*app.Name] {
+			if !appSetHasName(appSet, dependency) {
+				errs = append(errs, errors.New(fmt.Sprintf("App %s depends on %s, which is not in the manifest", *app.Name, dependency)))
+			}
+		}
+	}
+	if !errs.Empty() {
+		return
+	}
+
+	visited := map[string]bool{}
+	for _, app := range appSet {
+		if app.Name == nil || visited[*app.Name] {
+			continue
+		}
+		cycle := findDependencyCycle(*app.Name, dependencies, visited, []string{})
+		if cycle != nil {
+			errs = append(errs, errors.New(fmt.Sprintf("Cycle detected in app dependencies: %s", strings.Join(cycle, " -> "))))
+			return
+		}
+	}
+	return
+}
+
+func findDependencyCycle(name string, dependencies map[string][]string, visited map[string]bool, path []string) []string {
+	for index, pathName := range path {
+		if pathName == name {
+			return append(path[index:], name)
+		}
+	}
+	if visited[name] {
+		return nil
+	}
+	visited[name] = true
+
+	path = append(path, name)
+	for _, dependency := range dependencies[name] {
+		cycle := findDependencyCycle(dependency, dependencies, visited, path)
+		if cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+func appSetHasName(appSet []models.AppParams, name string) bool {
+	for _, app := range appSet {
+		if app.Name != nil && *app.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func mapToAppParams(basePath string, yamlMap generic.Map) (appParams models.AppParams, errs ManifestErrors) {
 	errs = checkForNulls(yamlMap)
 	if !errs.Empty() {
@@ -100,9 +145,12 @@ func mapToAppParams(basePath string, yamlMap generic.Map) (appParams models.AppP
 	}
 
 	appParams.BuildpackUrl = stringVal(yamlMap, "buildpack", &errs)
+	appParams.DependsOn = sliceOrNilVal(yamlMap, "depends_on", &errs)
 	appParams.DiskQuota = bytesVal(yamlMap, "disk_quota", &errs)
 	appParams.Domain = stringVal(yamlMap, "domain", &errs)
+	appParams.Domains = sliceOrNilVal(yamlMap, "domains", &errs)
 	appParams.Host = stringVal(yamlMap, "host", &errs)
+	appParams.Hosts = sliceOrNilVal(yamlMap, "hosts", &errs)
 	appParams.Name = stringVal(yamlMap, "name", &errs)
 	appParams.Path = stringVal(yamlMap, "path", &errs)
 	appParams.StackName = stringVal(yamlMap, "stack", &errs)
@@ -111,10 +159,10 @@ func mapToAppParams(basePath string, yamlMap generic.Map) (appParams models.AppP
 	appParams.InstanceCount = intVal(yamlMap, "instances", &errs)
 	appParams.HealthCheckTimeout = intVal(yamlMap, "timeout", &errs)
 	appParams.NoRoute = boolVal(yamlMap, "no-route", &errs)
-	appParams.Services = sliceOrEmptyVal(yamlMap, "services", &errs)
+	appParams.Services, appParams.ServiceInstances = servicesVal(yamlMap, &errs)
 	appParams.EnvironmentVars = envVarOrEmptyMap(yamlMap, &errs)
 
-	if appParams.Path != nil {
+	if appParams.Path != nil && !cf.IsRemoteAppSource(*appParams.Path) {
 		path := *appParams.Path
 		if filepath.IsAbs(path) {
 			path = filepath.Clean(path)
@@ -222,6 +270,13 @@ func boolVal(yamlMap generic.Map, key string, errs *ManifestErrors) *bool {
 	}
 }
 
+func sliceOrNilVal(yamlMap generic.Map, key string, errs *ManifestErrors) *[]string {
+	if !yamlMap.Has(key) {
+		return nil
+	}
+	return sliceOrEmptyVal(yamlMap, key, errs)
+}
+
 func sliceOrEmptyVal(yamlMap generic.Map, key string, errs *ManifestErrors) *[]string {
 	if !yamlMap.Has(key) {
 		return new([]string)
@@ -256,6 +311,101 @@ func sliceOrEmptyVal(yamlMap generic.Map, key string, errs *ManifestErrors) *[]s
 	return &stringSlice
 }
 
+// servicesVal reads the services list, where each entry is either the name of an existing
+// service instance or a map describing an instance to create if it does not exist.
+func servicesVal(yamlMap generic.Map, errs *ManifestErrors) (names *[]string, instances *[]models.ServiceInstanceParams) {
+	key := "services"
+	input, ok := yamlMap.Get(key).([]interface{})
+	if !ok || !containsMappable(input) {
+		names = sliceOrEmptyVal(yamlMap, key, errs)
+		return
+	}
+
+	names = new([]string)
+	instances = new([]models.ServiceInstanceParams)
+
+	for index, value := range input {
+		switch value := value.(type) {
+		case string:
+			*names = append(*names, value)
+		default:
+			if !generic.IsMappable(value) {
+				*errs = append(*errs, errors.New(fmt.Sprintf("Expected %s to be a list of service names or service instances.", key)))
+				continue
+			}
+
+			instance, err := serviceInstanceParams(generic.NewMap(value))
+			if err != nil {
+				*errs = append(*errs, errors.New(fmt.Sprintf("Invalid service at %s[%d]: %s", key, index, err.Error())))
+				continue
+			}
+
+			*names = append(*names, instance.Name)
+			*instances = append(*instances, instance)
+		}
+	}
+
+	return
+}
+
+func containsMappable(values []interface{}) bool {
+	for _, value := range values {
+		if generic.IsMappable(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func serviceInstanceParams(serviceMap generic.Map) (instance models.ServiceInstanceParams, err error) {
+	var serviceErrs ManifestErrors
+
+	name := stringVal(serviceMap, "name", &serviceErrs)
+	label := stringVal(serviceMap, "label", &serviceErrs)
+	plan := stringVal(serviceMap, "plan", &serviceErrs)
+	drainUrl := stringVal(serviceMap, "syslog_drain_url", &serviceErrs)
+	if !serviceErrs.Empty() {
+		err = serviceErrs
+		return
+	}
+
+	if name == nil {
+		err = errors.New("name is required")
+		return
+	}
+	instance.Name = *name
+
+	if serviceMap.Has("credentials") {
+		if label != nil || plan != nil {
+			err = errors.New("a service with credentials is user provided and cannot have a label or plan")
+			return
+		}
+
+		credentials := serviceMap.Get("credentials")
+		if !generic.IsMappable(credentials) {
+			err = errors.New("credentials must be a set of key => value")
+			return
+		}
+
+		instance.Credentials = map[string]string{}
+		generic.Each(generic.NewMap(credentials), func(key, value interface{}) {
+			instance.Credentials[fmt.Sprintf("%v", key)] = fmt.Sprintf("%v", value)
+		})
+		if drainUrl != nil {
+			instance.SyslogDrainUrl = *drainUrl
+		}
+		return
+	}
+
+	if label == nil || plan == nil {
+		err = errors.New("label and plan are required")
+		return
+	}
+	instance.Label = *label
+	instance.Plan = *plan
+	return
+}
+
 func envVarOrEmptyMap(yamlMap generic.Map, errs *ManifestErrors) *map[string]string {
 	key := "env"
 	switch envVars := yamlMap.Get(key).(type) {
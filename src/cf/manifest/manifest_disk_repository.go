@@ -1,17 +1,21 @@
 package manifest
 
 import (
+	"cf"
 	"errors"
+	"fmt"
 	"generic"
 	"github.com/cloudfoundry/gamble"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type ManifestRepository interface {
-	ReadManifest(string) (manifest *Manifest, path string, errors ManifestErrors)
+	ReadManifest(path string, vars Variables) (manifest *Manifest, manifestPath string, errors ManifestErrors)
+	WriteManifest(manifest *Manifest, path string) (err error)
 }
 
 type ManifestDiskRepository struct{}
@@ -20,7 +24,7 @@ func NewManifestDiskRepository() (repo ManifestRepository) {
 	return ManifestDiskRepository{}
 }
 
-func (repo ManifestDiskRepository) ReadManifest(inputPath string) (m *Manifest, manifestPath string, errs ManifestErrors) {
+func (repo ManifestDiskRepository) ReadManifest(inputPath string, vars Variables) (m *Manifest, manifestPath string, errs ManifestErrors) {
 	m = NewEmptyManifest()
 
 	basePath, fileName, err := repo.manifestPath(inputPath)
@@ -37,7 +41,7 @@ func (repo ManifestDiskRepository) ReadManifest(inputPath string) (m *Manifest,
 		return
 	}
 
-	m, errs = NewManifest(basePath, mapp)
+	m, errs = NewManifestWithVariables(basePath, mapp, vars)
 	if !errs.Empty() {
 		return
 	}
@@ -45,7 +49,37 @@ func (repo ManifestDiskRepository) ReadManifest(inputPath string) (m *Manifest,
 	return
 }
 
+func (repo ManifestDiskRepository) WriteManifest(m *Manifest, path string) (err error) {
+	file, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	err = m.Write(file)
+	return
+}
+
 func (repo ManifestDiskRepository) readAllYAMLFiles(path string) (mergedMap generic.Map, err error) {
+	return repo.readYAMLFileWithParents(path, []string{})
+}
+
+// readYAMLFileWithParents follows the chain of 'inherit' keys, keeping track of the
+// manifests it has already visited so that a manifest cannot inherit from itself.
+func (repo ManifestDiskRepository) readYAMLFileWithParents(path string, visitedPaths []string) (mergedMap generic.Map, err error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+
+	for _, visitedPath := range visitedPaths {
+		if visitedPath == absPath {
+			err = errors.New(fmt.Sprintf("Cycle detected in manifest inheritance: %s", strings.Join(append(visitedPaths, absPath), " -> ")))
+			return
+		}
+	}
+	visitedPaths = append(visitedPaths, absPath)
+
 	file, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return
@@ -57,6 +91,13 @@ func (repo ManifestDiskRepository) readAllYAMLFiles(path string) (mergedMap gene
 		return
 	}
 
+	if len(visitedPaths) > 1 {
+		err = expandAppPaths(mapp, filepath.Dir(absPath))
+		if err != nil {
+			return
+		}
+	}
+
 	if !mapp.Has("inherit") {
 		mergedMap = mapp
 		return
@@ -67,17 +108,109 @@ func (repo ManifestDiskRepository) readAllYAMLFiles(path string) (mergedMap gene
 		err = errors.New("invalid inherit path in manifest")
 		return
 	}
+	mapp.Delete("inherit")
 
 	if !filepath.IsAbs(inheritedPath) {
 		inheritedPath = filepath.Join(filepath.Dir(path), inheritedPath)
 	}
 
-	inheritedMap, err := repo.readAllYAMLFiles(inheritedPath)
+	inheritedMap, err := repo.readYAMLFileWithParents(inheritedPath, visitedPaths)
 	if err != nil {
 		return
 	}
 
-	mergedMap = generic.DeepMerge(inheritedMap, mapp)
+	mergedMap = mergeManifestMaps(inheritedMap, mapp)
+	return
+}
+
+// expandAppPaths makes the app paths in an inherited manifest absolute, so that they stay
+// relative to the manifest they were written in rather than the one inheriting them. Remote
+// app sources are left as they are.
+func expandAppPaths(mapp generic.Map, manifestDir string) (err error) {
+	mapsWithPaths := []generic.Map{mapp}
+
+	if apps, ok := mapp.Get("applications").([]interface{}); ok {
+		for index, app := range apps {
+			if !generic.IsMappable(app) {
+				continue
+			}
+			appMap := generic.NewMap(app)
+			apps[index] = appMap
+			mapsWithPaths = append(mapsWithPaths, appMap)
+		}
+	}
+
+	for _, mapWithPath := range mapsWithPaths {
+		appPath, ok := mapWithPath.Get("path").(string)
+		if !ok || filepath.IsAbs(appPath) || cf.IsRemoteAppSource(appPath) {
+			continue
+		}
+
+		appPath, err = filepath.Abs(filepath.Join(manifestDir, appPath))
+		if err != nil {
+			return
+		}
+		mapWithPath.Set("path", appPath)
+	}
+	return
+}
+
+// mergeManifestMaps deep merges a child manifest into its parent. Applications are
+// matched by name so that a child can override the settings of an inherited app.
+func mergeManifestMaps(parent, child generic.Map) (merged generic.Map) {
+	appsKey := []interface{}{"applications"}
+	merged = generic.DeepMerge(parent.Except(appsKey), child.Except(appsKey))
+
+	parentApps, _ := parent.Get("applications").([]interface{})
+	childApps, _ := child.Get("applications").([]interface{})
+	if parent.Has("applications") || child.Has("applications") {
+		merged.Set("applications", mergeAppsByName(parentApps, childApps))
+	}
+	return
+}
+
+func mergeAppsByName(parentApps, childApps []interface{}) (mergedApps []interface{}) {
+	mergedApps = []interface{}{}
+	matchedChildApps := map[int]bool{}
+
+	for _, parentApp := range parentApps {
+		childIndex, found := findAppByName(childApps, appName(parentApp))
+		if !found {
+			mergedApps = append(mergedApps, parentApp)
+			continue
+		}
+
+		matchedChildApps[childIndex] = true
+		mergedApps = append(mergedApps, generic.DeepMerge(generic.NewMap(parentApp), generic.NewMap(childApps[childIndex])))
+	}
+
+	for index, childApp := range childApps {
+		if !matchedChildApps[index] {
+			mergedApps = append(mergedApps, childApp)
+		}
+	}
+	return
+}
+
+func findAppByName(apps []interface{}, name string) (index int, found bool) {
+	if name == "" {
+		return
+	}
+
+	for index = range apps {
+		if appName(apps[index]) == name {
+			found = true
+			return
+		}
+	}
+	return
+}
+
+func appName(app interface{}) (name string) {
+	if !generic.IsMappable(app) {
+		return
+	}
+	name, _ = generic.NewMap(app).Get("name").(string)
 	return
 }
 
@@ -17,7 +17,7 @@ var _ = Describe("ManifestDiskRepository", func() {
 
 	Describe("given a directory containing a file called 'manifest.yml", func() {
 		It("reads that file", func() {
-			m, path, errs := repo.ReadManifest("../../fixtures/manifests")
+			m, path, errs := repo.ReadManifest("../../fixtures/manifests", NewVariables())
 
 			Expect(errs).To(BeEmpty())
 			Expect(path).To(Equal(filepath.Clean("../../fixtures/manifests/manifest.yml")))
@@ -27,7 +27,7 @@ var _ = Describe("ManifestDiskRepository", func() {
 
 	Describe("given a directory that doesn't contain a file called 'manifest.yml", func() {
 		It("returns an error", func() {
-			_, path, errs := repo.ReadManifest("../../fixtures")
+			_, path, errs := repo.ReadManifest("../../fixtures", NewVariables())
 
 			Expect(errs).NotTo(BeEmpty())
 			Expect(path).To(BeEmpty())
@@ -36,7 +36,7 @@ var _ = Describe("ManifestDiskRepository", func() {
 
 	Describe("given a path to a file", func() {
 		It("reads the file at that path", func() {
-			m, path, errs := repo.ReadManifest("../../fixtures/manifests/different-manifest.yml")
+			m, path, errs := repo.ReadManifest("../../fixtures/manifests/different-manifest.yml", NewVariables())
 
 			Expect(errs).To(BeEmpty())
 			Expect(path).To(Equal(filepath.Clean("../../fixtures/manifests/different-manifest.yml")))
@@ -44,7 +44,7 @@ var _ = Describe("ManifestDiskRepository", func() {
 		})
 
 		It("passes the base directory to the manifest file", func() {
-			m, _, errs := repo.ReadManifest("../../fixtures/manifests/different-manifest.yml")
+			m, _, errs := repo.ReadManifest("../../fixtures/manifests/different-manifest.yml", NewVariables())
 
 			Expect(errs).To(BeEmpty())
 			Expect(len(m.Applications)).To(Equal(1))
@@ -60,31 +60,31 @@ var _ = Describe("ManifestDiskRepository", func() {
 
 	Describe("given a path to a file that doesn't exist", func() {
 		It("returns an error", func() {
-			_, _, errs := repo.ReadManifest("some/path/that/doesnt/exist/manifest.yml")
+			_, _, errs := repo.ReadManifest("some/path/that/doesnt/exist/manifest.yml", NewVariables())
 			Expect(errs).NotTo(BeEmpty())
 		})
 
 		It("returns empty string for the manifest path", func() {
-			_, path, _ := repo.ReadManifest("some/path/that/doesnt/exist/manifest.yml")
+			_, path, _ := repo.ReadManifest("some/path/that/doesnt/exist/manifest.yml", NewVariables())
 			Expect(path).To(Equal(""))
 		})
 	})
 
 	Describe("when the manifest is not valid", func() {
 		It("returns an error", func() {
-			_, _, errs := repo.ReadManifest("../../fixtures/manifests/empty-manifest.yml")
+			_, _, errs := repo.ReadManifest("../../fixtures/manifests/empty-manifest.yml", NewVariables())
 			Expect(errs).NotTo(BeEmpty())
 		})
 
 		It("returns the path to the manifest", func() {
 			inputPath := filepath.Clean("../../fixtures/manifests/empty-manifest.yml")
-			_, path, _ := repo.ReadManifest(inputPath)
+			_, path, _ := repo.ReadManifest(inputPath, NewVariables())
 			Expect(path).To(Equal(inputPath))
 		})
 	})
 
 	It("converts nested maps to generic maps", func() {
-		m, _, errs := repo.ReadManifest("../../fixtures/manifests/different-manifest.yml")
+		m, _, errs := repo.ReadManifest("../../fixtures/manifests/different-manifest.yml", NewVariables())
 
 		Expect(errs).To(BeEmpty())
 		Expect(*m.Applications[0].EnvironmentVars).To(Equal(map[string]string{
@@ -92,8 +92,21 @@ var _ = Describe("ManifestDiskRepository", func() {
 		}))
 	})
 
+	It("substitutes the given variables into the manifest", func() {
+		vars := Variables{"app_name": "my-app", "instances": "3", "host": "my-host"}
+		m, _, errs := repo.ReadManifest("../../fixtures/manifests/manifest-with-variables.yml", vars)
+
+		Expect(errs).To(BeEmpty())
+		Expect(*m.Applications[0].Name).To(Equal("my-app"))
+		Expect(*m.Applications[0].InstanceCount).To(Equal(3))
+		Expect(*m.Applications[0].Host).To(Equal("my-host"))
+		Expect(*m.Applications[0].EnvironmentVars).To(Equal(map[string]string{
+			"GREETING": "hello-my-host",
+		}))
+	})
+
 	It("merges manifests with their 'inherited' manifests", func() {
-		m, _, errs := repo.ReadManifest("../../fixtures/manifests/inherited-manifest.yml")
+		m, _, errs := repo.ReadManifest("../../fixtures/manifests/inherited-manifest.yml", NewVariables())
 		Expect(errs).To(BeEmpty())
 		Expect(*m.Applications[0].Name).To(Equal("base-app"))
 		Expect(*m.Applications[0].Services).To(Equal([]string{"base-service"}))
@@ -111,4 +124,55 @@ var _ = Describe("ManifestDiskRepository", func() {
 		services := *m.Applications[1].Services
 		Expect(services).To(Equal([]string{"base-service", "foo-service"}))
 	})
+
+	Describe("inheriting a chain of manifests", func() {
+		It("merges applications with the same name, with the child taking precedence", func() {
+			m, _, errs := repo.ReadManifest("../../fixtures/manifests/inheritance/child-manifest.yml", NewVariables())
+			Expect(errs).To(BeEmpty())
+			Expect(len(m.Applications)).To(Equal(3))
+
+			Expect(*m.Applications[0].Name).To(Equal("web"))
+			Expect(*m.Applications[0].InstanceCount).To(Equal(3))
+			Expect(*m.Applications[0].Memory).To(Equal(uint64(256)))
+
+			Expect(*m.Applications[1].Name).To(Equal("worker"))
+			Expect(*m.Applications[1].InstanceCount).To(Equal(2))
+			Expect(*m.Applications[1].Memory).To(Equal(uint64(128)))
+
+			Expect(*m.Applications[2].Name).To(Equal("admin"))
+			Expect(m.Applications[2].InstanceCount).To(BeNil())
+		})
+
+		It("resolves paths relative to the manifest they are written in", func() {
+			m, _, errs := repo.ReadManifest("../../fixtures/manifests/inheritance/child-manifest.yml", NewVariables())
+			Expect(errs).To(BeEmpty())
+
+			webPath, err := filepath.Abs("../../fixtures/manifests/inheritance/base/web")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*m.Applications[0].Path).To(Equal(webPath))
+
+			appPath, err := filepath.Abs("../../fixtures/manifests/inheritance/app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*m.Applications[2].Path).To(Equal(appPath))
+		})
+
+		It("leaves remote app sources as they are", func() {
+			m, _, errs := repo.ReadManifest("../../fixtures/manifests/inheritance/remote-child-manifest.yml", NewVariables())
+			Expect(errs).To(BeEmpty())
+
+			Expect(*m.Applications[0].Name).To(Equal("from-git"))
+			Expect(*m.Applications[0].InstanceCount).To(Equal(2))
+			Expect(*m.Applications[0].Path).To(Equal("git+https://example.com/app.git#v1"))
+
+			Expect(*m.Applications[1].Name).To(Equal("from-archive"))
+			Expect(*m.Applications[1].Path).To(Equal("https://example.com/app.zip"))
+		})
+
+		It("returns an error when manifests inherit from each other", func() {
+			_, _, errs := repo.ReadManifest("../../fixtures/manifests/inheritance/cycle-a.yml", NewVariables())
+			Expect(errs).NotTo(BeEmpty())
+			Expect(errs.Error()).To(ContainSubstring("Cycle detected in manifest inheritance"))
+			Expect(errs.Error()).To(ContainSubstring(filepath.Join("inheritance", "cycle-b.yml")))
+		})
+	})
 })
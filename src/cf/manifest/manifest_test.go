@@ -2,9 +2,11 @@ package manifest_test
 
 import (
 	"cf/manifest"
+	"cf/models"
 	"generic"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"os"
 	"runtime"
 	"strings"
 	testassert "testhelpers/assert"
@@ -137,6 +139,19 @@ var _ = Describe("Testing with ginkgo", func() {
 		}
 	})
 
+	It("TestManifestWithRemotePathLeavesItAlone", func() {
+		m, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{"path": "git+https://example.com/app.git#v1"},
+				map[string]interface{}{"path": "https://example.com/app.zip"},
+			},
+		}))
+
+		Expect(errs).To(BeEmpty())
+		Expect(*m.Applications[0].Path).To(Equal("git+https://example.com/app.git#v1"))
+		Expect(*m.Applications[1].Path).To(Equal("https://example.com/app.zip"))
+	})
+
 	It("TestParsingManifestWithNulls", func() {
 		_, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
 			"applications": []interface{}{
@@ -180,7 +195,91 @@ var _ = Describe("Testing with ginkgo", func() {
 		}))
 
 		Expect(errs).NotTo(BeEmpty())
-		Expect(errs.Error()).To(ContainSubstring("Properties are not supported. Found property '${foo}'"))
+		Expect(errs.Error()).To(ContainSubstring("Unresolved variable '${foo}' at applications[0].env.bar"))
+	})
+
+	It("TestParsingManifestWithVariablesSubstitutesThem", func() {
+		m, errs := manifest.NewManifestWithVariables("/some/path", generic.NewMap(map[string]interface{}{
+			"instances": "${instances}",
+			"applications": []interface{}{
+				map[string]interface{}{
+					"name": "${name}",
+					"env": map[string]interface{}{
+						"bar": "many-${foo}-are-cool",
+					},
+					"services": []interface{}{"${name}-db"},
+				},
+			},
+		}), manifest.Variables{"instances": "3", "name": "my-app", "foo": "dogs"})
+
+		Expect(errs).To(BeEmpty())
+		Expect(*m.Applications[0].Name).To(Equal("my-app"))
+		Expect(*m.Applications[0].InstanceCount).To(Equal(3))
+		Expect(*m.Applications[0].EnvironmentVars).To(Equal(map[string]string{"bar": "many-dogs-are-cool"}))
+		Expect(*m.Applications[0].Services).To(Equal([]string{"my-app-db"}))
+	})
+
+	It("TestParsingManifestWithVariablesFallsBackToEnvironmentVariables", func() {
+		os.Setenv("CF_MANIFEST_TEST_HOST", "host-from-env")
+		defer os.Setenv("CF_MANIFEST_TEST_HOST", "")
+
+		m, errs := manifest.NewManifestWithVariables("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{
+					"name": "my-app",
+					"host": "${CF_MANIFEST_TEST_HOST}",
+				},
+			},
+		}), manifest.NewVariables())
+
+		Expect(errs).To(BeEmpty())
+		Expect(*m.Applications[0].Host).To(Equal("host-from-env"))
+	})
+
+	It("TestParsingManifestWithVariablesPrefersGivenVariablesOverTheEnvironment", func() {
+		os.Setenv("CF_MANIFEST_TEST_HOST", "host-from-env")
+		defer os.Setenv("CF_MANIFEST_TEST_HOST", "")
+
+		m, errs := manifest.NewManifestWithVariables("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{
+					"name": "my-app",
+					"host": "${CF_MANIFEST_TEST_HOST}",
+				},
+			},
+		}), manifest.Variables{"CF_MANIFEST_TEST_HOST": "host-from-var"})
+
+		Expect(errs).To(BeEmpty())
+		Expect(*m.Applications[0].Host).To(Equal("host-from-var"))
+	})
+
+	It("TestParsingManifestWithUnresolvedVariablesReportsEachLocation", func() {
+		_, errs := manifest.NewManifestWithVariables("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{
+					"name":     "${name}",
+					"services": []interface{}{"db", "${cache}"},
+				},
+			},
+		}), manifest.NewVariables())
+
+		Expect(len(errs)).To(Equal(2))
+		Expect(errs.Error()).To(ContainSubstring("Unresolved variable '${name}' at applications[0].name"))
+		Expect(errs.Error()).To(ContainSubstring("Unresolved variable '${cache}' at applications[0].services[1]"))
+	})
+
+	It("TestParsingManifestWithEscapedVariablesKeepsThemLiteral", func() {
+		m, errs := manifest.NewManifestWithVariables("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{
+					"name":    "my-app",
+					"command": "sh -c 'echo $${HOME} ${greeting}'",
+				},
+			},
+		}), manifest.Variables{"greeting": "hi"})
+
+		Expect(errs).To(BeEmpty())
+		Expect(*m.Applications[0].Command).To(Equal("sh -c 'echo ${HOME} hi'"))
 	})
 
 	It("TestParsingManifestWithNullCommand", func() {
@@ -206,4 +305,146 @@ var _ = Describe("Testing with ginkgo", func() {
 		Expect(errs).To(BeEmpty())
 		Expect(m.Applications[0].Command).To(BeNil())
 	})
+
+	It("TestParsingManifestWithHostsAndDomains", func() {
+		m, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{
+					"hosts":   []interface{}{"www", "shop"},
+					"domains": []interface{}{"example.com", "example.org"},
+				},
+			},
+		}))
+
+		Expect(errs).To(BeEmpty())
+		Expect(*m.Applications[0].Hosts).To(Equal([]string{"www", "shop"}))
+		Expect(*m.Applications[0].Domains).To(Equal([]string{"example.com", "example.org"}))
+	})
+
+	It("TestParsingManifestWithoutHostsAndDomainsLeavesThemUnset", func() {
+		m, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{},
+			},
+		}))
+
+		Expect(errs).To(BeEmpty())
+		Expect(m.Applications[0].Hosts).To(BeNil())
+		Expect(m.Applications[0].Domains).To(BeNil())
+	})
+
+	It("TestParsingManifestWithInvalidHosts", func() {
+		_, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{
+					"hosts": "www",
+				},
+			},
+		}))
+
+		Expect(errs).NotTo(BeEmpty())
+		Expect(errs.Error()).To(ContainSubstring("Expected hosts to be a list of strings."))
+	})
+
+	It("TestParsingManifestWithServiceInstancesToCreate", func() {
+		m, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
+			"services": []interface{}{"global-service"},
+			"applications": []interface{}{
+				map[string]interface{}{
+					"services": []interface{}{
+						"existing-service",
+						map[string]interface{}{
+							"name":  "review-db",
+							"label": "cleardb",
+							"plan":  "spark",
+						},
+						map[string]interface{}{
+							"name":             "review-creds",
+							"credentials":      map[string]interface{}{"password": "secret"},
+							"syslog_drain_url": "syslog://example.com",
+						},
+					},
+				},
+			},
+		}))
+
+		Expect(errs).To(BeEmpty())
+		Expect(*m.Applications[0].Services).To(Equal([]string{"global-service", "existing-service", "review-db", "review-creds"}))
+		Expect(*m.Applications[0].ServiceInstances).To(Equal([]models.ServiceInstanceParams{
+			{Name: "review-db", Label: "cleardb", Plan: "spark"},
+			{Name: "review-creds", Credentials: map[string]string{"password": "secret"}, SyslogDrainUrl: "syslog://example.com"},
+		}))
+	})
+
+	It("TestParsingManifestWithOnlyServiceNamesDoesNotCreateInstances", func() {
+		m, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{
+					"services": []interface{}{"existing-service"},
+				},
+			},
+		}))
+
+		Expect(errs).To(BeEmpty())
+		Expect(*m.Applications[0].Services).To(Equal([]string{"existing-service"}))
+		Expect(m.Applications[0].ServiceInstances).To(BeNil())
+	})
+
+	It("TestParsingManifestWithInvalidServiceInstances", func() {
+		_, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{
+					"services": []interface{}{
+						map[string]interface{}{"label": "cleardb", "plan": "spark"},
+						map[string]interface{}{"name": "no-plan", "label": "cleardb"},
+						map[string]interface{}{"name": "both", "label": "cleardb", "credentials": map[string]interface{}{}},
+					},
+				},
+			},
+		}))
+
+		Expect(errs).NotTo(BeEmpty())
+		Expect(errs.Error()).To(ContainSubstring("services[0]: name is required"))
+		Expect(errs.Error()).To(ContainSubstring("services[1]: label and plan are required"))
+		Expect(errs.Error()).To(ContainSubstring("services[2]: a service with credentials is user provided"))
+	})
+
+	It("TestParsingManifestWithDependencies", func() {
+		m, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{"name": "db-migrator"},
+				map[string]interface{}{"name": "api", "depends_on": []interface{}{"db-migrator"}},
+				map[string]interface{}{"name": "web", "depends_on": []interface{}{"api", "db-migrator"}},
+			},
+		}))
+
+		Expect(errs).To(BeEmpty())
+		Expect(m.Applications[0].DependsOn).To(BeNil())
+		Expect(*m.Applications[1].DependsOn).To(Equal([]string{"db-migrator"}))
+		Expect(*m.Applications[2].DependsOn).To(Equal([]string{"api", "db-migrator"}))
+	})
+
+	It("TestParsingManifestWithDependenciesOnUnknownApps", func() {
+		_, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{"name": "web", "depends_on": []interface{}{"apii"}},
+			},
+		}))
+
+		Expect(errs).NotTo(BeEmpty())
+		Expect(errs.Error()).To(ContainSubstring("App web depends on apii, which is not in the manifest"))
+	})
+
+	It("TestParsingManifestWithDependencyCycle", func() {
+		_, errs := manifest.NewManifest("/some/path", generic.NewMap(map[string]interface{}{
+			"applications": []interface{}{
+				map[string]interface{}{"name": "worker"},
+				map[string]interface{}{"name": "api", "depends_on": []interface{}{"web"}},
+				map[string]interface{}{"name": "web", "depends_on": []interface{}{"worker", "api"}},
+			},
+		}))
+
+		Expect(errs).NotTo(BeEmpty())
+		Expect(errs.Error()).To(ContainSubstring("Cycle detected in app dependencies: api -> web -> api"))
+	})
 })
@@ -0,0 +1,179 @@
+package manifest
+
+import (
+	"bytes"
+	"cf/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var plainYAMLString = regexp.MustCompile(`^[A-Za-z_/][\w./:@-]*$`)
+
+// Write renders the manifest as YAML that can be read back into the same AppParams.
+func (m *Manifest) Write(writer io.Writer) (err error) {
+	buffer := &bytes.Buffer{}
+	fmt.Fprintln(buffer, "---")
+	fmt.Fprintln(buffer, "applications:")
+
+	for _, app := range m.Applications {
+		writeApp(buffer, app)
+	}
+
+	_, err = io.Copy(writer, buffer)
+	return
+}
+
+func writeApp(buffer *bytes.Buffer, app models.AppParams) {
+	prefix := "- "
+	writeField := func(key string, value string) {
+		if value == "" {
+			fmt.Fprintf(buffer, "%s%s:\n", prefix, key)
+		} else {
+			fmt.Fprintf(buffer, "%s%s: %s\n", prefix, key, value)
+		}
+		prefix = "  "
+	}
+
+	if app.Name != nil {
+		writeField("name", yamlValue(*app.Name))
+	}
+	if app.Memory != nil {
+		writeField("memory", fmt.Sprintf("%dM", *app.Memory))
+	}
+	if app.DiskQuota != nil {
+		writeField("disk_quota", fmt.Sprintf("%dM", *app.DiskQuota))
+	}
+	if app.InstanceCount != nil {
+		writeField("instances", fmt.Sprintf("%d", *app.InstanceCount))
+	}
+	if app.HealthCheckTimeout != nil {
+		writeField("timeout", fmt.Sprintf("%d", *app.HealthCheckTimeout))
+	}
+	if app.BuildpackUrl != nil {
+		writeField("buildpack", yamlValue(*app.BuildpackUrl))
+	}
+	if app.Command != nil {
+		if *app.Command == "" {
+			writeField("command", "null")
+		} else {
+			writeField("command", yamlValue(*app.Command))
+		}
+	}
+	if app.StackName != nil {
+		writeField("stack", yamlValue(*app.StackName))
+	}
+	if app.Path != nil {
+		writeField("path", yamlValue(*app.Path))
+	}
+	if app.Host != nil {
+		writeField("host", yamlValue(*app.Host))
+	}
+	if app.Hosts != nil {
+		writeField("hosts", "")
+		writeList(buffer, *app.Hosts)
+	}
+	if app.Domain != nil {
+		writeField("domain", yamlValue(*app.Domain))
+	}
+	if app.Domains != nil {
+		writeField("domains", "")
+		writeList(buffer, *app.Domains)
+	}
+	if app.NoRoute != nil && *app.NoRoute {
+		writeField("no-route", "true")
+	}
+
+	if app.DependsOn != nil {
+		writeField("depends_on", "")
+		writeList(buffer, *app.DependsOn)
+	}
+
+	if app.Services != nil && len(*app.Services) > 0 {
+		writeField("services", "")
+		writeServices(buffer, *app.Services, app.ServiceInstances)
+	}
+
+	if app.EnvironmentVars != nil && len(*app.EnvironmentVars) > 0 {
+		writeField("env", "")
+		envVars := *app.EnvironmentVars
+		for _, key := range sortedKeys(envVars) {
+			fmt.Fprintf(buffer, "    %s: %s\n", yamlString(key), yamlValue(envVars[key]))
+		}
+	}
+}
+
+func writeServices(buffer *bytes.Buffer, names []string, instances *[]models.ServiceInstanceParams) {
+	instancesByName := map[string]models.ServiceInstanceParams{}
+	if instances != nil {
+		for _, instance := range *instances {
+			instancesByName[instance.Name] = instance
+		}
+	}
+
+	for _, name := range names {
+		instance, found := instancesByName[name]
+		if !found {
+			fmt.Fprintf(buffer, "  - %s\n", yamlValue(name))
+			continue
+		}
+
+		fmt.Fprintf(buffer, "  - name: %s\n", yamlValue(instance.Name))
+		if !instance.IsUserProvided() {
+			fmt.Fprintf(buffer, "    label: %s\n", yamlValue(instance.Label))
+			fmt.Fprintf(buffer, "    plan: %s\n", yamlValue(instance.Plan))
+			continue
+		}
+
+		fmt.Fprintln(buffer, "    credentials:")
+		for _, key := range sortedKeys(instance.Credentials) {
+			fmt.Fprintf(buffer, "      %s: %s\n", yamlString(key), yamlValue(instance.Credentials[key]))
+		}
+		if instance.SyslogDrainUrl != "" {
+			fmt.Fprintf(buffer, "    syslog_drain_url: %s\n", yamlValue(instance.SyslogDrainUrl))
+		}
+	}
+}
+
+func writeList(buffer *bytes.Buffer, values []string) {
+	for _, value := range values {
+		fmt.Fprintf(buffer, "  - %s\n", yamlValue(value))
+	}
+}
+
+func sortedKeys(values map[string]string) (keys []string) {
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
+
+// yamlValue writes a value so that it is read back as it is, even when it contains a ${.
+func yamlValue(value string) string {
+	return yamlString(escapeVariables(value))
+}
+
+// yamlString quotes values that YAML would otherwise read as something other than the same
+// plain string, or as a key when they end in a colon. JSON strings are valid double quoted YAML
+// scalars.
+func yamlString(value string) string {
+	looksLikeKey := strings.HasSuffix(value, ":") || strings.Contains(value, ": ")
+	if plainYAMLString.MatchString(value) && !looksLikeKey && !isYAMLKeyword(value) {
+		return value
+	}
+
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+func isYAMLKeyword(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package manifest_test
+
+import (
+	"bytes"
+	. "cf/manifest"
+	"cf/models"
+	"fileutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"path/filepath"
+)
+
+var _ = Describe("writing manifests", func() {
+	It("writes apps as YAML", func() {
+		name := "my-app"
+		memory := uint64(256)
+		instances := 2
+		services := []string{"my-db"}
+		envVars := map[string]string{"B_VAR": "b", "A_VAR": "a"}
+
+		m := &Manifest{Applications: []models.AppParams{{
+			Name:            &name,
+			Memory:          &memory,
+			InstanceCount:   &instances,
+			Services:        &services,
+			EnvironmentVars: &envVars,
+		}}}
+
+		buffer := &bytes.Buffer{}
+		err := m.Write(buffer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buffer.String()).To(Equal(`---
+applications:
+- name: my-app
+  memory: 256M
+  instances: 2
+  services:
+  - my-db
+  env:
+    A_VAR: a
+    B_VAR: b
+`))
+	})
+
+	It("writes manifests that can be read back into the same apps", func() {
+		name := "my-app"
+		memory := uint64(1024)
+		diskQuota := uint64(2048)
+		instances := 3
+		buildpack := "https://github.com/cloudfoundry/heroku-buildpack-ruby.git"
+		command := `bundle exec rackup config.ru -p $PORT -E "production"`
+		stack := "lucid64"
+		host := "my-host"
+		domain := "example.com"
+		services := []string{"my-db", "my cache"}
+		envVars := map[string]string{
+			"NUMBER":     "42",
+			"BOOLEAN":    "true",
+			"EMPTY":      "",
+			"MULTI_LINE": "first\nsecond",
+			"SPECIAL":    "a: b # c, [d] {e} 'f' & *g",
+		}
+
+		serviceInstances := []models.ServiceInstanceParams{
+			{Name: "my-db", Label: "cleardb", Plan: "spark"},
+			{Name: "my-creds", Credentials: map[string]string{"password": "p@ss: word"}, SyslogDrainUrl: "syslog://example.com"},
+		}
+		servicesToCreate := []string{"my-db", "my-creds", "existing-service"}
+
+		multiRouteName := "my-other-app"
+		hosts := []string{"www", "shop"}
+		domains := []string{"example.com", "example.org"}
+		noRouteName := "worker"
+		noRoute := true
+		emptyCommand := ""
+		noEnvVars := map[string]string{}
+		dependsOn := []string{"my-app"}
+
+		m := &Manifest{Applications: []models.AppParams{
+			{
+				Name:            &name,
+				Memory:          &memory,
+				DiskQuota:       &diskQuota,
+				InstanceCount:   &instances,
+				BuildpackUrl:    &buildpack,
+				Command:         &command,
+				StackName:       &stack,
+				Host:            &host,
+				Domain:          &domain,
+				Services:        &services,
+				EnvironmentVars: &envVars,
+			},
+			{
+				Name:             &multiRouteName,
+				Hosts:            &hosts,
+				Domains:          &domains,
+				Services:         &servicesToCreate,
+				ServiceInstances: &serviceInstances,
+				EnvironmentVars:  &noEnvVars,
+			},
+			{
+				Name:            &noRouteName,
+				NoRoute:         &noRoute,
+				Command:         &emptyCommand,
+				DependsOn:       &dependsOn,
+				Services:        new([]string),
+				EnvironmentVars: &noEnvVars,
+			},
+		}}
+
+		fileutils.TempDir("manifest-round-trip", func(dir string, err error) {
+			Expect(err).NotTo(HaveOccurred())
+
+			path := filepath.Join(dir, "manifest.yml")
+			repo := NewManifestDiskRepository()
+			err = repo.WriteManifest(m, path)
+			Expect(err).NotTo(HaveOccurred())
+
+			readManifest, _, errs := repo.ReadManifest(path, NewVariables())
+			Expect(errs).To(BeEmpty())
+			Expect(readManifest.Applications).To(Equal(m.Applications))
+		})
+	})
+
+	It("writes values that read back as they are, even with colons or variable references", func() {
+		name := "my-app"
+		command := "run:"
+		host := "my-host:"
+		envVars := map[string]string{"URL": "http:", "KEY:": "value", "GREETING": "sh -c 'echo ${HOME}'", "ESCAPED": "$${"}
+
+		m := &Manifest{Applications: []models.AppParams{{
+			Name:            &name,
+			Command:         &command,
+			Host:            &host,
+			Services:        new([]string),
+			EnvironmentVars: &envVars,
+		}}}
+
+		fileutils.TempDir("manifest-colons", func(dir string, err error) {
+			Expect(err).NotTo(HaveOccurred())
+
+			path := filepath.Join(dir, "manifest.yml")
+			repo := NewManifestDiskRepository()
+			err = repo.WriteManifest(m, path)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`command: "run:"`))
+			Expect(string(contents)).To(ContainSubstring(`"KEY:": value`))
+			Expect(string(contents)).To(ContainSubstring(`URL: "http:"`))
+			Expect(string(contents)).To(ContainSubstring(`GREETING: "sh -c 'echo $${HOME}'"`))
+
+			readManifest, _, errs := repo.ReadManifest(path, NewVariables())
+			Expect(errs).To(BeEmpty())
+			Expect(readManifest.Applications).To(Equal(m.Applications))
+		})
+	})
+})
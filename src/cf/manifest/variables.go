@@ -0,0 +1,137 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"generic"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// variableRegex matches ${name} references as well as $${, which stands for a literal ${.
+var variableRegex = regexp.MustCompile(`\$\$\{|\${([\w.-]+)}`)
+
+const escapedVariableStart = "$${"
+
+// Variables holds the values substituted for ${name} references in a manifest.
+// Names that are not set fall back to environment variables of the same name.
+// A literal ${ is written as $${.
+type Variables map[string]string
+
+func NewVariables() Variables {
+	return Variables{}
+}
+
+func (vars Variables) Merge(other Variables) {
+	for key, value := range other {
+		vars[key] = value
+	}
+}
+
+// ParseVariable reads a single variable given as "key=value".
+func (vars Variables) ParseVariable(assignment string) (err error) {
+	parts := strings.SplitN(assignment, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		err = errors.New(fmt.Sprintf("Invalid variable '%s', expected key=value", assignment))
+		return
+	}
+
+	vars[strings.TrimSpace(parts[0])] = parts[1]
+	return
+}
+
+// ReadVariablesFile reads a YAML file of key: value pairs. Numbers and booleans are read as the
+// strings they were written as, lists and maps are refused.
+func ReadVariablesFile(path string) (vars Variables, err error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	mapp, err := parseManifest(file)
+	if err != nil {
+		err = errors.New(fmt.Sprintf("Error reading vars file %s: %s", path, err))
+		return
+	}
+
+	vars = NewVariables()
+	generic.Each(mapp, func(key, value interface{}) {
+		if err != nil {
+			return
+		}
+
+		switch value.(type) {
+		case string, int, int64, float64, bool:
+			vars[fmt.Sprintf("%v", key)] = fmt.Sprintf("%v", value)
+		default:
+			err = errors.New(fmt.Sprintf("Error reading vars file %s: value for '%s' must be a string, number or boolean", path, key))
+		}
+	})
+
+	return
+}
+
+func (vars Variables) lookup(name string) (value string, found bool) {
+	value, found = vars[name]
+	if found {
+		return
+	}
+
+	value = os.Getenv(name)
+	found = value != ""
+	return
+}
+
+func interpolateManifest(data generic.Map, vars Variables) (errs ManifestErrors) {
+	for _, key := range data.Keys() {
+		data.Set(key, interpolateValue(data.Get(key), fmt.Sprintf("%v", key), vars, &errs))
+	}
+	return
+}
+
+func interpolateValue(value interface{}, location string, vars Variables, errs *ManifestErrors) interface{} {
+	switch value := value.(type) {
+	case string:
+		return interpolateString(value, location, vars, errs)
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for index, item := range value {
+			result[index] = interpolateValue(item, fmt.Sprintf("%s[%d]", location, index), vars, errs)
+		}
+		return result
+	default:
+		if !generic.IsMappable(value) {
+			return value
+		}
+
+		result := generic.NewMap()
+		generic.Each(generic.NewMap(value), func(key, item interface{}) {
+			result.Set(key, interpolateValue(item, fmt.Sprintf("%s.%v", location, key), vars, errs))
+		})
+		return result
+	}
+}
+
+func interpolateString(value string, location string, vars Variables, errs *ManifestErrors) string {
+	return variableRegex.ReplaceAllStringFunc(value, func(match string) string {
+		if match == escapedVariableStart {
+			return "${"
+		}
+
+		name := variableRegex.FindStringSubmatch(match)[1]
+		replacement, found := vars.lookup(name)
+		if !found {
+			*errs = append(*errs, errors.New(fmt.Sprintf("Unresolved variable '%s' at %s", match, location)))
+			return match
+		}
+		return replacement
+	})
+}
+
+// escapeVariables keeps interpolation from replacing anything in value when it is read back.
+func escapeVariables(value string) string {
+	return strings.Replace(value, "${", escapedVariableStart, -1)
+}
@@ -0,0 +1,70 @@
+package manifest_test
+
+import (
+	. "cf/manifest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Variables", func() {
+	It("parses key=value assignments", func() {
+		vars := NewVariables()
+		err := vars.ParseVariable("host=my-host")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = vars.ParseVariable("command=bin/start --port=8080")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(vars).To(Equal(Variables{
+			"host":    "my-host",
+			"command": "bin/start --port=8080",
+		}))
+	})
+
+	It("rejects assignments without a key", func() {
+		vars := NewVariables()
+		Expect(vars.ParseVariable("no-equals-sign")).To(HaveOccurred())
+		Expect(vars.ParseVariable("=value")).To(HaveOccurred())
+	})
+
+	It("lets merged variables override existing ones", func() {
+		vars := Variables{"host": "old-host", "instances": "1"}
+		vars.Merge(Variables{"host": "new-host"})
+
+		Expect(vars).To(Equal(Variables{"host": "new-host", "instances": "1"}))
+	})
+
+	Describe("reading a vars file", func() {
+		It("reads the variables in the file", func() {
+			vars, err := ReadVariablesFile("../../fixtures/manifests/vars.yml")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vars).To(Equal(Variables{
+				"instances": "2",
+				"host":      "from-vars-file",
+			}))
+		})
+
+		It("reads numbers and booleans as strings", func() {
+			vars, err := ReadVariablesFile("../../fixtures/manifests/vars-with-scalars.yml")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vars).To(Equal(Variables{
+				"instances": "3",
+				"ratio":     "0.5",
+				"enabled":   "true",
+			}))
+		})
+
+		It("refuses lists and maps", func() {
+			_, err := ReadVariablesFile("../../fixtures/manifests/vars-with-list.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("value for 'hosts' must be a string, number or boolean"))
+		})
+
+		It("returns an error when the file does not exist", func() {
+			_, err := ReadVariablesFile("../../fixtures/manifests/no-such-vars.yml")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
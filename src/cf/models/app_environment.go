@@ -0,0 +1,39 @@
+package models
+
+import "encoding/json"
+
+// AppEnvironment is the whole environment of an app as the Cloud Controller sees it. The values
+// are the JSON the Cloud Controller sent, so that VCAP_SERVICES keeps its structure.
+type AppEnvironment struct {
+	System      map[string]interface{}
+	Application map[string]interface{}
+	User        map[string]interface{}
+	Running     map[string]interface{}
+	Staging     map[string]interface{}
+}
+
+// RuntimeVars are the variables the app sees when it runs. User-provided variables override the
+// running environment variable group, and the system-provided ones override both.
+func (env AppEnvironment) RuntimeVars() (vars map[string]string) {
+	vars = map[string]string{}
+	for _, section := range []map[string]interface{}{env.Running, env.User, env.System, env.Application} {
+		for key, value := range section {
+			vars[key] = EnvValueString(value)
+		}
+	}
+	return
+}
+
+// EnvValueString is the value of an environment variable as the app sees it, with structured
+// values written as JSON.
+func EnvValueString(value interface{}) string {
+	if stringValue, ok := value.(string); ok {
+		return stringValue
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(bytes)
+}
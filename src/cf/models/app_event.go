@@ -8,3 +8,31 @@ type EventFields struct {
 	Timestamp   time.Time
 	Description string
 }
+
+// EventQuery narrows down the events that are listed. Zero values leave them out of the query:
+// a zero Since or Until does not bound the time, and no Types allows every type.
+type EventQuery struct {
+	Since time.Time
+	Until time.Time
+	Types []string
+}
+
+// Includes tells whether event matches the query, for events that could not be filtered by the
+// Cloud Controller.
+func (query EventQuery) Includes(event EventFields, eventType string) bool {
+	if !query.Since.IsZero() && event.Timestamp.Before(query.Since) {
+		return false
+	}
+	if !query.Until.IsZero() && event.Timestamp.After(query.Until) {
+		return false
+	}
+	if len(query.Types) == 0 {
+		return true
+	}
+	for _, queryType := range query.Types {
+		if queryType == eventType {
+			return true
+		}
+	}
+	return false
+}
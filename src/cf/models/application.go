@@ -14,25 +14,31 @@ type Application struct {
 func (model Application) ToParams() (params AppParams) {
 	state := strings.ToUpper(model.State)
 	params = AppParams{
-		Guid:            &model.Guid,
-		Name:            &model.Name,
-		BuildpackUrl:    &model.BuildpackUrl,
-		Command:         &model.Command,
-		DiskQuota:       &model.DiskQuota,
-		InstanceCount:   &model.InstanceCount,
-		Memory:          &model.Memory,
-		State:           &state,
-		StackGuid:       &model.Stack.Guid,
-		SpaceGuid:       &model.SpaceGuid,
-		EnvironmentVars: &model.EnvironmentVars,
+		Guid:          &model.Guid,
+		Name:          &model.Name,
+		BuildpackUrl:  &model.BuildpackUrl,
+		Command:       &model.Command,
+		InstanceCount: &model.InstanceCount,
+		Memory:        &model.Memory,
+		State:         &state,
+		StackGuid:     &model.Stack.Guid,
+		SpaceGuid:     &model.SpaceGuid,
 	}
 
+	// an unknown disk quota or env is left as it is rather than cleared
+	if model.DiskQuota != 0 {
+		params.DiskQuota = &model.DiskQuota
+	}
+	if model.EnvironmentVars != nil {
+		params.EnvironmentVars = &model.EnvironmentVars
+	}
 	return
 }
 
 type AppSummary struct {
 	ApplicationFields
 	RouteSummaries []RouteSummary
+	Services       []ServiceInstanceFields
 }
 
 type ApplicationFields struct {
@@ -52,12 +58,15 @@ type ApplicationFields struct {
 type AppParams struct {
 	BuildpackUrl       *string
 	Command            *string
+	DependsOn          *[]string
 	DiskQuota          *uint64
 	Domain             *string
+	Domains            *[]string
 	EnvironmentVars    *map[string]string
 	Guid               *string
 	HealthCheckTimeout *int
 	Host               *string
+	Hosts              *[]string
 	InstanceCount      *int
 	Memory             *uint64
 	Name               *string
@@ -65,6 +74,7 @@ type AppParams struct {
 	Path               *string
 	RunningInstances   *int
 	Services           *[]string
+	ServiceInstances   *[]ServiceInstanceParams
 	SpaceGuid          *string
 	StackGuid          *string
 	StackName          *string
@@ -78,12 +88,18 @@ func (app *AppParams) Merge(other *AppParams) {
 	if other.Command != nil {
 		app.Command = other.Command
 	}
+	if other.DependsOn != nil {
+		app.DependsOn = other.DependsOn
+	}
 	if other.DiskQuota != nil {
 		app.DiskQuota = other.DiskQuota
 	}
 	if other.Domain != nil {
 		app.Domain = other.Domain
 	}
+	if other.Domains != nil {
+		app.Domains = other.Domains
+	}
 	if other.EnvironmentVars != nil {
 		app.EnvironmentVars = other.EnvironmentVars
 	}
@@ -96,6 +112,9 @@ func (app *AppParams) Merge(other *AppParams) {
 	if other.Host != nil {
 		app.Host = other.Host
 	}
+	if other.Hosts != nil {
+		app.Hosts = other.Hosts
+	}
 	if other.InstanceCount != nil {
 		app.InstanceCount = other.InstanceCount
 	}
@@ -117,6 +136,9 @@ func (app *AppParams) Merge(other *AppParams) {
 	if other.Services != nil {
 		app.Services = other.Services
 	}
+	if other.ServiceInstances != nil {
+		app.ServiceInstances = other.ServiceInstances
+	}
 	if other.SpaceGuid != nil {
 		app.SpaceGuid = other.SpaceGuid
 	}
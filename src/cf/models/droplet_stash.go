@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// DropletStash is a droplet that was saved before a push replaced it, together with the settings
+// the app ran it with.
+type DropletStash struct {
+	Version         int
+	AppName         string
+	StashedAt       time.Time
+	BuildpackUrl    string
+	Command         string
+	DiskQuota       uint64
+	EnvironmentVars map[string]string
+	InstanceCount   int
+	Memory          uint64
+}
+
+func NewDropletStash(app Application) (stash DropletStash) {
+	stash.AppName = app.Name
+	stash.BuildpackUrl = app.BuildpackUrl
+	stash.Command = app.Command
+	stash.DiskQuota = app.DiskQuota
+	stash.EnvironmentVars = app.EnvironmentVars
+	stash.InstanceCount = app.InstanceCount
+	stash.Memory = app.Memory
+	return
+}
+
+func (stash DropletStash) ToParams() (params AppParams) {
+	envVars := stash.EnvironmentVars
+	if envVars == nil {
+		envVars = map[string]string{}
+	}
+
+	params.BuildpackUrl = &stash.BuildpackUrl
+	params.Command = &stash.Command
+	params.EnvironmentVars = &envVars
+	params.InstanceCount = &stash.InstanceCount
+	params.Memory = &stash.Memory
+	if stash.DiskQuota != 0 {
+		params.DiskQuota = &stash.DiskQuota
+	}
+	return
+}
@@ -18,3 +18,17 @@ type ServiceInstance struct {
 func (inst ServiceInstance) IsUserProvided() bool {
 	return inst.ServicePlan.Guid == ""
 }
+
+// ServiceInstanceParams describes a service instance that push creates when it does not exist yet.
+// Instances with credentials are user provided, all others are created from a service plan.
+type ServiceInstanceParams struct {
+	Name           string
+	Label          string
+	Plan           string
+	Credentials    map[string]string
+	SyslogDrainUrl string
+}
+
+func (params ServiceInstanceParams) IsUserProvided() bool {
+	return params.Credentials != nil
+}
@@ -53,6 +53,7 @@ type tokenRefresher interface {
 type Request struct {
 	HttpReq      *http.Request
 	SeekableBody io.ReadSeeker
+	BodyStream   func() io.ReadCloser
 }
 
 type Gateway struct {
@@ -179,6 +180,8 @@ func (gateway Gateway) NewRequest(method, path, accessToken string, body io.Read
 				break
 			}
 			request.ContentLength = fileStats.Size()
+		case *ProgressReader:
+			request.ContentLength = v.Size()
 		}
 	}
 
@@ -186,6 +189,19 @@ func (gateway Gateway) NewRequest(method, path, accessToken string, body io.Read
 	return
 }
 
+// NewStreamingRequest builds a request whose body is not read from a file but written while it is
+// sent. newBody is called for every attempt, so a retry starts over with a fresh stream.
+func (gateway Gateway) NewStreamingRequest(method, path, accessToken string, contentLength int64, newBody func() io.ReadCloser) (req *Request, apiResponse ApiResponse) {
+	req, apiResponse = gateway.NewRequest(method, path, accessToken, nil)
+	if apiResponse.IsNotSuccessful() {
+		return
+	}
+
+	req.HttpReq.ContentLength = contentLength
+	req.BodyStream = newBody
+	return
+}
+
 func (gateway Gateway) PerformRequest(request *Request) (apiResponse ApiResponse) {
 	_, apiResponse = gateway.doRequestHandlingAuth(request)
 	return
@@ -271,12 +287,15 @@ func (gateway Gateway) PerformPollingRequestForJSONResponse(request *Request, re
 	}
 
 	jobUrl = fmt.Sprintf("%s://%s%s", request.HttpReq.URL.Scheme, request.HttpReq.URL.Host, asyncResponse.Metadata.Url)
-	apiResponse = gateway.waitForJob(jobUrl, request.HttpReq.Header.Get("Authorization"), timeout)
+	apiResponse = gateway.WaitForJob(jobUrl, request.HttpReq.Header.Get("Authorization"), timeout, nil)
 
 	return
 }
 
-func (gateway Gateway) waitForJob(jobUrl, accessToken string, timeout time.Duration) (apiResponse ApiResponse) {
+// WaitForJob polls the job at jobUrl until it finishes or fails. statusCb, when given, is called
+// with the status of the job every time it changes.
+func (gateway Gateway) WaitForJob(jobUrl, accessToken string, timeout time.Duration, statusCb func(status string)) (apiResponse ApiResponse) {
+	lastStatus := ""
 	startTime := time.Now()
 	for true {
 		if time.Since(startTime) > timeout {
@@ -293,6 +312,11 @@ func (gateway Gateway) waitForJob(jobUrl, accessToken string, timeout time.Durat
 			return
 		}
 
+		if statusCb != nil && response.Entity.Status != lastStatus {
+			lastStatus = response.Entity.Status
+			statusCb(lastStatus)
+		}
+
 		switch response.Entity.Status {
 		case JOB_FINISHED:
 			return
@@ -314,6 +338,9 @@ func (gateway Gateway) doRequestHandlingAuth(request *Request) (rawResponse *htt
 	if request.SeekableBody != nil {
 		httpReq.Body = ioutil.NopCloser(request.SeekableBody)
 	}
+	if request.BodyStream != nil {
+		httpReq.Body = request.BodyStream()
+	}
 
 	// perform request
 	rawResponse, apiResponse = gateway.doRequestAndHandlerError(request)
@@ -337,6 +364,9 @@ func (gateway Gateway) doRequestHandlingAuth(request *Request) (rawResponse *htt
 		request.SeekableBody.Seek(0, 0)
 		httpReq.Body = ioutil.NopCloser(request.SeekableBody)
 	}
+	if request.BodyStream != nil {
+		httpReq.Body = request.BodyStream()
+	}
 
 	// make the request again
 	rawResponse, apiResponse = gateway.doRequestAndHandlerError(request)
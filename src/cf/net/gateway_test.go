@@ -8,6 +8,7 @@ import (
 	"fmt"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -157,6 +158,20 @@ var _ = Describe("Testing with ginkgo", func() {
 			Expect(apiResponse.IsSuccessful()).To(BeTrue())
 		})
 
+		It("tells every change of the status of the job it waits for", func() {
+			go func() {
+				time.Sleep(25 * time.Millisecond)
+				jobStatus = "finished"
+			}()
+
+			statuses := []string{}
+			apiResponse := ccGateway.WaitForJob(config.ApiEndpoint()+"/v2/jobs/the-job-guid", config.AccessToken(), 500*time.Millisecond, func(status string) {
+				statuses = append(statuses, status)
+			})
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(statuses).To(Equal([]string{"queued", "finished"}))
+		})
+
 		It("returns an error if jobs takes longer than the timeout", func() {
 			request, _ := ccGateway.NewRequest("GET", config.ApiEndpoint()+"/v2/foo", config.AccessToken(), nil)
 			_, apiResponse := ccGateway.PerformPollingRequestForJSONResponse(request, new(struct{}), 10*time.Millisecond)
@@ -214,6 +229,82 @@ var _ = Describe("Testing with ginkgo", func() {
 		})
 	})
 
+	Describe("when streaming a request body", func() {
+		var apiServer *httptest.Server
+		var authServer *httptest.Server
+
+		BeforeEach(func() {
+			apiServer = httptest.NewTLSServer(refreshTokenApiEndPoint(
+				`{ "code": 1000, "description": "Auth token is invalid" }`,
+				testnet.TestResponse{Status: http.StatusOK},
+			))
+
+			authServer = httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				fmt.Fprintln(
+					writer,
+					`{ "access_token": "new-access-token", "token_type": "bearer", "refresh_token": "new-refresh-token"}`)
+			}))
+		})
+
+		AfterEach(func() {
+			apiServer.Close()
+			authServer.Close()
+		})
+
+		It("creates a new stream when the request is sent again after refreshing the token", func() {
+			config, auth := createAuthenticationRepository(apiServer, authServer)
+			ccGateway.SetTokenRefresher(auth)
+
+			streams := 0
+			request, apiResponse := ccGateway.NewStreamingRequest("POST", config.ApiEndpoint()+"/v2/foo", config.AccessToken(), 13, func() io.ReadCloser {
+				streams++
+				return ioutil.NopCloser(strings.NewReader("expected body"))
+			})
+			Expect(apiResponse.IsSuccessful()).To(BeTrue())
+			Expect(request.HttpReq.ContentLength).To(Equal(int64(13)))
+
+			apiResponse = ccGateway.PerformRequest(request)
+			Expect(apiResponse.Message).To(BeEmpty())
+			Expect(streams).To(Equal(2))
+		})
+	})
+
+	It("refreshes the token once when requests sent at the same time all find it expired", func() {
+		apiServer := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Header.Get("Authorization") != "bearer new-access-token" {
+				writer.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprintln(writer, `{ "code": 1000, "description": "Auth token is invalid" }`)
+			}
+		}))
+		defer apiServer.Close()
+
+		refreshes := make(chan bool, 10)
+		authServer := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			refreshes <- true
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprintln(writer, `{ "access_token": "new-access-token", "token_type": "bearer", "refresh_token": "new-refresh-token"}`)
+		}))
+		defer authServer.Close()
+
+		config, auth := createAuthenticationRepository(apiServer, authServer)
+		ccGateway.SetTokenRefresher(auth)
+
+		responses := make(chan ApiResponse, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				defer GinkgoRecover()
+				request, apiResponse := ccGateway.NewRequest("GET", config.ApiEndpoint()+"/v2/foo", config.AccessToken(), nil)
+				Expect(apiResponse.IsSuccessful()).To(BeTrue())
+				responses <- ccGateway.PerformRequest(request)
+			}()
+		}
+
+		Expect((<-responses).IsSuccessful()).To(BeTrue())
+		Expect((<-responses).IsSuccessful()).To(BeTrue())
+		Expect(len(refreshes)).To(Equal(1))
+		Expect(config.AccessToken()).To(Equal("bearer new-access-token"))
+	})
+
 	It("TestRefreshingTheTokenWithUAARequest", func() {
 		endpoint := refreshTokenApiEndPoint(
 			`{ "error": "invalid_token", "error_description": "Auth token is invalid" }`,
@@ -35,7 +35,13 @@ func PrepareRedirect(req *http.Request, via []*http.Request) error {
 
 	prevReq := via[len(via)-1]
 
-	req.Header.Set("Authorization", prevReq.Header.Get("Authorization"))
+	// the token is only for the host that was asked, redirects elsewhere (e.g. downloads that are
+	// sent on to the blobstore) must not see it
+	if req.URL.Host == prevReq.URL.Host {
+		req.Header.Set("Authorization", prevReq.Header.Get("Authorization"))
+	} else {
+		req.Header.Del("Authorization")
+	}
 
 	dumpRequest(req)
 
@@ -87,11 +93,34 @@ func dumpRequest(req *http.Request) {
 	}
 }
 
+// binaryContentTypes are responses such as app bits and droplets that are streamed to disk, so
+// they are neither read into memory nor written to the trace.
+var binaryContentTypes = []string{
+	"application/zip",
+	"application/octet-stream",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-tar",
+}
+
+func isBinaryContentType(contentType string) bool {
+	for _, binaryType := range binaryContentTypes {
+		if strings.HasPrefix(contentType, binaryType) {
+			return true
+		}
+	}
+	return false
+}
+
 func dumpResponse(res *http.Response) {
-	dumpedResponse, err := httputil.DumpResponse(res, true)
+	shouldDisplayBody := !isBinaryContentType(res.Header.Get("Content-Type"))
+	dumpedResponse, err := httputil.DumpResponse(res, shouldDisplayBody)
 	if err != nil {
 		trace.Logger.Printf("Error dumping response\n%s\n", err)
 	} else {
 		trace.Logger.Printf("\n%s [%s]\n%s\n", terminal.HeaderColor("RESPONSE:"), time.Now().Format(time.RFC3339), Sanitize(string(dumpedResponse)))
+		if !shouldDisplayBody {
+			trace.Logger.Println("[BINARY CONTENT HIDDEN]")
+		}
 	}
 }
@@ -145,6 +145,20 @@ Server: Apache-Coyote/1.1
 		Expect(err).NotTo(HaveOccurred())
 		Expect(redirectReq.Header.Get("Authorization")).To(Equal("my-auth-token"))
 	})
+	It("TestPrepareRedirectDropsAuthorizationHeaderForOtherHosts", func() {
+		originalReq, err := http.NewRequest("GET", "https://api.example.com/v2/apps/my-app-guid/download", nil)
+		Expect(err).NotTo(HaveOccurred())
+		originalReq.Header.Set("Authorization", "my-auth-token")
+
+		redirectReq, err := http.NewRequest("GET", "https://blobstore.example.com/my-app.zip?signature=abc", nil)
+		Expect(err).NotTo(HaveOccurred())
+		redirectReq.Header.Set("Authorization", "my-auth-token")
+
+		err = PrepareRedirect(redirectReq, []*http.Request{originalReq})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(redirectReq.Header.Get("Authorization")).To(Equal(""))
+	})
 	It("TestPrepareRedirectFailsAfterOneRedirect", func() {
 
 		firstReq, err := http.NewRequest("GET", "/foo", nil)
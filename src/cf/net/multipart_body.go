@@ -0,0 +1,130 @@
+package net
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// MultipartBody is a multipart/form-data request body that is written while it is sent instead of
+// being put together in a temporary file first. Its length is known up front, and it can be read
+// again from the start as often as needed, e.g. to retry a request after refreshing the auth token.
+type MultipartBody struct {
+	boundary string
+	parts    []multipartPart
+}
+
+type multipartPart struct {
+	header textproto.MIMEHeader
+	write  func(destination io.Writer) error
+	size   int64
+}
+
+func NewMultipartBody() (body *MultipartBody) {
+	body = new(MultipartBody)
+	body.boundary = multipart.NewWriter(ioutil.Discard).Boundary()
+	return
+}
+
+func (body *MultipartBody) AddField(name string, value []byte) {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, name))
+	body.AddPart(header, bytes.NewReader(value), int64(len(value)))
+}
+
+func (body *MultipartBody) AddFile(fieldName, fileName string, contents io.ReaderAt, size int64) {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, fieldName, fileName))
+	header.Set("Content-Type", "application/octet-stream")
+	body.AddPart(header, contents, size)
+}
+
+// AddPart adds a part with the given header whose contents are read from contents, which has to
+// stay readable until the request is done.
+func (body *MultipartBody) AddPart(header textproto.MIMEHeader, contents io.ReaderAt, size int64) {
+	body.AddWrittenPart(header, size, func(destination io.Writer) (err error) {
+		_, err = io.Copy(destination, io.NewSectionReader(contents, 0, size))
+		return
+	})
+}
+
+// AddWrittenPart adds a part whose contents are generated by write every time the body is read,
+// so that they never have to be kept anywhere. write has to produce exactly size bytes each time.
+func (body *MultipartBody) AddWrittenPart(header textproto.MIMEHeader, size int64, write func(destination io.Writer) error) {
+	body.parts = append(body.parts, multipartPart{header: header, write: write, size: size})
+}
+
+func (body *MultipartBody) ContentType() string {
+	return "multipart/form-data; boundary=" + body.boundary
+}
+
+// Length is the number of bytes a reader of the body returns, counted without reading any of the
+// contents of the parts.
+func (body *MultipartBody) Length() (length int64) {
+	counter := &CountingWriter{}
+	writer := body.newWriter(counter)
+	for _, part := range body.parts {
+		writer.CreatePart(part.header)
+		length += part.size
+	}
+	writer.Close()
+
+	return length + counter.Count
+}
+
+// Reader returns a reader of the whole body, reporting the bytes sent to progressCb. The body is
+// written as the reader is read, and writing stops when the reader is closed.
+func (body *MultipartBody) Reader(progressCb ProgressCallback) io.ReadCloser {
+	reader, writer := io.Pipe()
+
+	go func() {
+		writer.CloseWithError(body.writeTo(NewProgressWriter(writer, body.Length(), progressCb)))
+	}()
+
+	return reader
+}
+
+func (body *MultipartBody) writeTo(destination io.Writer) (err error) {
+	writer := body.newWriter(destination)
+
+	for _, part := range body.parts {
+		var partWriter io.Writer
+		partWriter, err = writer.CreatePart(part.header)
+		if err != nil {
+			return
+		}
+
+		counter := &CountingWriter{}
+		err = part.write(io.MultiWriter(partWriter, counter))
+		if err != nil {
+			return
+		}
+
+		if counter.Count != part.size {
+			err = errors.New(fmt.Sprintf("Part of %d bytes changed to %d bytes while it was sent", part.size, counter.Count))
+			return
+		}
+	}
+
+	return writer.Close()
+}
+
+func (body *MultipartBody) newWriter(destination io.Writer) (writer *multipart.Writer) {
+	writer = multipart.NewWriter(destination)
+	writer.SetBoundary(body.boundary)
+	return
+}
+
+// CountingWriter throws away what is written to it, counting the bytes.
+type CountingWriter struct {
+	Count int64
+}
+
+func (writer *CountingWriter) Write(p []byte) (n int, err error) {
+	writer.Count += int64(len(p))
+	return len(p), nil
+}
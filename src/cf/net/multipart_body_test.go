@@ -0,0 +1,121 @@
+package net_test
+
+import (
+	. "cf/net"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+var _ = Describe("MultipartBody", func() {
+	var body *MultipartBody
+
+	BeforeEach(func() {
+		body = NewMultipartBody()
+		body.AddField("resources", []byte(`[{"fn":"app.rb"}]`))
+		body.AddFile("droplet", "droplet.tgz", strings.NewReader("droplet contents"), 16)
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="application"; filename="application.zip"`)
+		header.Set("Content-Type", "application/zip")
+		body.AddPart(header, strings.NewReader("zip contents"), 12)
+	})
+
+	readBody := func() []byte {
+		reader := body.Reader(nil)
+		defer reader.Close()
+
+		contents, err := ioutil.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		return contents
+	}
+
+	It("writes the parts as multipart/form-data", func() {
+		mediaType, params, err := mime.ParseMediaType(body.ContentType())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mediaType).To(Equal("multipart/form-data"))
+
+		reader := multipart.NewReader(strings.NewReader(string(readBody())), params["boundary"])
+		form, err := reader.ReadForm(1024)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(form.Value["resources"]).To(Equal([]string{`[{"fn":"app.rb"}]`}))
+		Expect(form.File["droplet"][0].Filename).To(Equal("droplet.tgz"))
+		Expect(form.File["application"][0].Header.Get("Content-Type")).To(Equal("application/zip"))
+
+		file, err := form.File["application"][0].Open()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.ReadAll(file)).To(Equal([]byte("zip contents")))
+	})
+
+	It("knows its length without reading the parts", func() {
+		Expect(body.Length()).To(Equal(int64(len(readBody()))))
+	})
+
+	It("can be read again from the start", func() {
+		Expect(readBody()).To(Equal(readBody()))
+	})
+
+	It("reports the bytes sent", func() {
+		var bytesSent, total int64
+		reader := body.Reader(func(bytesRead, totalBytes int64) {
+			bytesSent = bytesRead
+			total = totalBytes
+		})
+		ioutil.ReadAll(reader)
+		reader.Close()
+
+		Expect(bytesSent).To(Equal(body.Length()))
+		Expect(total).To(Equal(body.Length()))
+	})
+
+	It("stops writing when the reader is closed early", func() {
+		reader := body.Reader(nil)
+		reader.Read(make([]byte, 10))
+		err := reader.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = reader.Read(make([]byte, 10))
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("parts that are written while the body is read", func() {
+		var writes int
+		var contents string
+
+		BeforeEach(func() {
+			writes = 0
+			contents = "zipped app"
+
+			header := make(textproto.MIMEHeader)
+			header.Set("Content-Disposition", `form-data; name="written"`)
+			body.AddWrittenPart(header, 10, func(destination io.Writer) (err error) {
+				writes++
+				_, err = io.WriteString(destination, contents)
+				return
+			})
+		})
+
+		It("writes them again every time the body is read", func() {
+			Expect(strings.Contains(string(readBody()), "zipped app")).To(BeTrue())
+			Expect(body.Length()).To(Equal(int64(len(readBody()))))
+			Expect(writes).To(Equal(2))
+		})
+
+		It("fails when they do not have the size they were added with", func() {
+			contents = "a larger zipped app"
+
+			reader := body.Reader(nil)
+			defer reader.Close()
+
+			_, err := ioutil.ReadAll(reader)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("changed to 19 bytes"))
+		})
+	})
+})
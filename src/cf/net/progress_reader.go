@@ -0,0 +1,54 @@
+package net
+
+import (
+	"io"
+)
+
+type ProgressCallback func(bytesRead, totalBytes int64)
+
+type ProgressReader struct {
+	reader    io.ReadSeeker
+	total     int64
+	bytesRead int64
+	callback  ProgressCallback
+}
+
+func NewProgressReader(reader io.ReadSeeker, total int64, callback ProgressCallback) *ProgressReader {
+	return &ProgressReader{
+		reader:   reader,
+		total:    total,
+		callback: callback,
+	}
+}
+
+func (progressReader *ProgressReader) Read(p []byte) (n int, err error) {
+	n, err = progressReader.reader.Read(p)
+	if n > 0 {
+		progressReader.bytesRead += int64(n)
+		progressReader.report()
+	}
+	return
+}
+
+// Seeking (e.g. when the gateway rewinds the body to retry after a token refresh)
+// moves the progress back so that the retried upload is reported from the new offset.
+func (progressReader *ProgressReader) Seek(offset int64, whence int) (newOffset int64, err error) {
+	newOffset, err = progressReader.reader.Seek(offset, whence)
+	if err != nil {
+		return
+	}
+
+	progressReader.bytesRead = newOffset
+	return
+}
+
+func (progressReader *ProgressReader) Size() int64 {
+	return progressReader.total
+}
+
+func (progressReader *ProgressReader) report() {
+	if progressReader.callback == nil {
+		return
+	}
+	progressReader.callback(progressReader.bytesRead, progressReader.total)
+}
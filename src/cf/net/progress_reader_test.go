@@ -0,0 +1,95 @@
+package net_test
+
+import (
+	. "cf/net"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	testnet "testhelpers/net"
+)
+
+var _ = Describe("ProgressReader", func() {
+	var (
+		reportedBytes []int64
+		reportedTotal int64
+		reader        *ProgressReader
+	)
+
+	BeforeEach(func() {
+		reportedBytes = []int64{}
+		reader = NewProgressReader(strings.NewReader("expected body"), 13, func(bytesRead, totalBytes int64) {
+			reportedBytes = append(reportedBytes, bytesRead)
+			reportedTotal = totalBytes
+		})
+	})
+
+	It("reports the bytes read so far", func() {
+		buffer := make([]byte, 5)
+		reader.Read(buffer)
+		reader.Read(buffer)
+
+		Expect(reportedBytes).To(Equal([]int64{5, 10}))
+		Expect(reportedTotal).To(Equal(int64(13)))
+	})
+
+	It("reports the full size once everything has been read", func() {
+		body, err := ioutil.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(Equal("expected body"))
+		Expect(reportedBytes[len(reportedBytes)-1]).To(Equal(int64(13)))
+	})
+
+	It("starts counting again when it is rewound", func() {
+		ioutil.ReadAll(reader)
+		reader.Seek(0, 0)
+		reader.Read(make([]byte, 4))
+
+		Expect(reportedBytes[len(reportedBytes)-1]).To(Equal(int64(4)))
+	})
+
+	It("knows the size of the body", func() {
+		Expect(reader.Size()).To(Equal(int64(13)))
+	})
+
+	It("sets the content length of requests", func() {
+		request, apiResponse := NewCloudControllerGateway().NewRequest("PUT", "https://example.com/v2/foo", "BEARER my-access-token", reader)
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+		Expect(request.HttpReq.ContentLength).To(Equal(int64(13)))
+	})
+
+	It("reports progress for the retried request after a token refresh", func() {
+		endpoint := refreshTokenApiEndPoint(
+			`{ "code": 1000, "description": "Auth token is invalid" }`,
+			testnet.TestResponse{Status: http.StatusOK},
+		)
+		apiServer := httptest.NewTLSServer(endpoint)
+		defer apiServer.Close()
+
+		authServer := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			fmt.Fprintln(writer, `{ "access_token": "new-access-token", "token_type": "bearer", "refresh_token": "new-refresh-token"}`)
+		}))
+		defer authServer.Close()
+
+		config, auth := createAuthenticationRepository(apiServer, authServer)
+		gateway := NewCloudControllerGateway()
+		gateway.SetTokenRefresher(auth)
+
+		request, apiResponse := gateway.NewRequest("POST", config.ApiEndpoint()+"/v2/foo", config.AccessToken(), reader)
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+
+		apiResponse = gateway.PerformRequest(request)
+		Expect(apiResponse.IsSuccessful()).To(BeTrue())
+
+		timesCompleted := 0
+		for _, bytesRead := range reportedBytes {
+			if bytesRead == 13 {
+				timesCompleted++
+			}
+		}
+		Expect(timesCompleted).To(Equal(2))
+	})
+})
@@ -0,0 +1,47 @@
+package net
+
+import (
+	"io"
+)
+
+// ProgressWriter reports the bytes written through it, e.g. while a download is saved to disk.
+// A negative total means the size is not known in advance.
+type ProgressWriter struct {
+	writer       io.Writer
+	total        int64
+	bytesWritten int64
+	callback     ProgressCallback
+}
+
+func NewProgressWriter(writer io.Writer, total int64, callback ProgressCallback) *ProgressWriter {
+	return &ProgressWriter{
+		writer:   writer,
+		total:    total,
+		callback: callback,
+	}
+}
+
+func (progressWriter *ProgressWriter) Write(p []byte) (n int, err error) {
+	n, err = progressWriter.writer.Write(p)
+	if n > 0 {
+		progressWriter.bytesWritten += int64(n)
+		progressWriter.report()
+	}
+	return
+}
+
+func (progressWriter *ProgressWriter) BytesWritten() int64 {
+	return progressWriter.bytesWritten
+}
+
+func (progressWriter *ProgressWriter) report() {
+	if progressWriter.callback == nil {
+		return
+	}
+
+	total := progressWriter.total
+	if total < 0 {
+		total = progressWriter.bytesWritten
+	}
+	progressWriter.callback(progressWriter.bytesWritten, total)
+}
@@ -0,0 +1,44 @@
+package net_test
+
+import (
+	"bytes"
+	. "cf/net"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProgressWriter", func() {
+	var (
+		reportedBytes []int64
+		reportedTotal int64
+		buffer        *bytes.Buffer
+	)
+
+	callback := func(bytesWritten, totalBytes int64) {
+		reportedBytes = append(reportedBytes, bytesWritten)
+		reportedTotal = totalBytes
+	}
+
+	BeforeEach(func() {
+		reportedBytes = []int64{}
+		buffer = &bytes.Buffer{}
+	})
+
+	It("writes through and reports the bytes written so far", func() {
+		writer := NewProgressWriter(buffer, 13, callback)
+		writer.Write([]byte("expected"))
+		writer.Write([]byte(" body"))
+
+		Expect(buffer.String()).To(Equal("expected body"))
+		Expect(reportedBytes).To(Equal([]int64{8, 13}))
+		Expect(reportedTotal).To(Equal(int64(13)))
+		Expect(writer.BytesWritten()).To(Equal(int64(13)))
+	})
+
+	It("reports the bytes written as the total when the size is not known", func() {
+		writer := NewProgressWriter(buffer, -1, callback)
+		writer.Write([]byte("expected"))
+
+		Expect(reportedTotal).To(Equal(int64(8)))
+	})
+})
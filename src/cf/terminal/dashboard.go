@@ -0,0 +1,116 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Dashboard shows a screen of lines that is redrawn in place, like top, until the user presses q
+// or Ctrl-C.
+type Dashboard interface {
+	Draw(lines []string)
+	WaitForQuit(duration time.Duration) (quit bool)
+	Close()
+}
+
+type terminalDashboard struct {
+	output       io.Writer
+	interactive  bool
+	quit         chan bool
+	quitOnce     *sync.Once
+	signals      chan os.Signal
+	restoreInput func()
+	drawn        bool
+}
+
+// NewDashboard returns a dashboard that reads keys from input. When output is not interactive
+// every screen is printed below the last one instead of replacing it.
+func NewDashboard(output io.Writer, input *os.File, interactive bool) Dashboard {
+	dashboard := &terminalDashboard{
+		output:       output,
+		interactive:  interactive,
+		quit:         make(chan bool),
+		quitOnce:     &sync.Once{},
+		signals:      make(chan os.Signal, 1),
+		restoreInput: func() {},
+	}
+
+	signal.Notify(dashboard.signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-dashboard.signals; ok {
+			dashboard.stop()
+		}
+	}()
+
+	if interactive {
+		dashboard.restoreInput = readKeysImmediately(input)
+		fmt.Fprint(output, "\033[?25l")
+	}
+	go dashboard.readKeys(input)
+
+	return dashboard
+}
+
+func (dashboard *terminalDashboard) Draw(lines []string) {
+	if !dashboard.interactive {
+		fmt.Fprintf(dashboard.output, "%s\n\n", strings.Join(lines, "\n"))
+		return
+	}
+
+	screen := "\033[H"
+	if !dashboard.drawn {
+		screen = "\033[2J" + screen
+		dashboard.drawn = true
+	}
+	for _, line := range lines {
+		screen += line + "\033[K\n"
+	}
+	fmt.Fprint(dashboard.output, screen+"\033[J")
+}
+
+func (dashboard *terminalDashboard) WaitForQuit(duration time.Duration) (quit bool) {
+	select {
+	case <-dashboard.quit:
+		return true
+	case <-time.After(duration):
+		return false
+	}
+}
+
+func (dashboard *terminalDashboard) Close() {
+	signal.Stop(dashboard.signals)
+	close(dashboard.signals)
+	dashboard.restoreInput()
+
+	if dashboard.interactive {
+		fmt.Fprint(dashboard.output, "\033[?25h")
+	}
+}
+
+func (dashboard *terminalDashboard) readKeys(input io.Reader) {
+	key := make([]byte, 1)
+	for {
+		_, err := input.Read(key)
+		if err != nil {
+			return
+		}
+
+		switch key[0] {
+		case 'q', 'Q', 3:
+			dashboard.stop()
+			return
+		}
+	}
+}
+
+func (dashboard *terminalDashboard) stop() {
+	dashboard.quitOnce.Do(func() {
+		close(dashboard.quit)
+	})
+}
@@ -0,0 +1,63 @@
+package terminal_test
+
+import (
+	"bytes"
+	. "cf/terminal"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"time"
+)
+
+var _ = Describe("Dashboard", func() {
+	var (
+		output *bytes.Buffer
+		input  *os.File
+		keys   *os.File
+	)
+
+	BeforeEach(func() {
+		var err error
+		output = &bytes.Buffer{}
+		input, keys, err = os.Pipe()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		input.Close()
+		keys.Close()
+	})
+
+	It("redraws the screen in place when interactive", func() {
+		dashboard := NewDashboard(output, input, true)
+		dashboard.Draw([]string{"first", "screen"})
+		dashboard.Draw([]string{"second"})
+		dashboard.Close()
+
+		Expect(output.String()).To(Equal(
+			"\033[?25l" +
+				"\033[2J\033[Hfirst\033[K\nscreen\033[K\n\033[J" +
+				"\033[Hsecond\033[K\n\033[J" +
+				"\033[?25h"))
+	})
+
+	It("prints every screen below the last one when not interactive", func() {
+		dashboard := NewDashboard(output, input, false)
+		dashboard.Draw([]string{"first", "screen"})
+		dashboard.Draw([]string{"second"})
+		dashboard.Close()
+
+		Expect(output.String()).To(Equal("first\nscreen\n\nsecond\n\n"))
+	})
+
+	It("quits when q is pressed", func() {
+		dashboard := NewDashboard(output, input, false)
+		defer dashboard.Close()
+
+		Expect(dashboard.WaitForQuit(time.Millisecond)).To(BeFalse())
+
+		keys.Write([]byte("xq"))
+		Expect(dashboard.WaitForQuit(time.Minute)).To(BeTrue())
+		Expect(dashboard.WaitForQuit(time.Minute)).To(BeTrue())
+	})
+})
@@ -0,0 +1,37 @@
+// +build darwin freebsd linux netbsd openbsd
+
+package terminal
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// readKeysImmediately switches the terminal to pass on keys as they are pressed, without echoing
+// them, and returns a function that restores the previous settings.
+func readKeysImmediately(input *os.File) (restore func()) {
+	restore = func() {}
+
+	settings, err := stty(input, "-g")
+	if err != nil {
+		return
+	}
+
+	_, err = stty(input, "-icanon", "-echo", "min", "1")
+	if err != nil {
+		return
+	}
+
+	return func() {
+		stty(input, strings.TrimSpace(settings))
+	}
+}
+
+func stty(input *os.File, args ...string) (output string, err error) {
+	command := exec.Command("stty", args...)
+	command.Stdin = input
+	outputBytes, err := command.Output()
+	output = string(outputBytes)
+	return
+}
@@ -0,0 +1,151 @@
+package terminal
+
+import (
+	"cf/configuration"
+	"fmt"
+	"github.com/codegangsta/cli"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UIFailure is what a prefixed UI panics with when Failed is called. Whoever runs the operation
+// that uses the UI can recover it and carry on with other work instead of exiting.
+type UIFailure struct {
+	Message string
+}
+
+func (failure UIFailure) Error() string {
+	return failure.Message
+}
+
+type prefixedUI struct {
+	ui     UI
+	prefix string
+	lock   *sync.Mutex
+}
+
+// NewPrefixedUI returns a UI that starts every line of output with prefix, so that the output of
+// operations running side by side can be told apart. UIs sharing the same lock never interleave
+// the lines of a single message.
+func NewPrefixedUI(ui UI, prefix string, lock *sync.Mutex) UI {
+	return &prefixedUI{ui: ui, prefix: prefix, lock: lock}
+}
+
+func (ui *prefixedUI) PrintPaginator(rows []string, err error) {
+	if err != nil {
+		ui.Failed(err.Error())
+		return
+	}
+
+	for _, row := range rows {
+		ui.Say("%s", row)
+	}
+}
+
+func (ui *prefixedUI) Say(message string, args ...interface{}) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.ui.Say("%s", ui.prefixLines(fmt.Sprintf(message, args...)))
+}
+
+func (ui *prefixedUI) Warn(message string, args ...interface{}) {
+	ui.Say("%s", WarningColor(fmt.Sprintf(message, args...)))
+}
+
+func (ui *prefixedUI) Ask(prompt string, args ...interface{}) (answer string) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	return ui.ui.Ask("%s", ui.prefixLines(fmt.Sprintf(prompt, args...)))
+}
+
+func (ui *prefixedUI) AskForPassword(prompt string, args ...interface{}) (answer string) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	return ui.ui.AskForPassword("%s", ui.prefixLines(fmt.Sprintf(prompt, args...)))
+}
+
+func (ui *prefixedUI) Confirm(prompt string, args ...interface{}) bool {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	return ui.ui.Confirm("%s", ui.prefixLines(fmt.Sprintf(prompt, args...)))
+}
+
+func (ui *prefixedUI) Ok() {
+	ui.Say("%s", SuccessColor("OK"))
+}
+
+func (ui *prefixedUI) Failed(message string, args ...interface{}) {
+	message = fmt.Sprintf(message, args...)
+	ui.Say("%s\n%s", FailureColor("FAILED"), message)
+	panic(UIFailure{Message: message})
+}
+
+func (ui *prefixedUI) FailWithUsage(ctxt *cli.Context, cmdName string) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.ui.FailWithUsage(ctxt, cmdName)
+}
+
+func (ui *prefixedUI) ConfigFailure(err error) {
+	ui.Failed(err.Error())
+}
+
+func (ui *prefixedUI) ShowConfiguration(config configuration.Reader) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.ui.ShowConfiguration(config)
+}
+
+func (ui *prefixedUI) LoadingIndication() {
+}
+
+func (ui *prefixedUI) Wait(duration time.Duration) {
+	ui.ui.Wait(duration)
+}
+
+func (ui *prefixedUI) DisplayTable(table [][]string) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.ui.DisplayTable(table)
+}
+
+func (ui *prefixedUI) Table(headers []string) Table {
+	return NewTable(ui, headers)
+}
+
+// ProgressBar reports progress as separate lines, because redrawing a single line does not work
+// when other output can be printed in between.
+func (ui *prefixedUI) ProgressBar() ProgressBar {
+	return NewProgressBar(prefixedWriter{ui}, false, time.Now)
+}
+
+// Dashboard prints every screen below the last one, for the same reason.
+func (ui *prefixedUI) Dashboard() Dashboard {
+	return NewDashboard(prefixedWriter{ui}, os.Stdin, false)
+}
+
+func (ui *prefixedUI) prefixLines(message string) string {
+	lines := strings.Split(message, "\n")
+	for index, line := range lines {
+		lines[index] = ui.prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+type prefixedWriter struct {
+	ui UI
+}
+
+func (writer prefixedWriter) Write(p []byte) (n int, err error) {
+	writer.ui.Say("%s", strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
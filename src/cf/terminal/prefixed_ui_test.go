@@ -0,0 +1,54 @@
+package terminal_test
+
+import (
+	. "cf/terminal"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+	"sync"
+	testterm "testhelpers/terminal"
+)
+
+var _ = Describe("PrefixedUI", func() {
+	var (
+		fakeUI *testterm.FakeUI
+		ui     UI
+	)
+
+	BeforeEach(func() {
+		fakeUI = &testterm.FakeUI{}
+		ui = NewPrefixedUI(fakeUI, "[my-app] ", &sync.Mutex{})
+	})
+
+	It("prefixes every line of a message", func() {
+		ui.Say("Staging %s\n100%% done", "my-app")
+
+		Expect(fakeUI.Outputs).To(Equal([]string{
+			"[my-app] Staging my-app",
+			"[my-app] 100% done",
+		}))
+	})
+
+	It("panics with the message instead of exiting when failing", func() {
+		var recovered interface{}
+		func() {
+			defer func() { recovered = recover() }()
+			ui.Failed("Could not find %s", "something")
+		}()
+
+		Expect(recovered).To(Equal(UIFailure{Message: "Could not find something"}))
+		Expect(len(fakeUI.Outputs)).To(Equal(2))
+		Expect(fakeUI.Outputs[0]).To(ContainSubstring("[my-app] "))
+		Expect(fakeUI.Outputs[0]).To(ContainSubstring("FAILED"))
+		Expect(fakeUI.Outputs[1]).To(Equal("[my-app] Could not find something"))
+	})
+
+	It("reports progress as prefixed lines", func() {
+		bar := ui.ProgressBar()
+		bar.Update(50, 100)
+		bar.Done()
+
+		Expect(len(fakeUI.Outputs)).To(Equal(1))
+		Expect(strings.HasPrefix(fakeUI.Outputs[0], "[my-app] Transferred 50")).To(BeTrue())
+	})
+})
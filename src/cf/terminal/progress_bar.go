@@ -0,0 +1,147 @@
+package terminal
+
+import (
+	"cf/formatters"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	progressBarWidth             = 30
+	interactiveRedrawInterval    = 100 * time.Millisecond
+	nonInteractiveReportInterval = 5 * time.Second
+)
+
+type ProgressBar interface {
+	Update(current, total int64)
+	Done()
+}
+
+type terminalProgressBar struct {
+	output      io.Writer
+	interactive bool
+	now         func() time.Time
+
+	startTime      time.Time
+	lastRenderTime time.Time
+	lastCurrent    int64
+	lastLineLength int
+}
+
+func NewProgressBar(output io.Writer, interactive bool, now func() time.Time) ProgressBar {
+	return &terminalProgressBar{
+		output:      output,
+		interactive: interactive,
+		now:         now,
+	}
+}
+
+func (bar *terminalProgressBar) Update(current, total int64) {
+	now := bar.now()
+
+	// a smaller value than last time means the upload was rewound and started over
+	if bar.startTime.IsZero() || current < bar.lastCurrent {
+		bar.startTime = now
+		bar.lastRenderTime = time.Time{}
+	}
+	bar.lastCurrent = current
+
+	finished := current >= total
+	if !finished && !bar.lastRenderTime.IsZero() && now.Sub(bar.lastRenderTime) < bar.renderInterval() {
+		return
+	}
+	bar.lastRenderTime = now
+
+	elapsed := now.Sub(bar.startTime)
+	if bar.interactive {
+		line := interactiveProgressLine(current, total, elapsed)
+		padding := ""
+		if len(line) < bar.lastLineLength {
+			padding = strings.Repeat(" ", bar.lastLineLength-len(line))
+		}
+		bar.lastLineLength = len(line)
+		fmt.Fprintf(bar.output, "\r%s%s", line, padding)
+	} else {
+		fmt.Fprintln(bar.output, progressLine(current, total, elapsed))
+	}
+}
+
+func (bar *terminalProgressBar) Done() {
+	if bar.interactive && bar.lastLineLength > 0 {
+		fmt.Fprintln(bar.output, "")
+	}
+	bar.lastLineLength = 0
+}
+
+func (bar *terminalProgressBar) renderInterval() time.Duration {
+	if bar.interactive {
+		return interactiveRedrawInterval
+	}
+	return nonInteractiveReportInterval
+}
+
+func interactiveProgressLine(current, total int64, elapsed time.Duration) string {
+	filled := int(percentage(current, total) * progressBarWidth / 100)
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar = bar + ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+
+	return fmt.Sprintf("%3d%% [%s] %s/%s %s/s ETA %s",
+		percentage(current, total),
+		bar,
+		formatters.ByteSize(uint64(current)),
+		formatters.ByteSize(uint64(total)),
+		formatters.ByteSize(uint64(throughput(current, elapsed))),
+		eta(current, total, elapsed),
+	)
+}
+
+func progressLine(current, total int64, elapsed time.Duration) string {
+	return fmt.Sprintf("Transferred %s of %s (%d%%), %s/s, ETA %s",
+		formatters.ByteSize(uint64(current)),
+		formatters.ByteSize(uint64(total)),
+		percentage(current, total),
+		formatters.ByteSize(uint64(throughput(current, elapsed))),
+		eta(current, total, elapsed),
+	)
+}
+
+func percentage(current, total int64) int64 {
+	if total <= 0 || current >= total {
+		return 100
+	}
+	return current * 100 / total
+}
+
+func throughput(current int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(current) / elapsed.Seconds()
+}
+
+func eta(current, total int64, elapsed time.Duration) string {
+	if current >= total {
+		return "0s"
+	}
+
+	bytesPerSecond := throughput(current, elapsed)
+	if bytesPerSecond == 0 {
+		return "--"
+	}
+
+	remaining := time.Duration(float64(total-current) / bytesPerSecond * float64(time.Second))
+	return (remaining / time.Second * time.Second).String()
+}
+
+func isTerminal(file *os.File) bool {
+	stat, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
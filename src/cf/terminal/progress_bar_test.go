@@ -0,0 +1,89 @@
+package terminal_test
+
+import (
+	"bytes"
+	. "cf/terminal"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+	"time"
+)
+
+var _ = Describe("ProgressBar", func() {
+	var (
+		output  *bytes.Buffer
+		now     time.Time
+		fakeNow func() time.Time
+	)
+
+	BeforeEach(func() {
+		output = &bytes.Buffer{}
+		now = time.Date(2014, time.March, 1, 12, 0, 0, 0, time.UTC)
+		fakeNow = func() time.Time { return now }
+	})
+
+	Context("when writing to a terminal", func() {
+		It("redraws a single line with percentage, throughput and eta", func() {
+			bar := NewProgressBar(output, true, fakeNow)
+
+			bar.Update(0, 4*1024*1024)
+			now = now.Add(2 * time.Second)
+			bar.Update(2*1024*1024, 4*1024*1024)
+
+			lines := strings.Split(output.String(), "\r")
+			lastLine := lines[len(lines)-1]
+			Expect(lastLine).To(ContainSubstring(" 50%"))
+			Expect(lastLine).To(ContainSubstring("2M/4M"))
+			Expect(lastLine).To(ContainSubstring("1M/s"))
+			Expect(lastLine).To(ContainSubstring("ETA 2s"))
+			Expect(output.String()).NotTo(ContainSubstring("\n"))
+
+			bar.Done()
+			Expect(strings.HasSuffix(output.String(), "\n")).To(BeTrue())
+		})
+
+		It("does not redraw more often than necessary", func() {
+			bar := NewProgressBar(output, true, fakeNow)
+
+			bar.Update(1, 100)
+			bar.Update(2, 100)
+			bar.Update(3, 100)
+
+			Expect(strings.Count(output.String(), "\r")).To(Equal(1))
+		})
+	})
+
+	Context("when not writing to a terminal", func() {
+		It("prints periodic progress lines", func() {
+			bar := NewProgressBar(output, false, fakeNow)
+
+			bar.Update(0, 100)
+			now = now.Add(time.Second)
+			bar.Update(10, 100)
+			now = now.Add(5 * time.Second)
+			bar.Update(60, 100)
+			bar.Update(100, 100)
+			bar.Done()
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			Expect(len(lines)).To(Equal(3))
+			Expect(lines[0]).To(ContainSubstring("Transferred 0 of 100 (0%)"))
+			Expect(lines[1]).To(ContainSubstring("(60%)"))
+			Expect(lines[2]).To(ContainSubstring("(100%)"))
+			Expect(output.String()).NotTo(ContainSubstring("\r"))
+		})
+	})
+
+	It("starts over when the upload is rewound", func() {
+		bar := NewProgressBar(output, false, fakeNow)
+
+		bar.Update(0, 100)
+		now = now.Add(10 * time.Second)
+		bar.Update(80, 100)
+		bar.Update(5, 100)
+
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		Expect(lines[len(lines)-1]).To(ContainSubstring("(5%)"))
+		Expect(lines[len(lines)-1]).To(ContainSubstring("ETA --"))
+	})
+})
@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type ColoringFunction func(value string, row int, col int) string
@@ -34,6 +35,8 @@ type UI interface {
 	Wait(duration time.Duration)
 	DisplayTable(table [][]string)
 	Table(headers []string) Table
+	ProgressBar() ProgressBar
+	Dashboard() Dashboard
 }
 
 type terminalUI struct {
@@ -154,14 +157,28 @@ func (ui terminalUI) Table(headers []string) Table {
 	return NewTable(ui, headers)
 }
 
+func (ui terminalUI) ProgressBar() ProgressBar {
+	return NewProgressBar(os.Stdout, isTerminal(os.Stdout), time.Now)
+}
+
+func (ui terminalUI) Dashboard() Dashboard {
+	return NewDashboard(os.Stdout, os.Stdin, isTerminal(os.Stdout))
+}
+
 func (ui terminalUI) DisplayTable(table [][]string) {
+	for _, line := range FormatTable(table) {
+		fmt.Println(line)
+	}
+}
 
+// FormatTable lines up the columns of table and colors its header, returning a line for each row.
+func FormatTable(table [][]string) (lines []string) {
 	columnCount := len(table[0])
 	maxSizes := make([]int, columnCount)
 
 	for _, line := range table {
 		for index, value := range line {
-			cellLength := len(decolorize(value))
+			cellLength := utf8.RuneCountInString(decolorize(value))
 			if maxSizes[index] < cellLength {
 				maxSizes[index] = cellLength
 			}
@@ -169,13 +186,15 @@ func (ui terminalUI) DisplayTable(table [][]string) {
 	}
 
 	for row, line := range table {
+		output := ""
 		for col, value := range line {
-			padding := strings.Repeat(" ", maxSizes[col]-len(decolorize(value)))
+			padding := strings.Repeat(" ", maxSizes[col]-utf8.RuneCountInString(decolorize(value)))
 			value = tableColoringFunc(value, row, col)
-			fmt.Printf("%s%s   ", value, padding)
+			output += fmt.Sprintf("%s%s   ", value, padding)
 		}
-		fmt.Print("\n")
+		lines = append(lines, output)
 	}
+	return
 }
 
 func tableColoringFunc(value string, row int, col int) string {
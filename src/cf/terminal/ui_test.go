@@ -15,6 +15,23 @@ import (
 )
 
 var _ = Describe("UI", func() {
+	Describe("formatting tables", func() {
+		It("lines up the columns, counting characters rather than bytes", func() {
+			os.Setenv("CF_COLOR", "false")
+			lines := FormatTable([][]string{
+				{"name", "history"},
+				{"#0", "▁▅█"},
+				{"#10", "▁"},
+			})
+
+			Expect(lines).To(Equal([]string{
+				"name   history   ",
+				"#0     ▁▅█       ",
+				"#10    ▁         ",
+			}))
+		})
+	})
+
 	Describe("Printing message to stdout with Say", func() {
 		It("prints strings", func() {
 			simulateStdin("", func(reader io.Reader) {
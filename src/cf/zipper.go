@@ -2,14 +2,20 @@ package cf
 
 import (
 	"archive/zip"
+	"cf/models"
 	"errors"
 	"fileutils"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type Zipper interface {
 	Zip(dirToZip string, targetFile *os.File) (err error)
+	ZipFiles(dir string, files []models.AppFileFields, destination io.Writer) (err error)
+	Unzip(zipFile string, destDir string) (err error)
 }
 
 type ApplicationZipper struct{}
@@ -67,3 +73,86 @@ func writeZipFile(dir string, targetFile *os.File) (err error) {
 
 	return
 }
+
+// ZipFiles writes a zip of the given files of dir to destination without copying them anywhere
+// first. Like the copies that used to be zipped, the files only keep their executable bits.
+func (zipper ApplicationZipper) ZipFiles(dir string, files []models.AppFileFields, destination io.Writer) (err error) {
+	writer := zip.NewWriter(destination)
+
+	for _, file := range files {
+		err = addFileToZip(writer, dir, file.Path)
+		if err != nil {
+			writer.Close()
+			return
+		}
+	}
+
+	return writer.Close()
+}
+
+func addFileToZip(writer *zip.Writer, dir, fileName string) (err error) {
+	fullPath := filepath.Join(dir, fileName)
+	fileInfo, err := os.Stat(fullPath)
+	if err != nil {
+		return
+	}
+
+	header, err := zip.FileInfoHeader(fileInfo)
+	if err != nil {
+		return
+	}
+	header.Name = filepath.ToSlash(fileName)
+	header.SetMode(0644 | fileInfo.Mode()&0111)
+
+	zipFilePart, err := writer.CreateHeader(header)
+	if err != nil {
+		return
+	}
+
+	return fileutils.CopyPathToWriter(fullPath, zipFilePart)
+}
+
+// Unzip extracts the files of zipFile into destDir, keeping their executable bits. Entries that
+// would end up outside of destDir are refused.
+func (zipper ApplicationZipper) Unzip(zipFile string, destDir string) (err error) {
+	reader, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+
+	for _, f := range reader.File {
+		// Don't try to extract directories
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		destFilePath := filepath.Join(destDir, filepath.FromSlash(f.Name))
+		if !strings.HasPrefix(destFilePath, filepath.Clean(destDir)+string(filepath.Separator)) {
+			err = fmt.Errorf("Zip file entry %s is outside of the target directory", f.Name)
+			return
+		}
+
+		err = extractZipFile(f, destFilePath)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func extractZipFile(f *zip.File, destFilePath string) (err error) {
+	var rc io.ReadCloser
+	rc, err = f.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+
+	err = fileutils.CopyReaderToPath(rc, destFilePath)
+	if err != nil {
+		return
+	}
+
+	return fileutils.SetExecutableBits(destFilePath, f.FileInfo())
+}
@@ -4,10 +4,12 @@ import (
 	"archive/zip"
 	"bytes"
 	. "cf"
+	"cf/models"
 	"fileutils"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 )
@@ -130,4 +132,69 @@ var _ = Describe("Testing with ginkgo", func() {
 			})
 		})
 	})
+	It("TestUnzipExtractsFilesIntoTheDirectory", func() {
+		fileutils.TempDir("unzip_test", func(dir string, err error) {
+			workingDir, err := os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+
+			zipper := ApplicationZipper{}
+			err = zipper.Unzip(filepath.Join(workingDir, "../fixtures/example-app.zip"), dir)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = os.Stat(filepath.Join(dir, "Gemfile"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	It("TestUnzipRefusesEntriesOutsideOfTheDirectory", func() {
+		fileutils.TempFile("unzip_test", func(zipFile *os.File, err error) {
+			writer := zip.NewWriter(zipFile)
+			_, err = writer.Create("../escaped.txt")
+			Expect(err).NotTo(HaveOccurred())
+			writer.Close()
+
+			fileutils.TempDir("unzip_test", func(dir string, err error) {
+				zipper := ApplicationZipper{}
+				err = zipper.Unzip(zipFile.Name(), dir)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("outside of the target directory"))
+			})
+		})
+	})
+	It("TestZipFilesOnlyZipsTheGivenFilesKeepingTheirExecutableBits", func() {
+		fileutils.TempDir("zip_files_test", func(dir string, err error) {
+			err = ioutil.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo hi"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+			err = os.MkdirAll(filepath.Join(dir, "lib"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(dir, "lib", "util.rb"), []byte("puts 'hi'"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(dir, "matched.rb"), []byte("known"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			fileutils.TempFile("zip_files_test", func(zipFile *os.File, err error) {
+				zipper := ApplicationZipper{}
+				err = zipper.ZipFiles(dir, []models.AppFileFields{
+					{Path: "run.sh"},
+					{Path: filepath.Join("lib", "util.rb")},
+				}, zipFile)
+				Expect(err).NotTo(HaveOccurred())
+
+				fileStat, err := zipFile.Stat()
+				Expect(err).NotTo(HaveOccurred())
+				reader, err := zip.NewReader(zipFile, fileStat.Size())
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(len(reader.File)).To(Equal(2))
+				Expect(reader.File[0].Name).To(Equal("run.sh"))
+				Expect(reader.File[0].Mode()).To(Equal(os.FileMode(0755)))
+				Expect(reader.File[1].Name).To(Equal("lib/util.rb"))
+				Expect(reader.File[1].Mode()).To(Equal(os.FileMode(0644)))
+
+				contents, err := reader.File[1].Open()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ioutil.ReadAll(contents)).To(Equal([]byte("puts 'hi'")))
+			})
+		})
+	})
 })
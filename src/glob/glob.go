@@ -12,6 +12,7 @@ import (
 //  - `?` matches a single char in a single path component
 //  - `*` matches zero or more chars in a single path component
 //  - `**` matches zero or more chars in zero or more components
+//  - `[...]` matches a single char from a class, `[!...]` one not in it
 //  - any other sequence matches itself
 type Glob struct {
 	Pattern string         // original glob pattern
@@ -30,40 +31,95 @@ var globRe = mustBuildRe(`(` + charPat + `|[\*\?])`)
 // Supports unix/ruby-style glob patterns:
 //  - `?` matches a single char in a single path component
 //  - `*` matches zero or more chars in a single path component
-//  - `**` matches zero or more chars in zero or more components
+//  - `**` matches zero or more chars in zero or more components, and `**/`
+//    at the start of a component also matches no components at all
+//  - `[abc]`, `[a-z]` and `[!a-z]` match a single char in a single path
+//    component that is, or with `!` (or `^`) is not, in the class
 func translateGlob(pat string) (string, error) {
 	if !globRe.MatchString(pat) {
 		return "", GlobError(pat)
 	}
 
-	outs := make([]string, len(pat))
-	i, double := 0, false
-	for _, c := range pat {
+	runes := []rune(pat)
+	outs := make([]string, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
 		switch c {
 		default:
-			outs[i] = string(c)
-			double = false
-		case '.', '+', '-', '^', '$', '[', ']', '(', ')':
-			outs[i] = `\` + string(c)
-			double = false
+			outs = append(outs, string(c))
+		case '.', '+', '-', '^', '$', ']', '(', ')', '{', '}', '|':
+			outs = append(outs, `\`+string(c))
+		case '[':
+			class, length := translateCharClass(runes[i:])
+			if length == 0 {
+				outs = append(outs, `\[`)
+				continue
+			}
+			outs = append(outs, class)
+			i += length - 1
 		case '?':
-			outs[i] = `[^/]`
-			double = false
+			outs = append(outs, `[^/]`)
 		case '*':
-			if double {
-				outs[i-1] = `.*`
-			} else {
-				outs[i] = `[^/]*`
+			if i+1 >= len(runes) || runes[i+1] != '*' {
+				outs = append(outs, `[^/]*`)
+				continue
+			}
+			i++
+			startsComponent := i == 1 || runes[i-2] == '/'
+			if startsComponent && i+1 < len(runes) && runes[i+1] == '/' {
+				outs = append(outs, `(.*/)?`)
+				i++
+				continue
 			}
-			double = !double
+			outs = append(outs, `.*`)
 		}
-		i++
 	}
-	outs = outs[0:i]
 
 	return "^" + strings.Join(outs, "") + "$", nil
 }
 
+// translateCharClass translates the bracket expression at the start of runes, returning the
+// number of runes it takes up, or 0 when it is not closed and so is not a bracket expression.
+func translateCharClass(runes []rune) (class string, length int) {
+	i := 1
+	negated := false
+	if i < len(runes) && (runes[i] == '!' || runes[i] == '^') {
+		negated = true
+		i++
+	}
+
+	members := []string{}
+	for first := true; i < len(runes); i++ {
+		c := runes[i]
+		if c == ']' && !first {
+			break
+		}
+		first = false
+
+		switch c {
+		case '\\', '[', ']', '^':
+			members = append(members, `\`+string(c))
+		case '/':
+			// a class never matches the separator between path components
+		default:
+			members = append(members, string(c))
+		}
+	}
+	if i >= len(runes) {
+		return
+	}
+
+	length = i + 1
+	if negated {
+		class = "[^/" + strings.Join(members, "") + "]"
+	} else if len(members) == 0 {
+		class = "[^\\s\\S]"
+	} else {
+		class = "[" + strings.Join(members, "") + "]"
+	}
+	return
+}
+
 // CompileGlob translates pat into a form more convenient for
 // matching against paths in the store.
 func CompileGlob(pat string) (glob Glob, err error) {
@@ -20,7 +20,11 @@ var globs = [][]string{
 	{"/a*a/b", `^/a[^/]*a/b$`},
 	{"/*a*/b", `^/[^/]*a[^/]*/b$`},
 	{"/**", `^/.*$`},
-	{"/**/a", `^/.*/a$`},
+	{"/**/a", `^/(.*/)?a$`},
+	{"/a**/b", `^/a.*/b$`},
+	{"/[ab]", `^/[ab]$`},
+	{"/[!a-c]", `^/[^/a-c]$`},
+	{"/[a", `^/\[a$`},
 }
 
 var matches = [][]string{
@@ -30,6 +34,12 @@ var matches = [][]string{
 	{"/a**", "/a", "/ab", "/abc", "/a/", "/a/b", "/ab/c"},
 	{`c:\a\b\.d`, `c:\a\b\.d`},
 	{`c:\**\.d`, `c:\a\b\.d`},
+	{"/**/a", "/a", "/b/a", "/b/c/a"},
+	{"a/**/b", "a/b", "a/x/b", "a/x/y/b"},
+	{"*.[ch]", "main.c", "main.h"},
+	{"file[0-9]", "file1", "file9"},
+	{"file[!0-9]", "filea", "file_"},
+	{"[]]", "]"},
 }
 
 var nonMatches = [][]string{
@@ -37,6 +47,10 @@ var nonMatches = [][]string{
 	{"/a?", "/", "/abc", "/a", "/a/"},
 	{"/a*", "/", "/a/", "/ba"},
 	{"/a**", "/", "/ba"},
+	{"a/**/b", "a/bc", "ab"},
+	{"*.[ch]", "main.o", "main.cc"},
+	{"file[!0-9]", "file1", "file/"},
+	{"a[/]b", "a/b"},
 }
 
 var _ = Describe("Glob", func() {
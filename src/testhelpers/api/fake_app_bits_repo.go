@@ -1,29 +1,149 @@
 package api
 
 import (
+	"cf/models"
 	"cf/net"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
 )
 
 type FakeApplicationBitsRepository struct {
-	UploadedAppGuid string
-	UploadedDir     string
-	UploadAppErr    bool
+	UploadedAppGuid  string
+	UploadedAppGuids []string
+	UploadedDir      string
+	UploadedIgnores  []string
+	UploadAppErr     bool
+	UploadAppErrGuid string
+	UploadAppHook    func(appGuid string)
 
 	CallbackPath      string
 	CallbackZipSize   uint64
 	CallbackFileCount uint64
+
+	ProgressBytes []int64
+
+	AppFilesDir     string
+	AppFilesIgnores []string
+	AppFilesFiles   []models.AppFileFields
+
+	FilesToUploadDir     string
+	FilesToUploadIgnores []string
+	FilesToUploadFiles   []models.AppFileFields
+
+	DownloadedAppGuid     string
+	DownloadedDropletGuid string
+	DownloadContents      string
+	DownloadErr           bool
+
+	UploadedDropletGuid     string
+	UploadedDropletContents string
+	UploadDropletErr        bool
+
+	CopiedBitsSourceGuid string
+	CopiedBitsTargetGuid string
+	CopyBitsStatuses     []string
+	CopyBitsNotFound     bool
+	CopyBitsErr          bool
+
+	lock sync.Mutex
 }
 
-func (repo *FakeApplicationBitsRepository) UploadApp(appGuid, dir string, cb func(path string, zipSize, fileCount uint64)) (apiResponse net.ApiResponse) {
+func (repo *FakeApplicationBitsRepository) UploadApp(appGuid, dir string, ignoreFiles []string, cb func(path string, zipSize, fileCount uint64), progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	repo.lock.Lock()
 	repo.UploadedDir = dir
+	repo.UploadedIgnores = ignoreFiles
 	repo.UploadedAppGuid = appGuid
+	repo.UploadedAppGuids = append(repo.UploadedAppGuids, appGuid)
+	repo.lock.Unlock()
+
+	if repo.UploadAppHook != nil {
+		repo.UploadAppHook(appGuid)
+	}
 
-	if repo.UploadAppErr {
+	if repo.UploadAppErr || (repo.UploadAppErrGuid != "" && repo.UploadAppErrGuid == appGuid) {
 		apiResponse = net.NewApiResponseWithMessage("Error uploading app")
 		return
 	}
 
 	cb(repo.CallbackPath, repo.CallbackZipSize, repo.CallbackFileCount)
 
+	for _, bytesRead := range repo.ProgressBytes {
+		progressCb(bytesRead, repo.ProgressBytes[len(repo.ProgressBytes)-1])
+	}
+
+	return
+}
+
+func (repo *FakeApplicationBitsRepository) AppFiles(dir string, ignoreFiles []string) (files []models.AppFileFields, apiResponse net.ApiResponse) {
+	repo.AppFilesDir = dir
+	repo.AppFilesIgnores = ignoreFiles
+	files = repo.AppFilesFiles
+	return
+}
+
+func (repo *FakeApplicationBitsRepository) FilesToUpload(dir string, ignoreFiles []string) (files []models.AppFileFields, apiResponse net.ApiResponse) {
+	repo.FilesToUploadDir = dir
+	repo.FilesToUploadIgnores = ignoreFiles
+	files = repo.FilesToUploadFiles
+	return
+}
+
+func (repo *FakeApplicationBitsRepository) DownloadApp(appGuid string, destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	repo.DownloadedAppGuid = appGuid
+	return repo.download(destination, progressCb)
+}
+
+func (repo *FakeApplicationBitsRepository) DownloadDroplet(appGuid string, destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	repo.DownloadedDropletGuid = appGuid
+	return repo.download(destination, progressCb)
+}
+
+func (repo *FakeApplicationBitsRepository) download(destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	if repo.DownloadErr {
+		apiResponse = net.NewApiResponseWithMessage("Error downloading")
+		return
+	}
+
+	io.WriteString(destination, repo.DownloadContents)
+	size := int64(len(repo.DownloadContents))
+	progressCb(size, size)
+	return
+}
+
+func (repo *FakeApplicationBitsRepository) UploadDroplet(appGuid string, droplet *os.File, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	if repo.UploadDropletErr {
+		apiResponse = net.NewApiResponseWithMessage("Error uploading droplet")
+		return
+	}
+
+	contents, err := ioutil.ReadAll(droplet)
+	if err != nil {
+		apiResponse = net.NewApiResponseWithError("Error reading droplet", err)
+		return
+	}
+
+	repo.UploadedDropletGuid = appGuid
+	repo.UploadedDropletContents = string(contents)
+	return
+}
+
+func (repo *FakeApplicationBitsRepository) CopyBits(sourceAppGuid, targetAppGuid string, statusCb func(status string)) (apiResponse net.ApiResponse) {
+	repo.CopiedBitsSourceGuid = sourceAppGuid
+	repo.CopiedBitsTargetGuid = targetAppGuid
+
+	if repo.CopyBitsNotFound {
+		apiResponse = net.NewNotFoundApiResponse("Unknown request")
+		return
+	}
+	if repo.CopyBitsErr {
+		apiResponse = net.NewApiResponseWithMessage("Error copying bits")
+		return
+	}
+
+	for _, status := range repo.CopyBitsStatuses {
+		statusCb(status)
+	}
 	return
 }
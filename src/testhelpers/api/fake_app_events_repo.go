@@ -9,12 +9,72 @@ type FakeAppEventsRepo struct {
 	AppGuid     string
 	Events      []models.EventFields
 	ApiResponse net.ApiResponse
+
+	// AppGuids and Queries are those of every call, in order.
+	AppGuids []string
+	Queries  []models.EventQuery
+
+	// EventsByApp, when set, is used instead of Events.
+	EventsByApp map[string][]models.EventFields
+
+	// EventPolls, when set, are listed one per call instead of Events, the last one for every
+	// call after it.
+	EventPolls [][]models.EventFields
+
+	// PollApiResponses, when set, are returned one per call instead of ApiResponse, which is
+	// returned for the calls after them.
+	PollApiResponses []net.ApiResponse
+
+	SpaceGuid        string
+	SpaceQuery       models.EventQuery
+	SpaceEvents      []FakeSpaceEvent
+	SpaceApiResponse net.ApiResponse
+}
+
+type FakeSpaceEvent struct {
+	AppName string
+	Event   models.EventFields
+}
+
+func (repo *FakeAppEventsRepo) ListSpaceEvents(spaceGuid string, query models.EventQuery, cb func(appName string, event models.EventFields) bool) net.ApiResponse {
+	repo.SpaceGuid = spaceGuid
+	repo.SpaceQuery = query
+
+	if repo.SpaceApiResponse.IsNotSuccessful() {
+		return repo.SpaceApiResponse
+	}
+	for _, e := range repo.SpaceEvents {
+		if !cb(e.AppName, e.Event) {
+			break
+		}
+	}
+	return repo.SpaceApiResponse
 }
 
-func (repo FakeAppEventsRepo) ListEvents(appGuid string, cb func(models.EventFields) bool) net.ApiResponse {
+func (repo *FakeAppEventsRepo) ListEvents(appGuid string, query models.EventQuery, cb func(models.EventFields) bool) net.ApiResponse {
 	repo.AppGuid = appGuid
-	for _, e := range repo.Events {
-		cb(e)
+	repo.AppGuids = append(repo.AppGuids, appGuid)
+	repo.Queries = append(repo.Queries, query)
+
+	events := repo.Events
+	switch {
+	case repo.EventsByApp != nil:
+		events = repo.EventsByApp[appGuid]
+	case len(repo.EventPolls) > 0:
+		poll := len(repo.Queries) - 1
+		if poll >= len(repo.EventPolls) {
+			poll = len(repo.EventPolls) - 1
+		}
+		events = repo.EventPolls[poll]
+	}
+
+	for _, e := range events {
+		if !cb(e) {
+			break
+		}
+	}
+	if call := len(repo.Queries) - 1; call < len(repo.PollApiResponses) {
+		return repo.PollApiResponses[call]
 	}
 	return repo.ApiResponse
 }
@@ -1,20 +1,118 @@
 package api
 
 import (
+	realApi "cf/api"
 	"cf/net"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
 )
 
 type FakeAppFilesRepo struct {
 	AppGuid  string
+	Instance int
 	Path     string
 	FileList string
+
+	// Listings and Files are looked up by path when they are set, paths without an entry are not
+	// found.
+	Listings map[string]string
+	Files    map[string]string
+
+	DownloadedPaths []string
+
+	// RangeFileVersions are the contents of the file read by ReadFileRange, one for every read,
+	// the last one is kept once they run out. A RangeErrorCodes entry of "404" makes a read find
+	// no file, any other code fails it.
+	RangeFileVersions []string
+	RangeErrorCodes   []string
+	RangeOffsets      []int64
+
+	lock sync.Mutex
 }
 
-func (repo *FakeAppFilesRepo) ListFiles(appGuid, path string) (files string, apiResponse net.ApiResponse) {
+func (repo *FakeAppFilesRepo) ListFiles(appGuid string, instance int, path string) (files string, apiResponse net.ApiResponse) {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
 	repo.AppGuid = appGuid
+	repo.Instance = instance
 	repo.Path = path
 
-	files = repo.FileList
+	if repo.Listings == nil {
+		files = repo.FileList
+		return
+	}
+
+	files, found := repo.Listings[path]
+	if !found {
+		apiResponse = net.NewNotFoundApiResponse("Directory %s not found", path)
+	}
+	return
+}
+
+func (repo *FakeAppFilesRepo) DownloadFile(appGuid string, instance int, path string, destination io.Writer, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	repo.AppGuid = appGuid
+	repo.Instance = instance
+	repo.DownloadedPaths = append(repo.DownloadedPaths, path)
+	sort.Strings(repo.DownloadedPaths)
+
+	contents, found := repo.Files[path]
+	if !found {
+		apiResponse = net.NewNotFoundApiResponse("File %s not found", path)
+		return
+	}
+
+	io.WriteString(destination, contents)
+	return
+}
+
+func (repo *FakeAppFilesRepo) ReadFileRange(appGuid string, instance int, path string, offset int64) (fileRange realApi.FileRange, apiResponse net.ApiResponse) {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	repo.AppGuid = appGuid
+	repo.Instance = instance
+	repo.Path = path
+	repo.RangeOffsets = append(repo.RangeOffsets, offset)
+
+	contents := ""
+	if len(repo.RangeFileVersions) > 0 {
+		contents = repo.RangeFileVersions[0]
+		if len(repo.RangeFileVersions) > 1 {
+			repo.RangeFileVersions = repo.RangeFileVersions[1:]
+		}
+	}
+
+	if len(repo.RangeErrorCodes) > 0 {
+		errorCode := repo.RangeErrorCodes[0]
+		repo.RangeErrorCodes = repo.RangeErrorCodes[1:]
+		switch errorCode {
+		case "":
+		case "404":
+			apiResponse = net.NewNotFoundApiResponse("File %s not found", path)
+			return
+		default:
+			apiResponse = net.NewApiResponse("Error reading file", errorCode, http.StatusInternalServerError)
+			return
+		}
+	}
 
+	size := int64(len(contents))
+	fileRange = realApi.FileRange{Offset: offset, Size: size}
+	if offset < 0 {
+		fileRange.Offset = size + offset
+		if fileRange.Offset < 0 {
+			fileRange.Offset = 0
+		}
+	} else if offset >= size {
+		fileRange.PastEnd = true
+		return
+	}
+	fileRange.Contents = []byte(contents[fileRange.Offset:])
 	return
 }
@@ -11,6 +11,10 @@ type FakeAppInstancesRepo struct {
 	GetInstancesAppGuid    string
 	GetInstancesResponses  [][]models.AppInstanceFields
 	GetInstancesErrorCodes []string
+
+	DeleteInstanceAppGuid  string
+	DeleteInstanceIndexes  []int
+	DeleteInstanceResponse net.ApiResponse
 }
 
 func (repo *FakeAppInstancesRepo) GetInstances(appGuid string) (instances []models.AppInstanceFields, apiResponse net.ApiResponse) {
@@ -32,3 +36,10 @@ func (repo *FakeAppInstancesRepo) GetInstances(appGuid string) (instances []mode
 
 	return
 }
+
+func (repo *FakeAppInstancesRepo) DeleteInstance(appGuid string, index int) (apiResponse net.ApiResponse) {
+	repo.DeleteInstanceAppGuid = appGuid
+	repo.DeleteInstanceIndexes = append(repo.DeleteInstanceIndexes, index)
+	apiResponse = repo.DeleteInstanceResponse
+	return
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"cf/models"
 	"cf/net"
+	"sync"
 )
 
 type FakeApplicationRepository struct {
@@ -14,6 +15,12 @@ type FakeApplicationRepository struct {
 	ReadAuthErr  bool
 	ReadNotFound bool
 
+	// ReadAppsByName, when set, is used instead of ReadApp, and names missing from it are not found
+	ReadAppsByName map[string]models.Application
+
+	// ReadFromSpaceApps has the apps ReadFromSpace finds by space guid and then by name
+	ReadFromSpaceApps map[string]map[string]models.Application
+
 	CreateAppParams []models.AppParams
 
 	UpdateParams    models.AppParams
@@ -21,13 +28,32 @@ type FakeApplicationRepository struct {
 	UpdateAppResult models.Application
 	UpdateErr       bool
 
-	DeletedAppGuid string
+	ReadEnvAppGuid string
+	ReadEnvResult  models.AppEnvironment
+	ReadEnvErr     bool
+
+	DeletedAppGuid  string
+	DeletedAppGuids []string
+
+	lock sync.Mutex
 }
 
 func (repo *FakeApplicationRepository) Read(name string) (app models.Application, apiResponse net.ApiResponse) {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
 	repo.ReadName = name
 	app = repo.ReadApp
 
+	if repo.ReadAppsByName != nil {
+		var found bool
+		app, found = repo.ReadAppsByName[name]
+		if !found {
+			apiResponse = net.NewNotFoundApiResponse("%s %s not found", "App", name)
+		}
+		return
+	}
+
 	if repo.ReadErr {
 		apiResponse = net.NewApiResponseWithMessage("Error finding app by name.")
 	}
@@ -41,6 +67,17 @@ func (repo *FakeApplicationRepository) Read(name string) (app models.Application
 	return
 }
 
+func (repo *FakeApplicationRepository) ReadFromSpace(name string, spaceGuid string) (app models.Application, apiResponse net.ApiResponse) {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	app, found := repo.ReadFromSpaceApps[spaceGuid][name]
+	if !found {
+		apiResponse = net.NewNotFoundApiResponse("%s %s not found", "App", name)
+	}
+	return
+}
+
 func (repo *FakeApplicationRepository) CreatedAppParams() (params models.AppParams) {
 	if len(repo.CreateAppParams) > 0 {
 		params = repo.CreateAppParams[0]
@@ -49,6 +86,9 @@ func (repo *FakeApplicationRepository) CreatedAppParams() (params models.AppPara
 }
 
 func (repo *FakeApplicationRepository) Create(params models.AppParams) (resultApp models.Application, apiResponse net.ApiResponse) {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
 	if repo.CreateAppParams == nil {
 		repo.CreateAppParams = []models.AppParams{}
 	}
@@ -95,7 +135,17 @@ func (repo *FakeApplicationRepository) Update(appGuid string, params models.AppP
 	return
 }
 
+func (repo *FakeApplicationRepository) ReadEnv(appGuid string) (env models.AppEnvironment, apiResponse net.ApiResponse) {
+	repo.ReadEnvAppGuid = appGuid
+	env = repo.ReadEnvResult
+	if repo.ReadEnvErr {
+		apiResponse = net.NewApiResponseWithMessage("Error reading env.")
+	}
+	return
+}
+
 func (repo *FakeApplicationRepository) Delete(appGuid string) (apiResponse net.ApiResponse) {
 	repo.DeletedAppGuid = appGuid
+	repo.DeletedAppGuids = append(repo.DeletedAppGuids, appGuid)
 	return
 }
@@ -12,6 +12,9 @@ type FakeAppSummaryRepo struct {
 	GetSummaryErrorCode string
 	GetSummaryAppGuid   string
 	GetSummarySummary   models.AppSummary
+
+	// GetSummarySummaries, when set, is used instead of GetSummarySummary
+	GetSummarySummaries map[string]models.AppSummary
 }
 
 func (repo *FakeAppSummaryRepo) GetSummariesInCurrentSpace() (apps []models.AppSummary, apiResponse net.ApiResponse) {
@@ -22,6 +25,9 @@ func (repo *FakeAppSummaryRepo) GetSummariesInCurrentSpace() (apps []models.AppS
 func (repo *FakeAppSummaryRepo) GetSummary(appGuid string) (summary models.AppSummary, apiResponse net.ApiResponse) {
 	repo.GetSummaryAppGuid = appGuid
 	summary = repo.GetSummarySummary
+	if repo.GetSummarySummaries != nil {
+		summary = repo.GetSummarySummaries[appGuid]
+	}
 
 	if repo.GetSummaryErrorCode != "" {
 		apiResponse = net.NewApiResponse("Error", repo.GetSummaryErrorCode, http.StatusBadRequest)
@@ -16,10 +16,11 @@ type FakeDomainRepository struct {
 	ListDomainsDomains     []models.DomainFields
 	ListDomainsApiResponse net.ApiResponse
 
-	FindByNameInOrgName      string
-	FindByNameInOrgGuid      string
-	FindByNameInOrgDomain      models.DomainFields
-	FindByNameInOrgApiResponse net.ApiResponse
+	FindByNameInOrgName          string
+	FindByNameInOrgGuid          string
+	FindByNameInOrgDomain        models.DomainFields
+	FindByNameInOrgDomainsByName map[string]models.DomainFields
+	FindByNameInOrgApiResponse   net.ApiResponse
 
 	FindByNameName     string
 	FindByNameDomain   models.DomainFields
@@ -78,6 +79,9 @@ func (repo *FakeDomainRepository) FindByNameInOrg(name string, owningOrgGuid str
 	repo.FindByNameInOrgName = name
 	repo.FindByNameInOrgGuid = owningOrgGuid
 	domain = repo.FindByNameInOrgDomain
+	if namedDomain, ok := repo.FindByNameInOrgDomainsByName[name]; ok {
+		domain = namedDomain
+	}
 	apiResponse = repo.FindByNameInOrgApiResponse
 	return
 }
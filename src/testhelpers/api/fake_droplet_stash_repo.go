@@ -0,0 +1,58 @@
+package api
+
+import (
+	"cf/models"
+	"cf/net"
+)
+
+type FakeDropletStashRepository struct {
+	Stashes []models.DropletStash
+
+	StashedApps     []models.Application
+	StashNotFound   bool
+	StashErr        bool
+	ListedAppName   string
+	RestoredAppGuid string
+	RestoredStash   models.DropletStash
+	RestoreErr      bool
+	DeletedStashes  []models.DropletStash
+}
+
+func (repo *FakeDropletStashRepository) Stash(app models.Application, progressCb net.ProgressCallback) (stash models.DropletStash, apiResponse net.ApiResponse) {
+	if repo.StashNotFound {
+		apiResponse = net.NewNotFoundApiResponse("Droplet not found")
+		return
+	}
+	if repo.StashErr {
+		apiResponse = net.NewApiResponseWithMessage("Error stashing droplet")
+		return
+	}
+
+	repo.StashedApps = append(repo.StashedApps, app)
+	stash = models.NewDropletStash(app)
+	stash.Version = len(repo.Stashes) + 1
+	repo.Stashes = append([]models.DropletStash{stash}, repo.Stashes...)
+	return
+}
+
+func (repo *FakeDropletStashRepository) ListStashes(appName string) (stashes []models.DropletStash, apiResponse net.ApiResponse) {
+	repo.ListedAppName = appName
+	stashes = repo.Stashes
+	return
+}
+
+func (repo *FakeDropletStashRepository) Restore(appGuid string, stash models.DropletStash, progressCb net.ProgressCallback) (apiResponse net.ApiResponse) {
+	if repo.RestoreErr {
+		apiResponse = net.NewApiResponseWithMessage("Error uploading droplet")
+		return
+	}
+
+	repo.RestoredAppGuid = appGuid
+	repo.RestoredStash = stash
+	return
+}
+
+func (repo *FakeDropletStashRepository) Delete(stash models.DropletStash) (apiResponse net.ApiResponse) {
+	repo.DeletedStashes = append(repo.DeletedStashes, stash)
+	return
+}
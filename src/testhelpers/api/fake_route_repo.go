@@ -16,8 +16,10 @@ type FakeRouteRepository struct {
 	FindByHostAndDomainErr      bool
 	FindByHostAndDomainNotFound bool
 
-	CreatedHost       string
-	CreatedDomainGuid string
+	CreatedHost        string
+	CreatedDomainGuid  string
+	CreatedHosts       []string
+	CreatedDomainGuids []string
 
 	CreateInSpaceHost         string
 	CreateInSpaceDomainGuid   string
@@ -25,11 +27,13 @@ type FakeRouteRepository struct {
 	CreateInSpaceCreatedRoute models.Route
 	CreateInSpaceErr          bool
 
-	BoundRouteGuid string
-	BoundAppGuid   string
+	BoundRouteGuid  string
+	BoundAppGuid    string
+	BoundRouteGuids []string
 
-	UnboundRouteGuid string
-	UnboundAppGuid   string
+	UnboundRouteGuid  string
+	UnboundAppGuid    string
+	UnboundRouteGuids []string
 
 	ListErr bool
 	Routes  []models.Route
@@ -80,6 +84,8 @@ func (repo *FakeRouteRepository) FindByHostAndDomain(host, domain string) (route
 func (repo *FakeRouteRepository) Create(host, domainGuid string) (createdRoute models.Route, apiResponse net.ApiResponse) {
 	repo.CreatedHost = host
 	repo.CreatedDomainGuid = domainGuid
+	repo.CreatedHosts = append(repo.CreatedHosts, host)
+	repo.CreatedDomainGuids = append(repo.CreatedDomainGuids, domainGuid)
 
 	createdRoute.Guid = host + "-route-guid"
 
@@ -103,12 +109,14 @@ func (repo *FakeRouteRepository) CreateInSpace(host, domainGuid, spaceGuid strin
 func (repo *FakeRouteRepository) Bind(routeGuid, appGuid string) (apiResponse net.ApiResponse) {
 	repo.BoundRouteGuid = routeGuid
 	repo.BoundAppGuid = appGuid
+	repo.BoundRouteGuids = append(repo.BoundRouteGuids, routeGuid)
 	return
 }
 
 func (repo *FakeRouteRepository) Unbind(routeGuid, appGuid string) (apiResponse net.ApiResponse) {
 	repo.UnboundRouteGuid = routeGuid
 	repo.UnboundAppGuid = appGuid
+	repo.UnboundRouteGuids = append(repo.UnboundRouteGuids, routeGuid)
 	return
 }
 
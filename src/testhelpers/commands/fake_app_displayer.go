@@ -1,7 +1,9 @@
 package commands
 
 import (
+	"cf/commands/application"
 	"cf/models"
+	"cf/terminal"
 )
 
 type FakeAppDisplayer struct {
@@ -11,3 +13,7 @@ type FakeAppDisplayer struct {
 func (displayer *FakeAppDisplayer) ShowApp(app models.Application) {
 	displayer.AppToDisplay = app
 }
+
+func (displayer *FakeAppDisplayer) WithUI(ui terminal.UI) application.ApplicationDisplayer {
+	return displayer
+}
@@ -0,0 +1,20 @@
+package commands
+
+import (
+	"cf/models"
+)
+
+type FakeAppInstanceRestarter struct {
+	AppToRestart   models.Application
+	IndexToRestart int
+	AppToRollOut   models.Application
+}
+
+func (restarter *FakeAppInstanceRestarter) ApplicationRestartInstance(app models.Application, index int) {
+	restarter.AppToRestart = app
+	restarter.IndexToRestart = index
+}
+
+func (restarter *FakeAppInstanceRestarter) ApplicationRollingRestart(app models.Application) {
+	restarter.AppToRollOut = app
+}
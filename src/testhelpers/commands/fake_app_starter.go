@@ -1,16 +1,27 @@
 package commands
 
 import (
+	"cf/commands/application"
 	"cf/models"
+	"cf/terminal"
+	"sync"
 )
 
 type FakeAppStarter struct {
-	AppToStart models.Application
-	Timeout    int
+	AppToStart  models.Application
+	AppsStarted []models.Application
+	Timeout     int
+	UIs         []terminal.UI
+
+	lock sync.Mutex
 }
 
 func (starter *FakeAppStarter) ApplicationStart(appToStart models.Application) (startedApp models.Application, err error) {
+	starter.lock.Lock()
+	defer starter.lock.Unlock()
+
 	starter.AppToStart = appToStart
+	starter.AppsStarted = append(starter.AppsStarted, appToStart)
 	startedApp = appToStart
 	return
 }
@@ -19,6 +30,14 @@ func (starter *FakeAppStarter) SetStartTimeoutSeconds(timeout int) {
 	starter.Timeout = timeout
 }
 
+func (starter *FakeAppStarter) WithUI(ui terminal.UI) application.ApplicationStarter {
+	starter.lock.Lock()
+	defer starter.lock.Unlock()
+
+	starter.UIs = append(starter.UIs, ui)
+	return starter
+}
+
 func (starter *FakeAppStarter) ApplicationStartWithBuildpack(app models.Application, buildpackUrl string) (startedApp models.Application, err error) {
 	starter.AppToStart = app
 	startedApp = app
@@ -19,6 +19,12 @@ type FakeUI struct {
 	FailedWithUsage            bool
 	FailedWithUsageCommandName string
 	ShowConfigurationCalled    bool
+	ProgressBarUpdates         []int64
+	ProgressBarDone            bool
+	DashboardFrames            [][]string
+	DashboardWaits             []time.Duration
+	DashboardQuitAfterFrames   int
+	DashboardClosed            bool
 }
 
 func (ui *FakeUI) PrintPaginator(rows []string, err error) {
@@ -128,3 +134,41 @@ func (ui *FakeUI) DisplayTable(table [][]string) {
 func (ui *FakeUI) Table(headers []string) term.Table {
 	return term.NewTable(ui, headers)
 }
+
+func (ui *FakeUI) ProgressBar() term.ProgressBar {
+	return &FakeProgressBar{ui: ui}
+}
+
+type FakeProgressBar struct {
+	ui *FakeUI
+}
+
+func (bar *FakeProgressBar) Update(current, total int64) {
+	bar.ui.ProgressBarUpdates = append(bar.ui.ProgressBarUpdates, current)
+}
+
+func (bar *FakeProgressBar) Done() {
+	bar.ui.ProgressBarDone = true
+}
+
+func (ui *FakeUI) Dashboard() term.Dashboard {
+	return &FakeDashboard{ui: ui}
+}
+
+// FakeDashboard quits once DashboardQuitAfterFrames screens were drawn, or after the first one.
+type FakeDashboard struct {
+	ui *FakeUI
+}
+
+func (dashboard *FakeDashboard) Draw(lines []string) {
+	dashboard.ui.DashboardFrames = append(dashboard.ui.DashboardFrames, lines)
+}
+
+func (dashboard *FakeDashboard) WaitForQuit(duration time.Duration) bool {
+	dashboard.ui.DashboardWaits = append(dashboard.ui.DashboardWaits, duration)
+	return len(dashboard.ui.DashboardFrames) >= dashboard.ui.DashboardQuitAfterFrames
+}
+
+func (dashboard *FakeDashboard) Close() {
+	dashboard.ui.DashboardClosed = true
+}
@@ -0,0 +1,20 @@
+package time
+
+import (
+	"time"
+)
+
+// FakeClock only moves forward when it is slept on, or when a test sets CurrentTime.
+type FakeClock struct {
+	CurrentTime time.Time
+	Sleeps      []time.Duration
+}
+
+func (clock *FakeClock) Now() time.Time {
+	return clock.CurrentTime
+}
+
+func (clock *FakeClock) Sleep(duration time.Duration) {
+	clock.Sleeps = append(clock.Sleeps, duration)
+	clock.CurrentTime = clock.CurrentTime.Add(duration)
+}